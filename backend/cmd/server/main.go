@@ -1,47 +1,79 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
 	"log"
 	"net/http"
-	"os"
-	"strconv"
+	"os/signal"
+	"syscall"
 	"time"
+	_ "time/tzdata" // Schedule triggers name IANA time zones; do not depend on the host's copy
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/hungaikev/rootd/backend/internal/api/handlers"
+	"github.com/hungaikev/rootd/backend/internal/api/middleware"
+	"github.com/hungaikev/rootd/backend/internal/auth"
+	"github.com/hungaikev/rootd/backend/internal/config"
 	"github.com/hungaikev/rootd/backend/internal/db"
 	"github.com/hungaikev/rootd/backend/internal/logic"
+	"github.com/hungaikev/rootd/backend/internal/worker"
 )
 
 func main() {
-	// Get database configuration from environment variables
-	cfg := db.ServiceConfig{
-		Host:     getEnv("DB_HOST", "localhost"),
-		Port:     getEnvAsInt("DB_PORT", 5432),
-		User:     getEnv("DB_USER", "postgres"),
-		Password: getEnv("DB_PASSWORD", "password"),
-		DBName:   getEnv("DB_NAME", "rootd"),
-		SSLMode:  getEnv("DB_SSLMODE", "disable"),
-	}
+	cfg := config.Load()
 
 	// Create database service
-	dbService, err := db.NewService(cfg)
+	dbService, err := db.NewService(cfg.Database)
 	if err != nil {
 		log.Fatal("Failed to create database service:", err)
 	}
 	defer dbService.Close()
 
-	// Run migrations
-	if err := dbService.RunMigrations(nil); err != nil {
-		log.Fatal("Failed to run migrations:", err)
+	// Run migrations unless they are applied separately with cmd/migrate
+	if cfg.AutoMigrate {
+		if err := dbService.RunMigrations(nil); err != nil {
+			log.Fatal("Failed to run migrations:", err)
+		}
+	}
+
+	// Create the session token manager
+	authSecret := cfg.Server.AuthSecret
+	if len(authSecret) == 0 {
+		log.Println("AUTH_SECRET is not set; using a random secret, sessions will not survive a restart")
+		authSecret = make([]byte, 32)
+		if _, err := rand.Read(authSecret); err != nil {
+			log.Fatal("Failed to generate auth secret:", err)
+		}
 	}
+	tokens := auth.NewTokenManager(authSecret, cfg.Server.AuthTokenTTL)
 
 	// Create business logic services
-	services := logic.NewServices(dbService.Queries)
+	services := logic.NewServices(dbService, tokens)
+
+	// Stop serving and taking new work on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Run the background processing in this process too, unless cmd/worker does it
+	workerDone := make(chan struct{})
+	if cfg.Server.RunWorker {
+		w := worker.New(dbService, cfg.Worker)
+		go func() {
+			defer close(workerDone)
+			w.Run(ctx)
+		}()
+	} else {
+		log.Println("RUN_WORKER is false; run cmd/worker to process submissions, runs and schedules")
+		close(workerDone)
+	}
 
 	// Create handlers
 	workflowHandlers := handlers.NewWorkflowHandlers(services)
+	formHandlers := handlers.NewFormHandlers(services)
+	authHandlers := handlers.NewAuthHandlers(services)
 
 	// Initialize Gin router with default middleware (logger, recovery)
 	router := gin.Default()
@@ -56,6 +88,9 @@ func main() {
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// Render errors recorded by handlers as RFC 7807 problem+json responses
+	router.Use(middleware.Errors())
+
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -63,9 +98,19 @@ func main() {
 		})
 	})
 
-	// API v1 group
+	// Public authentication endpoints
+	authRoutes := router.Group("/api/v1/auth")
+	{
+		authRoutes.POST("/register", authHandlers.Register)
+		authRoutes.POST("/login", authHandlers.Login)
+	}
+
+	// API v1 group, every route requires an authenticated user
 	apiV1 := router.Group("/api/v1")
+	apiV1.Use(middleware.RequireAuth(services.Auth))
 	{
+		apiV1.GET("/auth/me", authHandlers.Me)
+
 		// Workflow Management Endpoints
 		workflows := apiV1.Group("/workflows")
 		{
@@ -74,14 +119,30 @@ func main() {
 			workflows.GET("/:workflowId", workflowHandlers.GetWorkflow)
 			workflows.PUT("/:workflowId", workflowHandlers.UpdateWorkflow)
 			workflows.PATCH("/:workflowId/status", workflowHandlers.UpdateWorkflowStatus)
+			workflows.GET("/:workflowId/status/history", workflowHandlers.ListStatusTransitions)
+			workflows.POST("/:workflowId/runs", workflowHandlers.StartRun)
+			workflows.GET("/:workflowId/runs", workflowHandlers.ListRuns)
+			workflows.GET("/:workflowId/runs/:runId", workflowHandlers.GetRun)
 			workflows.DELETE("/:workflowId", workflowHandlers.DeleteWorkflow)
 			workflows.GET("/:workflowId/submissions", workflowHandlers.ListSubmissions)
+			workflows.GET("/:workflowId/export", workflowHandlers.ExportSubmissions)
+		}
+
+		// Form Management Endpoints
+		forms := apiV1.Group("/forms")
+		{
+			forms.POST("", formHandlers.CreateForm)
+			forms.GET("", formHandlers.ListForms)
+			forms.GET("/:formId", formHandlers.GetForm)
+			forms.PUT("/:formId", formHandlers.UpdateForm)
+			forms.DELETE("/:formId", formHandlers.DeleteForm)
 		}
 
 		// Submission Management Endpoints
 		submissions := apiV1.Group("/submissions")
 		{
 			submissions.GET("/:submissionId", workflowHandlers.GetSubmission)
+			submissions.GET("/:submissionId/runs", workflowHandlers.ListSubmissionRuns)
 		}
 	}
 
@@ -89,29 +150,35 @@ func main() {
 	public := router.Group("/w")
 	{
 		public.POST("/:workflowId/submit", workflowHandlers.SubmitForm)
+		public.POST("/:workflowId/events", workflowHandlers.RecordFormEvent)
 	}
 
+	// Public webhook endpoint, requests are verified with the workflow's webhook secret
+	router.POST("/hooks/:token", workflowHandlers.ReceiveWebhook)
+
 	// Start the HTTP server
-	port := getEnv("PORT", "9000")
-	log.Printf("Server starting on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	server := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           router,
+		ReadHeaderTimeout: 5 * time.Second,
 	}
-}
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Server.Port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
 
-// Helper functions
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
+	<-ctx.Done()
+	log.Println("Server shutting down, finishing in-flight requests and jobs")
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
+	// Let requests in progress finish, then wait for the worker to drain so that no
+	// job is left to the lease to recover
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Failed to stop HTTP server:", err)
 	}
-	return defaultValue
+	<-workerDone
+	log.Println("Server stopped")
 }
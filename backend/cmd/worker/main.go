@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Schedule triggers name IANA time zones; do not depend on the host's copy
+
+	"github.com/hungaikev/rootd/backend/internal/config"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/worker"
+)
+
+func main() {
+	cfg := config.Load()
+
+	// Create database service
+	dbService, err := db.NewService(cfg.Database)
+	if err != nil {
+		log.Fatal("Failed to create database service:", err)
+	}
+	defer dbService.Close()
+
+	// Run migrations unless they are applied separately with cmd/migrate
+	if cfg.AutoMigrate {
+		if err := dbService.RunMigrations(nil); err != nil {
+			log.Fatal("Failed to run migrations:", err)
+		}
+	}
+
+	// Stop taking new work on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, func() {
+		log.Println("Worker shutting down, finishing in-flight jobs")
+	})
+
+	w := worker.New(dbService, cfg.Worker)
+
+	// Serve the health and metrics endpoints until the worker has drained
+	server := &http.Server{
+		Addr:              ":" + cfg.Worker.Port,
+		Handler:           w.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Printf("Worker health and metrics on port %s", cfg.Worker.Port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start worker HTTP server:", err)
+		}
+	}()
+
+	w.Run(ctx)
+	log.Println("Worker stopped")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Failed to stop worker HTTP server:", err)
+	}
+}
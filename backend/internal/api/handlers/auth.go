@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hungaikev/rootd/backend/internal/auth"
+	"github.com/hungaikev/rootd/backend/internal/logic"
+)
+
+type AuthHandlers struct {
+	services *logic.Services
+}
+
+// NewAuthHandlers creates a new auth handlers instance
+func NewAuthHandlers(services *logic.Services) *AuthHandlers {
+	return &AuthHandlers{
+		services: services,
+	}
+}
+
+// Register handles creating a new user account.
+// @Summary Register a new user
+// @Description Creates a user account with an email and password. The password is stored as a bcrypt hash.
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   user     body    logic.RegisterRequest     true        "Account to create"
+// @Success 201 {object} models.User
+// @Router /api/v1/auth/register [post]
+func (h *AuthHandlers) Register(c *gin.Context) {
+	var req logic.RegisterRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	user, err := h.services.Auth.Register(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// Login handles exchanging credentials for a session token.
+// @Summary Log in with email and password
+// @Description Returns a signed bearer token to send in the Authorization header of every /api/v1 request.
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   credentials     body    logic.LoginRequest     true        "Login credentials"
+// @Success 200 {object} logic.LoginResponse
+// @Router /api/v1/auth/login [post]
+func (h *AuthHandlers) Login(c *gin.Context) {
+	var req logic.LoginRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	resp, err := h.services.Auth.Login(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Me handles retrieving the authenticated user.
+// @Summary Retrieves the authenticated user
+// @Description Returns the account that owns the bearer token.
+// @Tags Auth
+// @Produce  json
+// @Success 200 {object} models.User
+// @Router /api/v1/auth/me [get]
+func (h *AuthHandlers) Me(c *gin.Context) {
+	user, err := h.services.Auth.GetUser(c.Request.Context(), auth.UserID(c.Request.Context()))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hungaikev/rootd/backend/internal/logic"
+)
+
+// bindJSON decodes the request body into dst. When the body is malformed it
+// records an invalid_request error for the error middleware and returns false.
+func bindJSON(c *gin.Context, dst interface{}) bool {
+	if err := c.ShouldBindJSON(dst); err != nil {
+		c.Error(logic.InvalidInput("invalid_request", "invalid request body", err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hungaikev/rootd/backend/internal/auth"
+	"github.com/hungaikev/rootd/backend/internal/logic"
+	"github.com/hungaikev/rootd/backend/internal/models"
+)
+
+type FormHandlers struct {
+	services *logic.Services
+}
+
+// NewFormHandlers creates a new form handlers instance
+func NewFormHandlers(services *logic.Services) *FormHandlers {
+	return &FormHandlers{
+		services: services,
+	}
+}
+
+// CreateForm handles the creation of a new form schema.
+// @Summary Create a new form
+// @Description Creates a form schema that workflows can reference through their schema ID.
+// @Tags Forms
+// @Accept  json
+// @Produce  json
+// @Param   form     body    logic.CreateFormRequest     true        "Form to create"
+// @Success 201 {object} models.Form
+// @Router /api/v1/forms [post]
+func (h *FormHandlers) CreateForm(c *gin.Context) {
+	var req logic.CreateFormRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	req.OwnerID = auth.UserID(c.Request.Context())
+
+	form, err := h.services.Form.CreateForm(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, form)
+}
+
+// ListForms handles listing all forms for the authenticated user.
+// @Summary List all forms for the authenticated user
+// @Description Retrieves all form schemas owned by the user, newest first.
+// @Tags Forms
+// @Produce  json
+// @Success 200 {array} models.Form
+// @Router /api/v1/forms [get]
+func (h *FormHandlers) ListForms(c *gin.Context) {
+	ownerID := auth.UserID(c.Request.Context())
+
+	forms, err := h.services.Form.ListForms(c.Request.Context(), ownerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, forms)
+}
+
+// GetForm handles retrieving a single form.
+// @Summary Retrieves a single form
+// @Description Fetches the complete form schema, including all of its fields.
+// @Tags Forms
+// @Produce  json
+// @Param   formId     path    string     true        "Form ID"
+// @Success 200 {object} models.Form
+// @Router /api/v1/forms/{formId} [get]
+func (h *FormHandlers) GetForm(c *gin.Context) {
+	form := h.authorizeForm(c, c.Param("formId"))
+	if form == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, form)
+}
+
+// UpdateForm handles updating a form.
+// @Summary Updates a form
+// @Description Used to modify the name, description, or schema of a form. Omitted properties keep their current value.
+// @Tags Forms
+// @Accept  json
+// @Produce  json
+// @Param   formId     path    string     true        "Form ID"
+// @Param   form     body    logic.UpdateFormRequest     true        "Updated form properties"
+// @Success 200 {object} models.Form
+// @Router /api/v1/forms/{formId} [put]
+func (h *FormHandlers) UpdateForm(c *gin.Context) {
+	formID := c.Param("formId")
+	var req logic.UpdateFormRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if h.authorizeForm(c, formID) == nil {
+		return
+	}
+
+	form, err := h.services.Form.UpdateForm(c.Request.Context(), formID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, form)
+}
+
+// DeleteForm handles deleting a form.
+// @Summary Deletes a form
+// @Description Permanently deletes a form. Forms that are still referenced by a workflow cannot be deleted.
+// @Tags Forms
+// @Param   formId     path    string     true        "Form ID"
+// @Success 204 {object} nil
+// @Failure 409 {object} object "The form is referenced by a workflow"
+// @Router /api/v1/forms/{formId} [delete]
+func (h *FormHandlers) DeleteForm(c *gin.Context) {
+	formID := c.Param("formId")
+
+	if h.authorizeForm(c, formID) == nil {
+		return
+	}
+
+	err := h.services.Form.DeleteForm(c.Request.Context(), formID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// authorizeForm loads a form and checks that it belongs to the authenticated user.
+// When it does not, the error is recorded and nil is returned.
+func (h *FormHandlers) authorizeForm(c *gin.Context, formID string) *models.Form {
+	form, err := h.services.Form.GetForm(c.Request.Context(), formID)
+	if err != nil {
+		c.Error(err)
+		return nil
+	}
+	if form.OwnerID != auth.UserID(c.Request.Context()) {
+		c.Error(logic.NotFound("form_not_found", "form not found", nil))
+		return nil
+	}
+
+	return form
+}
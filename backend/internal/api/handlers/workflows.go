@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/auth"
 	"github.com/hungaikev/rootd/backend/internal/logic"
 	"github.com/hungaikev/rootd/backend/internal/models"
 )
@@ -32,18 +33,15 @@ func NewWorkflowHandlers(services *logic.Services) *WorkflowHandlers {
 // @Router /api/v1/workflows [post]
 func (h *WorkflowHandlers) CreateWorkflow(c *gin.Context) {
 	var req logic.CreateWorkflowRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	// TODO: Get OwnerID from authenticated user context
-	// For now, using a placeholder
-	req.OwnerID = "00000000-0000-0000-0000-000000000000"
+	req.OwnerID = auth.UserID(c.Request.Context())
 
 	workflow, err := h.services.Workflow.CreateWorkflow(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -58,13 +56,11 @@ func (h *WorkflowHandlers) CreateWorkflow(c *gin.Context) {
 // @Success 200 {array} models.Workflow
 // @Router /api/v1/workflows [get]
 func (h *WorkflowHandlers) ListWorkflows(c *gin.Context) {
-	// TODO: Get OwnerID from authenticated user context
-	// For now, using a placeholder
-	ownerID := "00000000-0000-0000-0000-000000000000"
+	ownerID := auth.UserID(c.Request.Context())
 
 	workflows, err := h.services.Workflow.ListWorkflows(c.Request.Context(), ownerID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -81,10 +77,13 @@ func (h *WorkflowHandlers) ListWorkflows(c *gin.Context) {
 // @Router /api/v1/workflows/{workflowId} [get]
 func (h *WorkflowHandlers) GetWorkflow(c *gin.Context) {
 	workflowID := c.Param("workflowId")
+	if !h.authorizeWorkflow(c, workflowID) {
+		return
+	}
 
 	workflow, err := h.services.Workflow.GetWorkflow(c.Request.Context(), workflowID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Workflow not found"})
+		c.Error(err)
 		return
 	}
 
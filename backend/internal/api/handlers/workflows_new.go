@@ -1,13 +1,23 @@
 package handlers
 
 import (
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hungaikev/rootd/backend/internal/auth"
+	"github.com/hungaikev/rootd/backend/internal/export"
 	"github.com/hungaikev/rootd/backend/internal/logic"
 	"github.com/hungaikev/rootd/backend/internal/models"
 )
 
+// maxWebhookPayloadSize is the largest webhook body that is accepted.
+const maxWebhookPayloadSize = 1 << 20
+
 // UpdateWorkflow handles updating a workflow's configuration.
 // @Summary Updates a workflow's configuration
 // @Description Used to modify the name, trigger, or actions of a workflow. This operation is typically only allowed when the workflow is in a "draft" status.
@@ -21,14 +31,17 @@ import (
 func (h *WorkflowHandlers) UpdateWorkflow(c *gin.Context) {
 	workflowID := c.Param("workflowId")
 	var req logic.UpdateWorkflowRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if !h.authorizeWorkflow(c, workflowID) {
 		return
 	}
 
 	workflow, err := h.services.Workflow.UpdateWorkflow(c.Request.Context(), workflowID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -37,7 +50,7 @@ func (h *WorkflowHandlers) UpdateWorkflow(c *gin.Context) {
 
 // UpdateWorkflowStatus handles changing the status of a workflow.
 // @Summary Changes the status of a workflow
-// @Description A dedicated endpoint to manage the workflow's state (e.g., from "draft" to "active", or "active" to "paused").
+// @Description A dedicated endpoint to manage the workflow's state (e.g., from "draft" to "active", or "active" to "paused"). Transitions not allowed from the current status are rejected with 409.
 // @Tags Workflows
 // @Accept  json
 // @Produce  json
@@ -50,20 +63,131 @@ func (h *WorkflowHandlers) UpdateWorkflowStatus(c *gin.Context) {
 	var statusUpdate struct {
 		Status models.WorkflowStatus `json:"status"`
 	}
-	if err := c.ShouldBindJSON(&statusUpdate); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &statusUpdate) {
 		return
 	}
 
-	workflow, err := h.services.Workflow.UpdateWorkflowStatus(c.Request.Context(), workflowID, statusUpdate.Status)
+	if !h.authorizeWorkflow(c, workflowID) {
+		return
+	}
+
+	userID := auth.UserID(c.Request.Context())
+	workflow, err := h.services.Workflow.UpdateWorkflowStatus(c.Request.Context(), workflowID, statusUpdate.Status, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, workflow)
 }
 
+// ListStatusTransitions handles listing the status history of a workflow.
+// @Summary Lists the status changes of a workflow
+// @Description Returns every status transition of the workflow with who made it and when, newest first.
+// @Tags Workflows
+// @Produce  json
+// @Param   workflowId     path    string     true        "Workflow ID"
+// @Success 200 {array} models.WorkflowStatusTransition
+// @Router /api/v1/workflows/{workflowId}/status/history [get]
+func (h *WorkflowHandlers) ListStatusTransitions(c *gin.Context) {
+	workflowID := c.Param("workflowId")
+
+	if !h.authorizeWorkflow(c, workflowID) {
+		return
+	}
+
+	transitions, err := h.services.Workflow.ListStatusTransitions(c.Request.Context(), workflowID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transitions)
+}
+
+// StartRun handles starting a workflow run on demand.
+// @Summary Runs a workflow's actions on demand
+// @Description Queues a run of the workflow's actions, either against an operator-supplied input payload, which must satisfy the linked form, or against an existing submission of the workflow. A re-run leaves the submission's status unchanged. Poll the returned run for its progress.
+// @Tags Workflows
+// @Accept  json
+// @Produce  json
+// @Param   workflowId     path    string     true        "Workflow ID"
+// @Param   run     body    logic.StartRunRequest     true        "Either input or submission_id"
+// @Success 202 {object} models.WorkflowRun
+// @Failure 409 {object} middleware.Problem "workflow_archived"
+// @Router /api/v1/workflows/{workflowId}/runs [post]
+func (h *WorkflowHandlers) StartRun(c *gin.Context) {
+	workflowID := c.Param("workflowId")
+	var req logic.StartRunRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if !h.authorizeWorkflow(c, workflowID) {
+		return
+	}
+
+	req.TriggeredBy = auth.UserID(c.Request.Context())
+
+	run, err := h.services.Run.StartRun(c.Request.Context(), workflowID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/workflows/%s/runs/%s", workflowID, run.ID))
+	c.JSON(http.StatusAccepted, run)
+}
+
+// ListRuns handles listing the on-demand runs of a workflow.
+// @Summary Lists the runs of a workflow
+// @Description Returns every on-demand run of the workflow, newest first.
+// @Tags Workflows
+// @Produce  json
+// @Param   workflowId     path    string     true        "Workflow ID"
+// @Success 200 {array} models.WorkflowRun
+// @Router /api/v1/workflows/{workflowId}/runs [get]
+func (h *WorkflowHandlers) ListRuns(c *gin.Context) {
+	workflowID := c.Param("workflowId")
+
+	if !h.authorizeWorkflow(c, workflowID) {
+		return
+	}
+
+	runs, err := h.services.Run.ListRuns(c.Request.Context(), workflowID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// GetRun handles retrieving a single workflow run.
+// @Summary Retrieves a workflow run
+// @Description Returns the status of an on-demand run: pending until the engine picks it up, then running, then completed or failed with the error. A failed run is retried with backoff, like a submission, until it succeeds or runs out of attempts.
+// @Tags Workflows
+// @Produce  json
+// @Param   workflowId     path    string     true        "Workflow ID"
+// @Param   runId     path    string     true        "Run ID"
+// @Success 200 {object} models.WorkflowRun
+// @Router /api/v1/workflows/{workflowId}/runs/{runId} [get]
+func (h *WorkflowHandlers) GetRun(c *gin.Context) {
+	workflowID := c.Param("workflowId")
+
+	if !h.authorizeWorkflow(c, workflowID) {
+		return
+	}
+
+	run, err := h.services.Run.GetRun(c.Request.Context(), workflowID, c.Param("runId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
 // DeleteWorkflow handles deleting a workflow.
 // @Summary Deletes a workflow
 // @Description Permanently deletes a workflow and all of its associated submissions. This is a destructive action.
@@ -74,9 +198,13 @@ func (h *WorkflowHandlers) UpdateWorkflowStatus(c *gin.Context) {
 func (h *WorkflowHandlers) DeleteWorkflow(c *gin.Context) {
 	workflowID := c.Param("workflowId")
 
+	if !h.authorizeWorkflow(c, workflowID) {
+		return
+	}
+
 	err := h.services.Workflow.DeleteWorkflow(c.Request.Context(), workflowID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -92,12 +220,13 @@ func (h *WorkflowHandlers) DeleteWorkflow(c *gin.Context) {
 // @Param   workflowId     path    string     true        "Workflow ID"
 // @Param   submission     body    logic.CreateSubmissionRequest     true        "Submission data"
 // @Success 201 {object} object
+// @Failure 409 {object} middleware.Problem "workflow_not_active, trigger_not_form for workflows with a webhook or schedule trigger, or invalid_form_schema"
+// @Failure 422 {object} middleware.Problem "invalid_submission, with one error message per invalid field ID in fields"
 // @Router /w/{workflowId}/submit [post]
 func (h *WorkflowHandlers) SubmitForm(c *gin.Context) {
 	workflowID := c.Param("workflowId")
 	var req logic.CreateSubmissionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -105,7 +234,7 @@ func (h *WorkflowHandlers) SubmitForm(c *gin.Context) {
 
 	submission, err := h.services.Submission.CreateSubmission(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -116,42 +245,277 @@ func (h *WorkflowHandlers) SubmitForm(c *gin.Context) {
 	})
 }
 
-// ListSubmissions handles listing all submissions for a specific workflow.
-// @Summary Lists all submissions for a specific workflow
-// @Description An authenticated endpoint for the form owner to retrieve all data collected by a workflow. Should support pagination.
+// RecordFormEvent handles the public endpoint for form analytics events.
+// @Summary Records a form view or start event
+// @Description Called by the form renderer when the form is displayed ("view") and when the respondent first interacts with it ("start"). Events are counted once per session. It is not authenticated.
+// @Tags Analytics
+// @Accept  json
+// @Param   workflowId     path    string     true        "Workflow ID"
+// @Param   event     body    logic.FormEventRequest     true        "Form event"
+// @Success 204 {object} nil
+// @Router /w/{workflowId}/events [post]
+func (h *WorkflowHandlers) RecordFormEvent(c *gin.Context) {
+	workflowID := c.Param("workflowId")
+	var req logic.FormEventRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.services.Analytics.RecordFormEvent(c.Request.Context(), workflowID, req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ReceiveWebhook handles the public endpoint of workflows with a webhook trigger.
+// @Summary Ingests a payload sent to a workflow webhook
+// @Description The payload is verified with the HMAC signature in X-Rootd-Signature, "sha256=" followed by the hex HMAC-SHA256 of the X-Rootd-Timestamp value, a dot and the raw body, keyed with the webhook secret. Timestamps more than five minutes off are rejected. The payload is mapped onto form fields with the trigger's fieldMapping and stored as a submission. It is not authenticated.
+// @Tags Submissions
+// @Accept  json
+// @Produce  json
+// @Param   token     path    string     true        "Webhook token from the workflow's webhook URL"
+// @Param   X-Rootd-Timestamp     header    string     true        "Unix time the payload was signed"
+// @Param   X-Rootd-Signature     header    string     true        "sha256=<hex HMAC>"
+// @Param   payload     body    object     true        "Payload"
+// @Success 201 {object} object
+// @Failure 401 {object} middleware.Problem "invalid_webhook_signature"
+// @Failure 422 {object} middleware.Problem "invalid_submission, when the mapped payload does not satisfy the form"
+// @Router /hooks/{token} [post]
+func (h *WorkflowHandlers) ReceiveWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookPayloadSize))
+	if err != nil {
+		c.Error(logic.InvalidInput("invalid_request", "invalid request body", err))
+		return
+	}
+
+	req := logic.WebhookRequest{
+		Payload:   payload,
+		Timestamp: c.GetHeader(logic.WebhookTimestampHeader),
+		Signature: c.GetHeader(logic.WebhookSignatureHeader),
+		Metadata: &models.SubmissionMetadata{
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		},
+	}
+
+	submission, err := h.services.Webhook.ReceiveWebhook(c.Request.Context(), c.Param("token"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Submission successful",
+		"workflowId":   submission.WorkflowID,
+		"submissionId": submission.ID,
+	})
+}
+
+// ListSubmissions handles listing the submissions for a specific workflow.
+// @Summary Lists the submissions for a specific workflow
+// @Description An authenticated endpoint for the form owner to page through the data collected by a workflow. Pages are ordered by creation time; pass the returned nextCursor to fetch the following page.
 // @Tags Submissions
 // @Produce  json
 // @Param   workflowId     path    string     true        "Workflow ID"
-// @Success 200 {array} models.Submission
+// @Param   status     query    string     false        "Only return submissions with this status"
+// @Param   from     query    string     false        "Only return submissions created at or after this RFC 3339 time"
+// @Param   to     query    string     false        "Only return submissions created before this RFC 3339 time"
+// @Param   filter     query    []string     false        "Condition on a data field, e.g. data.email eq \"x@y.com\" or data.score gte 8. Operators: eq, ne, gt, gte, lt, lte, contains, in. Repeat to combine with AND." collectionFormat(multi)
+// @Param   sort     query    string     false        "Sort direction by creation time: asc or desc (default)"
+// @Param   limit     query    int     false        "Page size, 1-200 (default 50)"
+// @Param   cursor     query    string     false        "Cursor returned as nextCursor by the previous page"
+// @Success 200 {object} logic.SubmissionPage
 // @Router /api/v1/workflows/{workflowId}/submissions [get]
 func (h *WorkflowHandlers) ListSubmissions(c *gin.Context) {
 	workflowID := c.Param("workflowId")
 
-	submissions, err := h.services.Submission.ListSubmissions(c.Request.Context(), workflowID)
+	opts, err := parseListSubmissionsOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(logic.InvalidInput(logic.ErrInvalidListOptions.Code, logic.ErrInvalidListOptions.Message, err))
 		return
 	}
 
-	c.JSON(http.StatusOK, submissions)
+	if !h.authorizeWorkflow(c, workflowID) {
+		return
+	}
+
+	page, err := h.services.Submission.ListSubmissions(c.Request.Context(), workflowID, opts)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// parseListSubmissionsOptions reads the pagination and filter query parameters.
+func parseListSubmissionsOptions(c *gin.Context) (logic.ListSubmissionsOptions, error) {
+	opts := logic.ListSubmissionsOptions{
+		Cursor: c.Query("cursor"),
+		Sort:   logic.SortDirection(c.Query("sort")),
+	}
+
+	if status := c.Query("status"); status != "" {
+		s := models.SubmissionStatus(status)
+		opts.Status = &s
+	}
+
+	for _, expr := range c.QueryArray("filter") {
+		filter, err := logic.ParseDataFilter(expr)
+		if err != nil {
+			return opts, err
+		}
+		opts.DataFilters = append(opts.DataFilters, filter)
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return opts, fmt.Errorf("limit must be an integer")
+		}
+		opts.Limit = n
+	}
+
+	bounds := []struct {
+		param string
+		dst   **time.Time
+	}{
+		{"from", &opts.CreatedFrom},
+		{"to", &opts.CreatedTo},
+	}
+	for _, bound := range bounds {
+		if value := c.Query(bound.param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return opts, fmt.Errorf("%s must be an RFC 3339 timestamp", bound.param)
+			}
+			*bound.dst = &t
+		}
+	}
+
+	return opts, nil
+}
+
+// ExportSubmissions handles downloading every submission of a workflow.
+// @Summary Exports all submissions for a workflow
+// @Description Streams every submission as CSV, XLSX or newline-delimited JSON. Columns are id, status and createdAt, then the linked form's field labels in schema order, then the submission metadata.
+// @Tags Submissions
+// @Produce  text/csv
+// @Produce  application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce  application/x-ndjson
+// @Param   workflowId     path    string     true        "Workflow ID"
+// @Param   format     query    string     false        "csv (default), xlsx or ndjson"
+// @Success 200 {file} file
+// @Router /api/v1/workflows/{workflowId}/export [get]
+func (h *WorkflowHandlers) ExportSubmissions(c *gin.Context) {
+	workflowID := c.Param("workflowId")
+
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.Error(logic.InvalidInput("invalid_export_format", "invalid export format", err))
+		return
+	}
+
+	if !h.authorizeWorkflow(c, workflowID) {
+		return
+	}
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="submissions-%s.%s"`, workflowID, format.Extension()))
+
+	err = h.services.Export.ExportSubmissions(c.Request.Context(), workflowID, format, c.Writer)
+	if err != nil {
+		// Once the download has started the status can no longer change
+		if c.Writer.Written() {
+			log.Printf("export of workflow %s aborted: %v", workflowID, err)
+			c.Abort()
+			return
+		}
+		c.Writer.Header().Del("Content-Disposition")
+		c.Writer.Header().Del("Content-Type")
+		c.Error(err)
+		return
+	}
 }
 
 // GetSubmission handles retrieving a single submission.
 // @Summary Retrieves a single submission
-// @Description An authenticated endpoint to get the full details of one specific submission, including its data and metadata.
+// @Description An authenticated endpoint to get the full details of one specific submission, including its data, metadata and a summary of its execution log.
 // @Tags Submissions
 // @Produce  json
 // @Param   submissionId     path    string     true        "Submission ID"
 // @Success 200 {object} models.Submission
 // @Router /api/v1/submissions/{submissionId} [get]
 func (h *WorkflowHandlers) GetSubmission(c *gin.Context) {
+	submission := h.authorizeSubmission(c, c.Param("submissionId"))
+	if submission == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, submission)
+}
+
+// ListSubmissionRuns handles listing the execution log of a submission.
+// @Summary Lists the action runs of a submission
+// @Description Returns every action executed for the submission, oldest first: its attempt, input config, output, error, and start and end times. Entries older than the configured retention are removed.
+// @Tags Submissions
+// @Produce  json
+// @Param   submissionId     path    string     true        "Submission ID"
+// @Success 200 {array} models.ActionRun
+// @Router /api/v1/submissions/{submissionId}/runs [get]
+func (h *WorkflowHandlers) ListSubmissionRuns(c *gin.Context) {
 	submissionID := c.Param("submissionId")
 
-	submission, err := h.services.Submission.GetSubmission(c.Request.Context(), submissionID)
+	if h.authorizeSubmission(c, submissionID) == nil {
+		return
+	}
+
+	runs, err := h.services.Submission.ListActionRuns(c.Request.Context(), submissionID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, submission)
+	c.JSON(http.StatusOK, runs)
+}
+
+// authorizeWorkflow checks that a workflow belongs to the authenticated user. When it
+// does not, the error is recorded and false is returned. Workflows owned by someone
+// else are reported as not found so their IDs are not disclosed.
+func (h *WorkflowHandlers) authorizeWorkflow(c *gin.Context, workflowID string) bool {
+	ownerID, err := h.services.Workflow.GetWorkflowOwner(c.Request.Context(), workflowID)
+	if err != nil {
+		c.Error(err)
+		return false
+	}
+	if ownerID != auth.UserID(c.Request.Context()) {
+		c.Error(logic.NotFound("workflow_not_found", "workflow not found", nil))
+		return false
+	}
+
+	return true
+}
+
+// authorizeSubmission loads a submission and checks that its workflow belongs to the
+// authenticated user, reporting failures like authorizeWorkflow.
+func (h *WorkflowHandlers) authorizeSubmission(c *gin.Context, submissionID string) *models.Submission {
+	submission, err := h.services.Submission.GetSubmission(c.Request.Context(), submissionID)
+	if err != nil {
+		c.Error(err)
+		return nil
+	}
+
+	ownerID, err := h.services.Workflow.GetWorkflowOwner(c.Request.Context(), submission.WorkflowID)
+	if err != nil {
+		c.Error(err)
+		return nil
+	}
+	if ownerID != auth.UserID(c.Request.Context()) {
+		c.Error(logic.NotFound("submission_not_found", "submission not found", nil))
+		return nil
+	}
+
+	return submission
 }
@@ -0,0 +1,41 @@
+// Package middleware contains the gin middleware shared by the API routes.
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hungaikev/rootd/backend/internal/auth"
+	"github.com/hungaikev/rootd/backend/internal/logic"
+)
+
+// RequireAuth rejects requests without a valid bearer token and stores the
+// authenticated user ID in the request context. Rejections are rendered by Errors.
+func RequireAuth(authService logic.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := bearerToken(c.GetHeader("Authorization"))
+		if token == "" {
+			c.Error(logic.ErrUnauthenticated)
+			c.Abort()
+			return
+		}
+
+		userID, err := authService.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithUserID(c.Request.Context(), userID))
+		c.Next()
+	}
+}
+
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
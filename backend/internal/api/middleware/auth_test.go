@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/auth"
+	"github.com/hungaikev/rootd/backend/internal/db/memdb"
+	"github.com/hungaikev/rootd/backend/internal/logic"
+)
+
+func TestRequireAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tokens := auth.NewTokenManager([]byte("s3cret"), time.Hour)
+	router := gin.New()
+	router.Use(Errors(), RequireAuth(logic.NewAuthService(memdb.New(), tokens)))
+	router.GET("/me", func(c *gin.Context) {
+		c.String(http.StatusOK, auth.UserID(c.Request.Context()))
+	})
+
+	userID := uuid.NewString()
+	valid, _, err := tokens.Issue(userID)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	foreign, _, err := auth.NewTokenManager([]byte("other"), time.Hour).Issue(userID)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	expired, _, err := auth.NewTokenManager([]byte("s3cret"), -time.Minute).Issue(userID)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	notUUID, _, err := tokens.Issue("alice")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"valid", "Bearer " + valid, http.StatusOK},
+		{"lowercase scheme", "bearer " + valid, http.StatusOK},
+		{"no header", "", http.StatusUnauthorized},
+		{"other scheme", "Basic " + valid, http.StatusUnauthorized},
+		{"no token", "Bearer ", http.StatusUnauthorized},
+		{"other secret", "Bearer " + foreign, http.StatusUnauthorized},
+		{"expired", "Bearer " + expired, http.StatusUnauthorized},
+		{"subject not a user ID", "Bearer " + notUUID, http.StatusUnauthorized},
+		{"malformed", "Bearer not.a.token", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusOK {
+				if rec.Body.String() != userID {
+					t.Errorf("user ID = %q, want %q", rec.Body, userID)
+				}
+				return
+			}
+
+			var problem Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("problem body %q: %v", rec.Body, err)
+			}
+			if problem.Code != "unauthenticated" {
+				t.Errorf("code = %q, want unauthenticated", problem.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hungaikev/rootd/backend/internal/logic"
+)
+
+// ProblemContentType is the media type of RFC 7807 error responses.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body. Code is a stable identifier
+// clients can branch on; Detail is meant for people and may change.
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Code     string            `json:"code"`
+	Fields   map[string]string `json:"fields,omitempty"` // Messages per field ID for validation errors.
+}
+
+// kindStatus maps each domain error kind to its HTTP status code.
+var kindStatus = map[logic.ErrorKind]int{
+	logic.KindInvalidInput:    http.StatusBadRequest,
+	logic.KindValidation:      http.StatusUnprocessableEntity,
+	logic.KindNotFound:        http.StatusNotFound,
+	logic.KindConflict:        http.StatusConflict,
+	logic.KindUnauthenticated: http.StatusUnauthorized,
+	logic.KindForbidden:       http.StatusForbidden,
+	logic.KindUnavailable:     http.StatusServiceUnavailable,
+}
+
+// Errors renders the last error a handler attached with c.Error as a
+// problem+json response. Untyped errors become a 500 whose details are only logged.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		err := c.Errors.Last()
+		if err == nil {
+			return
+		}
+
+		// The handler already started a response, e.g. a streamed export
+		if c.Writer.Written() {
+			log.Printf("%s %s: error after response started: %v", c.Request.Method, c.Request.URL.Path, err.Err)
+			return
+		}
+
+		problem := NewProblem(err.Err)
+		problem.Instance = c.Request.URL.Path
+		if problem.Status >= http.StatusInternalServerError {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err.Err)
+		}
+
+		c.Header("Content-Type", ProblemContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
+
+// NewProblem builds the problem details for err.
+func NewProblem(err error) Problem {
+	domainErr := logic.AsError(err)
+	if domainErr == nil {
+		return Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Status: http.StatusInternalServerError,
+			Detail: "An unexpected error occurred",
+			Code:   "internal_error",
+		}
+	}
+
+	status, ok := kindStatus[domainErr.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: domainErr.Error(),
+		Code:   domainErr.Code,
+		Fields: domainErr.Fields,
+	}
+
+	// Do not leak database errors to clients
+	switch domainErr.Kind {
+	case logic.KindNotFound:
+		problem.Detail = domainErr.Message
+	case logic.KindUnavailable:
+		problem.Detail = "The service is temporarily unavailable"
+	}
+
+	return problem
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestManager(secret string) (*TokenManager, *time.Time) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := NewTokenManager([]byte(secret), time.Hour)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func encode(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	m, now := newTestManager("s3cret")
+
+	token, expiresAt, err := m.Issue("user-1")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if want := now.Add(time.Hour); !expiresAt.Equal(want) {
+		t.Errorf("expires at %s, want %s", expiresAt, want)
+	}
+
+	claims, err := m.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	want := Claims{Subject: "user-1", IssuedAt: now.Unix(), ExpiresAt: expiresAt.Unix()}
+	if *claims != want {
+		t.Errorf("claims = %+v, want %+v", *claims, want)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	m, _ := newTestManager("s3cret")
+	other, _ := newTestManager("other")
+
+	token, _, err := m.Issue("user-1")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	parts := strings.Split(token, ".")
+	payload := encode(`{"sub":"user-1","iat":0,"exp":9999999999}`)
+
+	foreign, _, err := other.Issue("user-1")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"tampered payload", parts[0] + "." + encode(`{"sub":"user-2","iat":0,"exp":9999999999}`) + "." + parts[2]},
+		{"other secret", foreign},
+		{"alg none", encode(`{"alg":"none","typ":"JWT"}`) + "." + payload + "."},
+		{"alg none with a valid signature", encode(`{"alg":"none","typ":"JWT"}`) + "." + parts[1] + "." + parts[2]},
+		{"different header signed with the secret", func() string {
+			unsigned := encode(`{"alg":"HS512","typ":"JWT"}`) + "." + parts[1]
+			return unsigned + "." + m.sign(unsigned)
+		}()},
+		{"empty", ""},
+		{"two parts", parts[0] + "." + parts[1]},
+		{"four parts", token + "." + parts[2]},
+		{"missing signature", parts[0] + "." + parts[1] + "."},
+		{"payload not base64", func() string {
+			unsigned := parts[0] + ".!!!"
+			return unsigned + "." + m.sign(unsigned)
+		}()},
+		{"payload not JSON", func() string {
+			unsigned := parts[0] + "." + encode("user-1")
+			return unsigned + "." + m.sign(unsigned)
+		}()},
+		{"no subject", func() string {
+			unsigned := parts[0] + "." + encode(`{"iat":0,"exp":9999999999}`)
+			return unsigned + "." + m.sign(unsigned)
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.Verify(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify(%q) error = %v, want %v", tt.token, err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestVerifyExpired(t *testing.T) {
+	m, now := newTestManager("s3cret")
+
+	token, _, err := m.Issue("user-1")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	*now = now.Add(time.Hour - time.Second)
+	if _, err := m.Verify(token); err != nil {
+		t.Fatalf("Verify a second before expiry: %v", err)
+	}
+
+	*now = now.Add(time.Second)
+	if _, err := m.Verify(token); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("Verify at expiry error = %v, want %v", err, ErrExpiredToken)
+	}
+}
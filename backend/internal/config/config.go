@@ -0,0 +1,122 @@
+// Package config loads the configuration shared by the server and the worker from
+// environment variables. Unset or unparsable variables take their defaults.
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/mail"
+	"github.com/hungaikev/rootd/backend/internal/queue"
+)
+
+// Config is the configuration of both binaries.
+type Config struct {
+	Database    db.ServiceConfig
+	AutoMigrate bool // AUTO_MIGRATE: apply migrations on startup instead of with cmd/migrate.
+
+	Server ServerConfig
+	Worker WorkerConfig
+}
+
+// ServerConfig configures the HTTP API server, cmd/server.
+type ServerConfig struct {
+	Port         string        // PORT
+	AuthSecret   []byte        // AUTH_SECRET: signs session tokens. Empty means a random secret per process.
+	AuthTokenTTL time.Duration // AUTH_TOKEN_TTL
+
+	// RunWorker makes the server do the background processing as well, for deployments
+	// without a separate cmd/worker. RUN_WORKER, defaults to true.
+	RunWorker bool
+}
+
+// WorkerConfig configures the background processing, in cmd/worker or in the server.
+type WorkerConfig struct {
+	Port                  string          // WORKER_PORT: serves the worker's health and metrics endpoints.
+	SchedulerPollInterval time.Duration   // SCHEDULER_POLL_INTERVAL: how often due schedule triggers are looked for.
+	Queue                 queue.Config    // QUEUE_*: the workers that run queued jobs.
+	Mail                  mail.SMTPConfig // SMTP_*: the relay of send_email actions, which fail while SMTP_HOST is unset.
+
+	// ActionRunRetention is how long the execution log of submissions is kept.
+	// ACTION_RUN_RETENTION, defaults to 30 days; 0 keeps it forever.
+	ActionRunRetention time.Duration
+}
+
+// Load reads the configuration from the environment.
+func Load() *Config {
+	return &Config{
+		Database: db.ServiceConfig{
+			Host:     getEnv("DB_HOST", "localhost"),
+			Port:     getEnvAsInt("DB_PORT", 5432),
+			User:     getEnv("DB_USER", "postgres"),
+			Password: getEnv("DB_PASSWORD", "password"),
+			DBName:   getEnv("DB_NAME", "rootd"),
+			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+		},
+		AutoMigrate: getEnvAsBool("AUTO_MIGRATE", true),
+		Server: ServerConfig{
+			Port:         getEnv("PORT", "9000"),
+			AuthSecret:   []byte(getEnv("AUTH_SECRET", "")),
+			AuthTokenTTL: getEnvAsDuration("AUTH_TOKEN_TTL", 24*time.Hour),
+			RunWorker:    getEnvAsBool("RUN_WORKER", true),
+		},
+		Worker: WorkerConfig{
+			Port:                  getEnv("WORKER_PORT", "9001"),
+			SchedulerPollInterval: getEnvAsDuration("SCHEDULER_POLL_INTERVAL", 30*time.Second),
+			Queue: queue.Config{
+				Workers:      getEnvAsInt("QUEUE_WORKERS", 4),
+				PollInterval: getEnvAsDuration("QUEUE_POLL_INTERVAL", time.Second),
+				MaxAttempts:  getEnvAsInt("QUEUE_MAX_ATTEMPTS", 5),
+				BaseBackoff:  getEnvAsDuration("QUEUE_BASE_BACKOFF", 10*time.Second),
+				MaxBackoff:   getEnvAsDuration("QUEUE_MAX_BACKOFF", time.Hour),
+				Lease:        getEnvAsDuration("QUEUE_LEASE", 5*time.Minute),
+			},
+			Mail: mail.SMTPConfig{
+				Host:     getEnv("SMTP_HOST", ""),
+				Port:     getEnvAsInt("SMTP_PORT", 587),
+				Username: getEnv("SMTP_USERNAME", ""),
+				Password: getEnv("SMTP_PASSWORD", ""),
+				From:     getEnv("SMTP_FROM", "Rootd <noreply@localhost>"),
+				Timeout:  getEnvAsDuration("SMTP_TIMEOUT", 30*time.Second),
+			},
+			ActionRunRetention: getEnvAsDuration("ACTION_RUN_RETENTION", 30*24*time.Hour),
+		},
+	}
+}
+
+// Helper functions
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvAsInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	// Empty variables count as unset
+	for _, key := range []string{"DB_HOST", "DB_PORT", "AUTO_MIGRATE", "PORT", "AUTH_SECRET", "RUN_WORKER", "WORKER_PORT", "QUEUE_WORKERS", "QUEUE_MAX_ATTEMPTS", "ACTION_RUN_RETENTION", "SMTP_HOST", "SMTP_PORT"} {
+		t.Setenv(key, "")
+	}
+
+	cfg := Load()
+
+	if cfg.Database.Host != "localhost" || cfg.Database.Port != 5432 || !cfg.AutoMigrate {
+		t.Errorf("database config = %+v, auto migrate %v, want the local defaults", cfg.Database, cfg.AutoMigrate)
+	}
+	if cfg.Server.Port != "9000" || !cfg.Server.RunWorker || len(cfg.Server.AuthSecret) != 0 {
+		t.Errorf("server config = %+v, want port 9000 running the worker", cfg.Server)
+	}
+	if cfg.Worker.Mail.Host != "" || cfg.Worker.Mail.Port != 587 {
+		t.Errorf("mail config = %+v, want no relay on the submission port", cfg.Worker.Mail)
+	}
+	if cfg.Worker.Port != "9001" || cfg.Worker.Queue.Workers != 4 || cfg.Worker.Queue.MaxAttempts != 5 || cfg.Worker.ActionRunRetention != 30*24*time.Hour {
+		t.Errorf("worker config = %+v, want port 9001 with 4 queue workers, 5 attempts and 30 days of action runs", cfg.Worker)
+	}
+}
+
+func TestLoadFromEnvironment(t *testing.T) {
+	t.Setenv("DB_HOST", "db.internal")
+	t.Setenv("DB_PORT", "6432")
+	t.Setenv("AUTO_MIGRATE", "false")
+	t.Setenv("RUN_WORKER", "0")
+	t.Setenv("AUTH_SECRET", "s3cret")
+	t.Setenv("WORKER_PORT", "9100")
+	t.Setenv("QUEUE_WORKERS", "16")
+	t.Setenv("QUEUE_BASE_BACKOFF", "30s")
+	t.Setenv("QUEUE_MAX_ATTEMPTS", "many") // Unparsable, so the default stays
+	t.Setenv("ACTION_RUN_RETENTION", "0s")
+	t.Setenv("SMTP_HOST", "smtp.internal")
+	t.Setenv("SMTP_PORT", "2525")
+
+	cfg := Load()
+
+	if cfg.Database.Host != "db.internal" || cfg.Database.Port != 6432 || cfg.AutoMigrate {
+		t.Errorf("database config = %+v, auto migrate %v", cfg.Database, cfg.AutoMigrate)
+	}
+	if cfg.Server.RunWorker || string(cfg.Server.AuthSecret) != "s3cret" {
+		t.Errorf("server config = %+v", cfg.Server)
+	}
+	queue := cfg.Worker.Queue
+	if cfg.Worker.Port != "9100" || queue.Workers != 16 || queue.BaseBackoff != 30*time.Second || queue.MaxAttempts != 5 || cfg.Worker.ActionRunRetention != 0 {
+		t.Errorf("worker config = %+v", cfg.Worker)
+	}
+	if cfg.Worker.Mail.Host != "smtp.internal" || cfg.Worker.Mail.Port != 2525 {
+		t.Errorf("mail config = %+v", cfg.Worker.Mail)
+	}
+}
@@ -0,0 +1,234 @@
+// Package cron parses standard five-field cron expressions and computes the
+// times at which they fire.
+//
+// An expression has the fields minute (0-59), hour (0-23), day of month (1-31),
+// month (1-12 or JAN-DEC) and day of week (0-6 or SUN-SAT, 7 is also Sunday).
+// Each field is "*", a value, a range "a-b", or a comma separated list of those,
+// optionally followed by a step "/n". The macros @yearly, @annually, @monthly,
+// @weekly, @daily, @midnight and @hourly are accepted as well.
+//
+// As in Vixie cron, when both the day of month and the day of week are
+// restricted, a day matches if either of them does.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression. It is safe for concurrent use.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // Bit i is set when value i matches.
+
+	// domAny and dowAny record whether the day fields start with "*", which
+	// decides how the two are combined.
+	domAny, dowAny bool
+}
+
+// field describes the range and names of one expression field.
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}},
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// searchLimit bounds how far ahead Next looks for a matching time. Expressions
+// such as "0 0 30 2 *" never fire.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// Parse parses a cron expression.
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "@") {
+		macro, ok := macros[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("cron: unknown macro %q", spec)
+		}
+		spec = macro
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron: expected %d fields, got %d in %q", len(fields), len(parts), expr)
+	}
+
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron: %s field %q: %w", fields[i].name, part, err)
+		}
+		bits[i] = b
+	}
+
+	// 7 is an alias for Sunday
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &Schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: strings.HasPrefix(parts[2], "*"),
+		dowAny: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// Next returns the first time after t at which the schedule fires, in t's location.
+// It returns the zero time when the schedule does not fire within five years.
+//
+// Local times that are skipped by a daylight saving change do not fire; local times
+// that occur twice fire once, at the first occurrence.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	after := wallClock(t)
+	limit := t.Add(searchLimit)
+
+	// Start at the next whole minute
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		var next time.Time
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.matchesDay(t):
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			next = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<uint(t.Minute())) == 0, !wallClock(t).After(after):
+			next = t.Add(time.Minute)
+		default:
+			return t
+		}
+
+		// Midnight can fall in a repeated hour, so make sure the search moves forward
+		if !next.After(t) {
+			next = t.Add(time.Minute)
+		}
+		t = next
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// wallClock returns the local date and time of t as if it were UTC, so that the
+// two occurrences of a repeated local time compare equal.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
+
+// parseField parses one comma separated field into a bit set.
+func parseField(spec string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(spec, ",") {
+		b, err := parseItem(item, f)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+	return bits, nil
+}
+
+// parseItem parses "*", "a", "a-b" or any of those followed by "/step".
+func parseItem(item string, f field) (uint64, error) {
+	rangeSpec, stepSpec, hasStep := strings.Cut(item, "/")
+
+	step := 1
+	if hasStep {
+		n, err := strconv.Atoi(stepSpec)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid step %q", stepSpec)
+		}
+		step = n
+	}
+
+	var lo, hi int
+	switch {
+	case rangeSpec == "*":
+		lo, hi = f.min, f.max
+		if f.name == "day of week" {
+			hi = 6 // Sunday is 0; 7 would only duplicate it
+		}
+	case strings.Contains(rangeSpec, "-"):
+		loSpec, hiSpec, _ := strings.Cut(rangeSpec, "-")
+		var err error
+		if lo, err = parseValue(loSpec, f); err != nil {
+			return 0, err
+		}
+		if hi, err = parseValue(hiSpec, f); err != nil {
+			return 0, err
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("range %q is backwards", rangeSpec)
+		}
+	default:
+		v, err := parseValue(rangeSpec, f)
+		if err != nil {
+			return 0, err
+		}
+		lo, hi = v, v
+		// "a/n" means every n starting at a, up to the end of the range
+		if hasStep {
+			hi = f.max
+		}
+	}
+
+	var bits uint64
+	for v := lo; v <= hi; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits, nil
+}
+
+func parseValue(spec string, f field) (int, error) {
+	if v, ok := f.names[strings.ToUpper(spec)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(spec)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", spec)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, f.min, f.max)
+	}
+	return v, nil
+}
@@ -0,0 +1,122 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q): %v", name, err)
+	}
+	return loc
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"* * * FOO *",
+		"1,,2 * * * *",
+		"@fortnightly",
+	}
+
+	for _, expr := range tests {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	utc := time.UTC
+	start := time.Date(2025, 1, 15, 10, 30, 0, 0, utc) // A Wednesday.
+
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", start, start.Add(time.Minute)},
+		{"* * * * *", start.Add(20 * time.Second), start.Add(time.Minute)},
+		{"*/15 * * * *", start, time.Date(2025, 1, 15, 10, 45, 0, 0, utc)},
+		{"0 * * * *", start, time.Date(2025, 1, 15, 11, 0, 0, 0, utc)},
+		{"30 10 * * *", start, time.Date(2025, 1, 16, 10, 30, 0, 0, utc)},
+		{"0 9-17/4 * * *", start, time.Date(2025, 1, 15, 13, 0, 0, 0, utc)},
+		{"0 9 * * MON", start, time.Date(2025, 1, 20, 9, 0, 0, 0, utc)},
+		{"0 9 * * 1-5", start, time.Date(2025, 1, 16, 9, 0, 0, 0, utc)},
+		{"0 0 * * 7", start, time.Date(2025, 1, 19, 0, 0, 0, 0, utc)},
+		{"0 0 1 * *", start, time.Date(2025, 2, 1, 0, 0, 0, 0, utc)},
+		{"0 0 31 * *", time.Date(2025, 1, 31, 12, 0, 0, 0, utc), time.Date(2025, 3, 31, 0, 0, 0, 0, utc)},
+		{"0 0 29 2 *", start, time.Date(2028, 2, 29, 0, 0, 0, 0, utc)},
+		{"0 0 1 jan,jul *", start, time.Date(2025, 7, 1, 0, 0, 0, 0, utc)},
+		{"@weekly", start, time.Date(2025, 1, 19, 0, 0, 0, 0, utc)},
+		{"@yearly", start, time.Date(2026, 1, 1, 0, 0, 0, 0, utc)},
+		// Day of month and day of week restricted: either one matches
+		{"0 0 20 * FRI", start, time.Date(2025, 1, 17, 0, 0, 0, 0, utc)},
+		// A stepped star still counts as unrestricted, so both must match
+		{"0 0 20 * */2", start, time.Date(2025, 2, 20, 0, 0, 0, 0, utc)},
+		{"0 0 30 2 *", start, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		schedule, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("Parse(%q).Next(%v) = %v, want %v", tt.expr, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestNextTimeZone(t *testing.T) {
+	nairobi := mustLoad(t, "Africa/Nairobi")
+	schedule, err := Parse("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 07:00 UTC is 10:00 in Nairobi, so the next 09:00 there is tomorrow
+	from := time.Date(2025, 1, 15, 7, 0, 0, 0, time.UTC).In(nairobi)
+	want := time.Date(2025, 1, 16, 6, 0, 0, 0, time.UTC)
+	if got := schedule.Next(from); !got.Equal(want) || got.Location() != nairobi {
+		t.Errorf("Next = %v, want %v in Africa/Nairobi", got, want)
+	}
+}
+
+func TestNextDaylightSaving(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+
+	// 2025-03-09 02:30 does not exist in New York; that day is skipped
+	spring, _ := Parse("30 2 * * *")
+	from := time.Date(2025, 3, 8, 12, 0, 0, 0, ny)
+	want := time.Date(2025, 3, 10, 2, 30, 0, 0, ny)
+	if got := spring.Next(from); !got.Equal(want) {
+		t.Errorf("spring forward: Next = %v, want %v", got, want)
+	}
+
+	// 2025-11-02 01:30 happens twice in New York; it fires once
+	fall, _ := Parse("30 1 * * *")
+	first := fall.Next(time.Date(2025, 11, 1, 12, 0, 0, 0, ny))
+	if want := time.Date(2025, 11, 2, 5, 30, 0, 0, time.UTC); !first.Equal(want) {
+		t.Fatalf("fall back: Next = %v, want %v", first, want)
+	}
+	if got, want := fall.Next(first), time.Date(2025, 11, 3, 1, 30, 0, 0, ny); !got.Equal(want) {
+		t.Errorf("fall back: Next after the first 01:30 = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (q *Queries) CreateActionRun(ctx context.Context, arg *db.CreateActionRunParams) (*db.ActionRun, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !arg.SubmissionID.Valid {
+		return nil, notNullViolation("action_runs", "submission_id")
+	}
+	if _, ok := q.submissions[arg.SubmissionID.Bytes]; !ok {
+		return nil, foreignKeyViolation("action_runs", "action_runs_submission_id_fkey")
+	}
+	if _, ok := q.runs[arg.WorkflowRunID.Bytes]; arg.WorkflowRunID.Valid && !ok {
+		return nil, foreignKeyViolation("action_runs", "action_runs_workflow_run_id_fkey")
+	}
+
+	run := &db.ActionRun{
+		ID:            newID(),
+		SubmissionID:  arg.SubmissionID,
+		WorkflowRunID: arg.WorkflowRunID,
+		ActionID:      arg.ActionID,
+		ActionType:    arg.ActionType,
+		Attempt:       arg.Attempt,
+		Step:          arg.Step,
+		Status:        arg.Status,
+		Config:        cloneBytes(arg.Config),
+		Output:        cloneBytes(arg.Output),
+		Error:         arg.Error,
+		StartedAt:     arg.StartedAt.UTC().Truncate(time.Microsecond),
+		FinishedAt:    arg.FinishedAt.UTC().Truncate(time.Microsecond),
+	}
+	q.actionRuns[run.ID.Bytes] = run
+
+	return cloneActionRun(run), nil
+}
+
+func (q *Queries) ListActionRunsBySubmission(ctx context.Context, submissionID pgtype.UUID) ([]*db.ActionRun, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	items := []*db.ActionRun{}
+	for _, run := range q.actionRuns {
+		if run.SubmissionID == submissionID && submissionID.Valid {
+			items = append(items, cloneActionRun(run))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if c := items[i].StartedAt.Compare(items[j].StartedAt); c != 0 {
+			return c < 0
+		}
+		return items[i].Step < items[j].Step
+	})
+	return items, nil
+}
+
+func (q *Queries) DeleteActionRunsFinishedBefore(ctx context.Context, finishedAt time.Time) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var n int64
+	for key, run := range q.actionRuns {
+		if run.FinishedAt.Before(finishedAt) {
+			delete(q.actionRuns, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cloneActionRun(run *db.ActionRun) *db.ActionRun {
+	c := *run
+	c.Config = cloneBytes(run.Config)
+	c.Output = cloneBytes(run.Output)
+	return &c
+}
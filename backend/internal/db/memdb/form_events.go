@@ -0,0 +1,41 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+)
+
+func (q *Queries) CreateFormEvent(ctx context.Context, arg *db.CreateFormEventParams) error {
+	if !arg.WorkflowID.Valid {
+		return notNullViolation("form_events", "workflow_id")
+	}
+	if !arg.SessionID.Valid {
+		return notNullViolation("form_events", "session_id")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.workflows[arg.WorkflowID.Bytes]; !ok {
+		return foreignKeyViolation("form_events", "form_events_workflow_id_fkey")
+	}
+
+	// ON CONFLICT (workflow_id, session_id, event_type) DO NOTHING
+	for _, event := range q.formEvents {
+		if event.WorkflowID == arg.WorkflowID && event.SessionID == arg.SessionID && event.EventType == arg.EventType {
+			return nil
+		}
+	}
+
+	event := &db.FormEvent{
+		ID:         newID(),
+		WorkflowID: arg.WorkflowID,
+		SessionID:  arg.SessionID,
+		EventType:  arg.EventType,
+		CreatedAt:  q.timestamp(),
+	}
+	q.formEvents[event.ID.Bytes] = event
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (q *Queries) CreateForm(ctx context.Context, arg *db.CreateFormParams) (*db.Form, error) {
+	if arg.Schema == nil {
+		return nil, notNullViolation("forms", "schema")
+	}
+	if !arg.OwnerID.Valid {
+		return nil, notNullViolation("forms", "owner_id")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.timestamp()
+	form := &db.Form{
+		ID:          newID(),
+		Name:        arg.Name,
+		Description: arg.Description,
+		Schema:      cloneBytes(arg.Schema),
+		OwnerID:     arg.OwnerID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	q.forms[form.ID.Bytes] = form
+
+	return cloneForm(form), nil
+}
+
+func (q *Queries) GetForm(ctx context.Context, id pgtype.UUID) (*db.Form, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	form, ok := q.forms[id.Bytes]
+	if !ok || !id.Valid {
+		return nil, pgx.ErrNoRows
+	}
+	return cloneForm(form), nil
+}
+
+func (q *Queries) ListForms(ctx context.Context, ownerID pgtype.UUID) ([]*db.Form, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	items := []*db.Form{}
+	for _, form := range q.forms {
+		if ownerID.Valid && form.OwnerID == ownerID {
+			items = append(items, cloneForm(form))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.After(items[j].CreatedAt)
+	})
+	return items, nil
+}
+
+func (q *Queries) UpdateForm(ctx context.Context, arg *db.UpdateFormParams) (*db.Form, error) {
+	if arg.Schema == nil {
+		return nil, notNullViolation("forms", "schema")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	form, ok := q.forms[arg.ID.Bytes]
+	if !ok || !arg.ID.Valid {
+		return nil, pgx.ErrNoRows
+	}
+
+	form.Name = arg.Name
+	form.Description = arg.Description
+	form.Schema = cloneBytes(arg.Schema)
+	form.UpdatedAt = q.timestamp()
+
+	return cloneForm(form), nil
+}
+
+func (q *Queries) DeleteForm(ctx context.Context, id pgtype.UUID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.forms[id.Bytes]; !ok || !id.Valid {
+		return nil
+	}
+	delete(q.forms, id.Bytes)
+
+	// submissions.schema_id is ON DELETE SET NULL, which fires the updated_at trigger
+	now := q.timestamp()
+	for _, submission := range q.submissions {
+		if submission.SchemaID == id {
+			submission.SchemaID = pgtype.UUID{}
+			submission.UpdatedAt = now
+		}
+	}
+
+	return nil
+}
+
+func cloneForm(form *db.Form) *db.Form {
+	c := *form
+	c.Schema = cloneBytes(form.Schema)
+	return &c
+}
@@ -0,0 +1,155 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (q *Queries) EnqueueJob(ctx context.Context, arg *db.EnqueueJobParams) (*db.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if arg.Payload == nil {
+		return nil, notNullViolation("jobs", "payload")
+	}
+
+	now := q.timestamp()
+	job := &db.Job{
+		ID:        newID(),
+		Kind:      arg.Kind,
+		Payload:   cloneBytes(arg.Payload),
+		Status:    "queued",
+		RunAt:     now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	q.jobs[job.ID.Bytes] = job
+
+	return cloneJob(job), nil
+}
+
+func (q *Queries) GetJob(ctx context.Context, id pgtype.UUID) (*db.Job, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	job, ok := q.jobs[id.Bytes]
+	if !ok || !id.Valid {
+		return nil, pgx.ErrNoRows
+	}
+	return cloneJob(job), nil
+}
+
+func (q *Queries) ListJobsByStatus(ctx context.Context, status string) ([]*db.Job, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	items := []*db.Job{}
+	for _, job := range q.jobs {
+		if job.Status == status {
+			items = append(items, cloneJob(job))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return compareRow(items[i].CreatedAt, items[i].ID, items[j].CreatedAt, items[j].ID) < 0
+	})
+	return items, nil
+}
+
+func (q *Queries) CountJobsByStatus(ctx context.Context) ([]*db.CountJobsByStatusRow, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	counts := make(map[string]int64)
+	for _, job := range q.jobs {
+		counts[job.Status]++
+	}
+
+	items := []*db.CountJobsByStatusRow{}
+	for status, count := range counts {
+		items = append(items, &db.CountJobsByStatusRow{Status: status, Count: count})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Status < items[j].Status
+	})
+	return items, nil
+}
+
+func (q *Queries) ClaimJob(ctx context.Context, arg *db.ClaimJobParams) (*db.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var next *db.Job
+	for _, job := range q.jobs {
+		runnable := job.Status == "queued" && !job.RunAt.After(arg.Now) ||
+			job.Status == "running" && job.LockedUntil.Valid && !job.LockedUntil.Time.After(arg.Now)
+		if !runnable {
+			continue
+		}
+		if next == nil || compareRow(job.RunAt, job.ID, next.RunAt, next.ID) < 0 {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, pgx.ErrNoRows
+	}
+
+	next.Status = "running"
+	next.Attempts++
+	next.LockedUntil = arg.LockedUntil
+	next.UpdatedAt = q.timestamp()
+
+	return cloneJob(next), nil
+}
+
+func (q *Queries) CompleteJob(ctx context.Context, arg *db.CompleteJobParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[arg.ID.Bytes]
+	if !ok || !arg.ID.Valid || job.Attempts != arg.Attempts {
+		return 0, nil
+	}
+	delete(q.jobs, arg.ID.Bytes)
+	return 1, nil
+}
+
+func (q *Queries) RetryJob(ctx context.Context, arg *db.RetryJobParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[arg.ID.Bytes]
+	if !ok || !arg.ID.Valid || job.Attempts != arg.Attempts {
+		return 0, nil
+	}
+	job.Status = "queued"
+	job.RunAt = arg.RunAt
+	job.LastError = arg.LastError
+	job.LockedUntil = pgtype.Timestamptz{}
+	job.UpdatedAt = q.timestamp()
+	return 1, nil
+}
+
+func (q *Queries) DeadLetterJob(ctx context.Context, arg *db.DeadLetterJobParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[arg.ID.Bytes]
+	if !ok || !arg.ID.Valid || job.Attempts != arg.Attempts {
+		return 0, nil
+	}
+	job.Status = "dead"
+	job.LastError = arg.LastError
+	job.LockedUntil = pgtype.Timestamptz{}
+	job.UpdatedAt = q.timestamp()
+	return 1, nil
+}
+
+func cloneJob(job *db.Job) *db.Job {
+	c := *job
+	c.Payload = cloneBytes(job.Payload)
+	return &c
+}
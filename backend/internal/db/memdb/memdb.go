@@ -0,0 +1,138 @@
+// Package memdb is an in-memory implementation of db.Store for tests.
+//
+// It mimics the behaviour of the Postgres schema that the queries rely on:
+// generated IDs and timestamps, updated_at triggers, unique, not-null and
+// foreign key constraints, ON DELETE CASCADE and SET NULL, and pgx.ErrNoRows
+// for :one queries that match nothing. Constraint violations are returned as
+// *pgconn.PgError with the Postgres error code, like the real driver does.
+//
+// jsonpath data filters are not evaluated; queries that use one return an error.
+package memdb
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrDataFilterUnsupported is returned by queries given a jsonpath data filter.
+var ErrDataFilterUnsupported = errors.New("memdb: jsonpath data filters are not supported")
+
+// Queries is a thread-safe in-memory db.Store. The zero value is not usable; call New.
+type Queries struct {
+	mu   sync.RWMutex
+	txMu sync.Mutex // Held for the duration of ExecTx.
+	now  func() time.Time
+	last time.Time
+
+	workflows   map[[16]byte]*db.Workflow
+	forms       map[[16]byte]*db.Form
+	submissions map[[16]byte]*db.Submission
+	users       map[[16]byte]*db.User
+	formEvents  map[[16]byte]*db.FormEvent
+	transitions map[[16]byte]*db.WorkflowStatusTransition
+	webhooks    map[[16]byte]*db.WorkflowWebhook
+	runs        map[[16]byte]*db.WorkflowRun
+	schedules   map[[16]byte]*db.WorkflowSchedule
+	jobs        map[[16]byte]*db.Job
+	actionRuns  map[[16]byte]*db.ActionRun
+	secrets     map[[16]byte]*db.WorkflowSigningSecret
+}
+
+var _ db.Querier = (*Queries)(nil)
+
+// New creates an empty in-memory database
+func New() *Queries {
+	return &Queries{
+		now:         time.Now,
+		workflows:   make(map[[16]byte]*db.Workflow),
+		forms:       make(map[[16]byte]*db.Form),
+		submissions: make(map[[16]byte]*db.Submission),
+		users:       make(map[[16]byte]*db.User),
+		formEvents:  make(map[[16]byte]*db.FormEvent),
+		transitions: make(map[[16]byte]*db.WorkflowStatusTransition),
+		webhooks:    make(map[[16]byte]*db.WorkflowWebhook),
+		runs:        make(map[[16]byte]*db.WorkflowRun),
+		schedules:   make(map[[16]byte]*db.WorkflowSchedule),
+		jobs:        make(map[[16]byte]*db.Job),
+		actionRuns:  make(map[[16]byte]*db.ActionRun),
+		secrets:     make(map[[16]byte]*db.WorkflowSigningSecret),
+	}
+}
+
+// SetClock replaces the source of NOW(). Timestamps handed out are still strictly
+// increasing, so rows created by consecutive calls never share a created_at.
+func (q *Queries) SetClock(now func() time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.now = now
+	q.last = time.Time{}
+}
+
+// timestamp returns the value of NOW() for the next statement, truncated to the
+// microsecond precision of timestamptz. The caller must hold the write lock.
+func (q *Queries) timestamp() time.Time {
+	t := q.now().UTC().Truncate(time.Microsecond)
+	if !t.After(q.last) && !q.last.IsZero() {
+		t = q.last.Add(time.Microsecond)
+	}
+	q.last = t
+	return t
+}
+
+// Helper functions
+
+func newID() pgtype.UUID {
+	return pgtype.UUID{Bytes: uuid.New(), Valid: true}
+}
+
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+// compareRow orders rows by (created_at, id) like a Postgres row comparison.
+func compareRow(aCreated time.Time, aID pgtype.UUID, bCreated time.Time, bID pgtype.UUID) int {
+	if c := aCreated.Compare(bCreated); c != 0 {
+		return c
+	}
+	return bytes.Compare(aID.Bytes[:], bID.Bytes[:])
+}
+
+func uniqueViolation(table, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23505",
+		Message:        "duplicate key value violates unique constraint \"" + constraint + "\"",
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+func foreignKeyViolation(table, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23503",
+		Message:        "insert or update on table \"" + table + "\" violates foreign key constraint \"" + constraint + "\"",
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+func notNullViolation(table, column string) error {
+	return &pgconn.PgError{
+		Severity:   "ERROR",
+		Code:       "23502",
+		Message:    "null value in column \"" + column + "\" of relation \"" + table + "\" violates not-null constraint",
+		TableName:  table,
+		ColumnName: column,
+	}
+}
@@ -0,0 +1,298 @@
+package memdb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func newWorkflow(t *testing.T, q *Queries, schemaID pgtype.UUID) *db.Workflow {
+	t.Helper()
+
+	workflow, err := q.CreateWorkflow(context.Background(), &db.CreateWorkflowParams{
+		Name:     "Intake",
+		Status:   "active",
+		OwnerID:  pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		SchemaID: schemaID,
+		Trigger:  []byte(`{}`),
+		Actions:  []byte(`[]`),
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	return workflow
+}
+
+func newSubmission(t *testing.T, q *Queries, workflow *db.Workflow) *db.Submission {
+	t.Helper()
+
+	submission, err := q.CreateSubmission(context.Background(), &db.CreateSubmissionParams{
+		WorkflowID: workflow.ID,
+		SchemaID:   workflow.SchemaID,
+		Data:       []byte(`{}`),
+		Metadata:   []byte(`{}`),
+		Status:     "pending",
+	})
+	if err != nil {
+		t.Fatalf("CreateSubmission: %v", err)
+	}
+	return submission
+}
+
+func pgCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+func TestDeleteWorkflowCascades(t *testing.T) {
+	ctx := context.Background()
+	q := New()
+	workflow := newWorkflow(t, q, pgtype.UUID{})
+	submission := newSubmission(t, q, workflow)
+
+	err := q.CreateFormEvent(ctx, &db.CreateFormEventParams{
+		WorkflowID: workflow.ID,
+		SessionID:  pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		EventType:  "view",
+	})
+	if err != nil {
+		t.Fatalf("CreateFormEvent: %v", err)
+	}
+	_, err = q.TransitionWorkflowStatus(ctx, &db.TransitionWorkflowStatusParams{
+		ID: workflow.ID, Status: "paused", ExpectedStatus: "active", ChangedBy: workflow.OwnerID,
+	})
+	if err != nil {
+		t.Fatalf("TransitionWorkflowStatus: %v", err)
+	}
+	_, err = q.CreateWorkflowWebhook(ctx, &db.CreateWorkflowWebhookParams{
+		WorkflowID: workflow.ID, Token: "token", Secret: "secret",
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflowWebhook: %v", err)
+	}
+
+	if err := q.DeleteWorkflow(ctx, workflow.ID); err != nil {
+		t.Fatalf("DeleteWorkflow: %v", err)
+	}
+
+	if _, err := q.GetSubmission(ctx, submission.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("submission survived the cascade: %v", err)
+	}
+	summary, err := q.GetWorkflowSubmissionSummary(ctx, workflow.ID)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("summary of a deleted workflow = %+v, %v", summary, err)
+	}
+	if transitions, _ := q.ListWorkflowStatusTransitions(ctx, workflow.ID); len(transitions) != 0 {
+		t.Errorf("transitions survived the cascade: %v", transitions)
+	}
+	if len(q.formEvents) != 0 {
+		t.Errorf("form events survived the cascade")
+	}
+	if _, err := q.GetWorkflowWebhookByToken(ctx, "token"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("webhook survived the cascade: %v", err)
+	}
+}
+
+func TestDeleteFormSetsSubmissionSchemaNull(t *testing.T) {
+	ctx := context.Background()
+	q := New()
+
+	form, err := q.CreateForm(ctx, &db.CreateFormParams{
+		Name:    "Contact",
+		Schema:  []byte(`{}`),
+		OwnerID: pgtype.UUID{Bytes: uuid.New(), Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateForm: %v", err)
+	}
+	submission := newSubmission(t, q, newWorkflow(t, q, form.ID))
+
+	if err := q.DeleteForm(ctx, form.ID); err != nil {
+		t.Fatalf("DeleteForm: %v", err)
+	}
+
+	got, err := q.GetSubmission(ctx, submission.ID)
+	if err != nil {
+		t.Fatalf("GetSubmission: %v", err)
+	}
+	if got.SchemaID.Valid {
+		t.Errorf("schema ID = %v, want NULL", got.SchemaID)
+	}
+	if !got.UpdatedAt.After(submission.UpdatedAt) {
+		t.Errorf("updated_at was not bumped")
+	}
+}
+
+func TestDeleteSubmissionSetsRunSubmissionNull(t *testing.T) {
+	ctx := context.Background()
+	q := New()
+	workflow := newWorkflow(t, q, pgtype.UUID{})
+	submission := newSubmission(t, q, workflow)
+
+	run, err := q.CreateWorkflowRun(ctx, &db.CreateWorkflowRunParams{
+		WorkflowID:   workflow.ID,
+		SubmissionID: submission.ID,
+		TriggeredBy:  workflow.OwnerID,
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflowRun: %v", err)
+	}
+
+	if err := q.DeleteSubmission(ctx, submission.ID); err != nil {
+		t.Fatalf("DeleteSubmission: %v", err)
+	}
+
+	got, err := q.GetWorkflowRun(ctx, run.ID)
+	if err != nil {
+		t.Fatalf("GetWorkflowRun: %v", err)
+	}
+	if got.SubmissionID.Valid {
+		t.Errorf("submission ID = %v, want NULL", got.SubmissionID)
+	}
+}
+
+func TestDeleteSubmissionCascadesActionRuns(t *testing.T) {
+	ctx := context.Background()
+	q := New()
+	workflow := newWorkflow(t, q, pgtype.UUID{})
+	submission := newSubmission(t, q, workflow)
+	other := newSubmission(t, q, workflow)
+
+	for _, s := range []*db.Submission{submission, other} {
+		_, err := q.CreateActionRun(ctx, &db.CreateActionRunParams{
+			SubmissionID: s.ID,
+			ActionID:     "notify",
+			ActionType:   "send_email",
+			Attempt:      1,
+			Step:         1,
+			Status:       "completed",
+			StartedAt:    time.Now(),
+			FinishedAt:   time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("CreateActionRun: %v", err)
+		}
+	}
+
+	if err := q.DeleteSubmission(ctx, submission.ID); err != nil {
+		t.Fatalf("DeleteSubmission: %v", err)
+	}
+
+	if runs, _ := q.ListActionRunsBySubmission(ctx, submission.ID); len(runs) != 0 {
+		t.Errorf("action runs survived the cascade: %v", runs)
+	}
+	if runs, _ := q.ListActionRunsBySubmission(ctx, other.ID); len(runs) != 1 {
+		t.Errorf("action runs of another submission = %v, want 1", runs)
+	}
+}
+
+func TestUpdateBumpsUpdatedAt(t *testing.T) {
+	ctx := context.Background()
+	q := New()
+	workflow := newWorkflow(t, q, pgtype.UUID{})
+
+	updated, err := q.UpdateWorkflowStatus(ctx, &db.UpdateWorkflowStatusParams{ID: workflow.ID, Status: "paused"})
+	if err != nil {
+		t.Fatalf("UpdateWorkflowStatus: %v", err)
+	}
+	if !updated.UpdatedAt.After(workflow.UpdatedAt) || !updated.CreatedAt.Equal(workflow.CreatedAt) {
+		t.Errorf("timestamps = %v/%v, want only updated_at bumped", updated.CreatedAt, updated.UpdatedAt)
+	}
+}
+
+func TestTransitionWorkflowStatusRequiresExpectedStatus(t *testing.T) {
+	ctx := context.Background()
+	q := New()
+	workflow := newWorkflow(t, q, pgtype.UUID{})
+
+	_, err := q.TransitionWorkflowStatus(ctx, &db.TransitionWorkflowStatusParams{
+		ID: workflow.ID, Status: "active", ExpectedStatus: "draft", ChangedBy: workflow.OwnerID,
+	})
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("got %v, want pgx.ErrNoRows", err)
+	}
+	if transitions, _ := q.ListWorkflowStatusTransitions(ctx, workflow.ID); len(transitions) != 0 {
+		t.Errorf("a failed transition was recorded: %v", transitions)
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	ctx := context.Background()
+	q := New()
+
+	user := &db.CreateUserParams{Email: "ada@example.com", PasswordHash: "x"}
+	if _, err := q.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if _, err := q.CreateUser(ctx, user); pgCode(err) != "23505" {
+		t.Errorf("duplicate email: got %v, want a unique violation", err)
+	}
+
+	_, err := q.CreateSubmission(ctx, &db.CreateSubmissionParams{
+		WorkflowID: pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		Data:       []byte(`{}`),
+		Metadata:   []byte(`{}`),
+	})
+	if pgCode(err) != "23503" {
+		t.Errorf("unknown workflow: got %v, want a foreign key violation", err)
+	}
+
+	_, err = q.CreateForm(ctx, &db.CreateFormParams{Name: "Contact", OwnerID: pgtype.UUID{Bytes: uuid.New(), Valid: true}})
+	if pgCode(err) != "23502" {
+		t.Errorf("missing schema: got %v, want a not-null violation", err)
+	}
+}
+
+func TestDataFilterUnsupported(t *testing.T) {
+	q := New()
+
+	_, err := q.CountSubmissions(context.Background(), &db.CountSubmissionsParams{
+		DataFilter: pgtype.Text{String: `$.a == 1`, Valid: true},
+	})
+	if !errors.Is(err, ErrDataFilterUnsupported) {
+		t.Errorf("got %v, want ErrDataFilterUnsupported", err)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	q := New()
+	workflow := newWorkflow(t, q, pgtype.UUID{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				_, err := q.CreateSubmission(ctx, &db.CreateSubmissionParams{
+					WorkflowID: workflow.ID,
+					Data:       []byte(`{}`),
+					Metadata:   []byte(`{}`),
+				})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				q.ListSubmissions(ctx, workflow.ID)
+			}
+		}()
+	}
+	wg.Wait()
+
+	count, err := q.CountSubmissions(ctx, &db.CountSubmissionsParams{WorkflowID: workflow.ID})
+	if err != nil || count != 200 {
+		t.Errorf("count = %d, %v, want 200", count, err)
+	}
+}
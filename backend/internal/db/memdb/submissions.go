@@ -0,0 +1,257 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (q *Queries) CreateSubmission(ctx context.Context, arg *db.CreateSubmissionParams) (*db.Submission, error) {
+	if arg.Data == nil {
+		return nil, notNullViolation("submissions", "data")
+	}
+	if arg.Metadata == nil {
+		return nil, notNullViolation("submissions", "metadata")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.workflows[arg.WorkflowID.Bytes]; !ok || !arg.WorkflowID.Valid {
+		if !arg.WorkflowID.Valid {
+			return nil, notNullViolation("submissions", "workflow_id")
+		}
+		return nil, foreignKeyViolation("submissions", "submissions_workflow_id_fkey")
+	}
+	if _, ok := q.forms[arg.SchemaID.Bytes]; arg.SchemaID.Valid && !ok {
+		return nil, foreignKeyViolation("submissions", "submissions_schema_id_fkey")
+	}
+
+	now := q.timestamp()
+	submission := &db.Submission{
+		ID:         newID(),
+		WorkflowID: arg.WorkflowID,
+		SchemaID:   arg.SchemaID,
+		Data:       cloneBytes(arg.Data),
+		Metadata:   cloneBytes(arg.Metadata),
+		Status:     arg.Status,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	q.submissions[submission.ID.Bytes] = submission
+
+	return cloneSubmission(submission), nil
+}
+
+func (q *Queries) GetSubmission(ctx context.Context, id pgtype.UUID) (*db.Submission, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	submission, ok := q.submissions[id.Bytes]
+	if !ok || !id.Valid {
+		return nil, pgx.ErrNoRows
+	}
+	return cloneSubmission(submission), nil
+}
+
+func (q *Queries) ListSubmissions(ctx context.Context, workflowID pgtype.UUID) ([]*db.Submission, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	items := q.selectSubmissions(func(s *db.Submission) bool {
+		return workflowID.Valid && s.WorkflowID == workflowID
+	})
+	sortSubmissions(items, true)
+	return items, nil
+}
+
+func (q *Queries) ListSubmissionsByOwner(ctx context.Context, ownerID pgtype.UUID) ([]*db.Submission, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	items := q.selectSubmissions(func(s *db.Submission) bool {
+		return ownerID.Valid && q.matches(submissionFilter{OwnerID: ownerID}, s)
+	})
+	sortSubmissions(items, true)
+	return items, nil
+}
+
+func (q *Queries) ListSubmissionsPageAsc(ctx context.Context, arg *db.ListSubmissionsPageAscParams) ([]*db.Submission, error) {
+	return q.listSubmissionsPage(submissionFilter{
+		WorkflowID:  arg.WorkflowID,
+		OwnerID:     arg.OwnerID,
+		Status:      arg.Status,
+		CreatedFrom: arg.CreatedFrom,
+		CreatedTo:   arg.CreatedTo,
+		DataFilter:  arg.DataFilter,
+	}, arg.CursorCreatedAt, arg.CursorID, arg.PageLimit, false)
+}
+
+func (q *Queries) ListSubmissionsPageDesc(ctx context.Context, arg *db.ListSubmissionsPageDescParams) ([]*db.Submission, error) {
+	return q.listSubmissionsPage(submissionFilter{
+		WorkflowID:  arg.WorkflowID,
+		OwnerID:     arg.OwnerID,
+		Status:      arg.Status,
+		CreatedFrom: arg.CreatedFrom,
+		CreatedTo:   arg.CreatedTo,
+		DataFilter:  arg.DataFilter,
+	}, arg.CursorCreatedAt, arg.CursorID, arg.PageLimit, true)
+}
+
+func (q *Queries) CountSubmissions(ctx context.Context, arg *db.CountSubmissionsParams) (int64, error) {
+	filter := submissionFilter{
+		WorkflowID:  arg.WorkflowID,
+		OwnerID:     arg.OwnerID,
+		Status:      arg.Status,
+		CreatedFrom: arg.CreatedFrom,
+		CreatedTo:   arg.CreatedTo,
+		DataFilter:  arg.DataFilter,
+	}
+	if filter.DataFilter.Valid {
+		return 0, ErrDataFilterUnsupported
+	}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	var count int64
+	for _, submission := range q.submissions {
+		if q.matches(filter, submission) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *Queries) UpdateSubmissionStatus(ctx context.Context, arg *db.UpdateSubmissionStatusParams) (*db.Submission, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	submission, ok := q.submissions[arg.ID.Bytes]
+	if !ok || !arg.ID.Valid {
+		return nil, pgx.ErrNoRows
+	}
+
+	submission.Status = arg.Status
+	submission.UpdatedAt = q.timestamp()
+
+	return cloneSubmission(submission), nil
+}
+
+func (q *Queries) DeleteSubmission(ctx context.Context, id pgtype.UUID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.submissions[id.Bytes]; !ok || !id.Valid {
+		return nil
+	}
+	delete(q.submissions, id.Bytes)
+
+	// workflow_runs.submission_id is ON DELETE SET NULL
+	for _, run := range q.runs {
+		if run.SubmissionID == id {
+			run.SubmissionID = pgtype.UUID{}
+		}
+	}
+	// action_runs.submission_id is ON DELETE CASCADE
+	for key, run := range q.actionRuns {
+		if run.SubmissionID == id {
+			delete(q.actionRuns, key)
+		}
+	}
+	return nil
+}
+
+// submissionFilter holds the optional filters shared by the paging and count queries.
+type submissionFilter struct {
+	WorkflowID  pgtype.UUID
+	OwnerID     pgtype.UUID
+	Status      pgtype.Text
+	CreatedFrom pgtype.Timestamptz
+	CreatedTo   pgtype.Timestamptz
+	DataFilter  pgtype.Text
+}
+
+func (q *Queries) listSubmissionsPage(filter submissionFilter, cursorCreatedAt pgtype.Timestamptz, cursorID pgtype.UUID, limit int32, desc bool) ([]*db.Submission, error) {
+	if filter.DataFilter.Valid {
+		return nil, ErrDataFilterUnsupported
+	}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	items := q.selectSubmissions(func(s *db.Submission) bool {
+		if !q.matches(filter, s) {
+			return false
+		}
+		if !cursorCreatedAt.Valid {
+			return true
+		}
+		c := compareRow(s.CreatedAt, s.ID, cursorCreatedAt.Time, cursorID)
+		if desc {
+			return c < 0
+		}
+		return c > 0
+	})
+	sortSubmissions(items, desc)
+
+	if limit >= 0 && len(items) > int(limit) {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+// matches evaluates the WHERE clause of the paging queries, including the join on workflows.
+func (q *Queries) matches(filter submissionFilter, s *db.Submission) bool {
+	workflow, ok := q.workflows[s.WorkflowID.Bytes]
+	if !ok {
+		return false
+	}
+	if filter.WorkflowID.Valid && s.WorkflowID != filter.WorkflowID {
+		return false
+	}
+	if filter.OwnerID.Valid && workflow.OwnerID != filter.OwnerID {
+		return false
+	}
+	if filter.Status.Valid && s.Status != filter.Status.String {
+		return false
+	}
+	if filter.CreatedFrom.Valid && s.CreatedAt.Before(filter.CreatedFrom.Time) {
+		return false
+	}
+	if filter.CreatedTo.Valid && !s.CreatedAt.Before(filter.CreatedTo.Time) {
+		return false
+	}
+	return true
+}
+
+// selectSubmissions returns clones of the submissions accepted by keep. The caller must hold the lock.
+func (q *Queries) selectSubmissions(keep func(*db.Submission) bool) []*db.Submission {
+	items := []*db.Submission{}
+	for _, submission := range q.submissions {
+		if keep(submission) {
+			items = append(items, cloneSubmission(submission))
+		}
+	}
+	return items
+}
+
+func sortSubmissions(items []*db.Submission, desc bool) {
+	sort.Slice(items, func(i, j int) bool {
+		c := compareRow(items[i].CreatedAt, items[i].ID, items[j].CreatedAt, items[j].ID)
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+func cloneSubmission(submission *db.Submission) *db.Submission {
+	c := *submission
+	c.Data = cloneBytes(submission.Data)
+	c.Metadata = cloneBytes(submission.Metadata)
+	return &c
+}
@@ -0,0 +1,121 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+)
+
+var _ db.Store = (*Queries)(nil)
+
+// ExecTx runs fn with the database itself as the transaction. Transactions run one at
+// a time, which is as strict as serializable isolation; statements outside ExecTx are
+// not blocked. When fn fails every table is restored to its state before the call.
+func (q *Queries) ExecTx(ctx context.Context, opts db.TxOptions, fn func(q db.Querier) error) error {
+	q.txMu.Lock()
+	defer q.txMu.Unlock()
+
+	snapshot := q.snapshot()
+	if err := fn(q); err != nil {
+		q.restore(snapshot)
+		return err
+	}
+	return nil
+}
+
+// tables holds a deep copy of every table.
+type tables struct {
+	workflows   map[[16]byte]*db.Workflow
+	forms       map[[16]byte]*db.Form
+	submissions map[[16]byte]*db.Submission
+	users       map[[16]byte]*db.User
+	formEvents  map[[16]byte]*db.FormEvent
+	transitions map[[16]byte]*db.WorkflowStatusTransition
+	webhooks    map[[16]byte]*db.WorkflowWebhook
+	runs        map[[16]byte]*db.WorkflowRun
+	schedules   map[[16]byte]*db.WorkflowSchedule
+	jobs        map[[16]byte]*db.Job
+	actionRuns  map[[16]byte]*db.ActionRun
+	secrets     map[[16]byte]*db.WorkflowSigningSecret
+}
+
+func (q *Queries) snapshot() tables {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	t := tables{
+		workflows:   make(map[[16]byte]*db.Workflow, len(q.workflows)),
+		forms:       make(map[[16]byte]*db.Form, len(q.forms)),
+		submissions: make(map[[16]byte]*db.Submission, len(q.submissions)),
+		users:       make(map[[16]byte]*db.User, len(q.users)),
+		formEvents:  make(map[[16]byte]*db.FormEvent, len(q.formEvents)),
+		transitions: make(map[[16]byte]*db.WorkflowStatusTransition, len(q.transitions)),
+		webhooks:    make(map[[16]byte]*db.WorkflowWebhook, len(q.webhooks)),
+		runs:        make(map[[16]byte]*db.WorkflowRun, len(q.runs)),
+		schedules:   make(map[[16]byte]*db.WorkflowSchedule, len(q.schedules)),
+		jobs:        make(map[[16]byte]*db.Job, len(q.jobs)),
+		actionRuns:  make(map[[16]byte]*db.ActionRun, len(q.actionRuns)),
+		secrets:     make(map[[16]byte]*db.WorkflowSigningSecret, len(q.secrets)),
+	}
+	for k, v := range q.workflows {
+		t.workflows[k] = cloneWorkflow(v)
+	}
+	for k, v := range q.forms {
+		t.forms[k] = cloneForm(v)
+	}
+	for k, v := range q.submissions {
+		t.submissions[k] = cloneSubmission(v)
+	}
+	for k, v := range q.users {
+		c := *v
+		t.users[k] = &c
+	}
+	for k, v := range q.formEvents {
+		c := *v
+		t.formEvents[k] = &c
+	}
+	for k, v := range q.transitions {
+		c := *v
+		t.transitions[k] = &c
+	}
+	for k, v := range q.webhooks {
+		c := *v
+		t.webhooks[k] = &c
+	}
+	for k, v := range q.runs {
+		t.runs[k] = cloneWorkflowRun(v)
+	}
+	for k, v := range q.schedules {
+		c := *v
+		t.schedules[k] = &c
+	}
+	for k, v := range q.jobs {
+		t.jobs[k] = cloneJob(v)
+	}
+	for k, v := range q.actionRuns {
+		t.actionRuns[k] = cloneActionRun(v)
+	}
+	for k, v := range q.secrets {
+		c := *v
+		t.secrets[k] = &c
+	}
+	return t
+}
+
+func (q *Queries) restore(t tables) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.workflows = t.workflows
+	q.forms = t.forms
+	q.submissions = t.submissions
+	q.users = t.users
+	q.formEvents = t.formEvents
+	q.transitions = t.transitions
+	q.webhooks = t.webhooks
+	q.runs = t.runs
+	q.schedules = t.schedules
+	q.jobs = t.jobs
+	q.actionRuns = t.actionRuns
+	q.secrets = t.secrets
+}
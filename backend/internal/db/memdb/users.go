@@ -0,0 +1,59 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (q *Queries) CreateUser(ctx context.Context, arg *db.CreateUserParams) (*db.User, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, user := range q.users {
+		if user.Email == arg.Email {
+			return nil, uniqueViolation("users", "idx_users_email")
+		}
+	}
+
+	now := q.timestamp()
+	user := &db.User{
+		ID:           newID(),
+		Email:        arg.Email,
+		Name:         arg.Name,
+		PasswordHash: arg.PasswordHash,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	q.users[user.ID.Bytes] = user
+
+	c := *user
+	return &c, nil
+}
+
+func (q *Queries) GetUser(ctx context.Context, id pgtype.UUID) (*db.User, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	user, ok := q.users[id.Bytes]
+	if !ok || !id.Valid {
+		return nil, pgx.ErrNoRows
+	}
+	c := *user
+	return &c, nil
+}
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (*db.User, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	for _, user := range q.users {
+		if user.Email == email {
+			c := *user
+			return &c, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
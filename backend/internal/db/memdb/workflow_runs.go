@@ -0,0 +1,105 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (q *Queries) CreateWorkflowRun(ctx context.Context, arg *db.CreateWorkflowRunParams) (*db.WorkflowRun, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !arg.WorkflowID.Valid {
+		return nil, notNullViolation("workflow_runs", "workflow_id")
+	}
+	if !arg.TriggeredBy.Valid {
+		return nil, notNullViolation("workflow_runs", "triggered_by")
+	}
+	if _, ok := q.workflows[arg.WorkflowID.Bytes]; !ok {
+		return nil, foreignKeyViolation("workflow_runs", "workflow_runs_workflow_id_fkey")
+	}
+	if _, ok := q.submissions[arg.SubmissionID.Bytes]; arg.SubmissionID.Valid && !ok {
+		return nil, foreignKeyViolation("workflow_runs", "workflow_runs_submission_id_fkey")
+	}
+
+	run := &db.WorkflowRun{
+		ID:           newID(),
+		WorkflowID:   arg.WorkflowID,
+		SubmissionID: arg.SubmissionID,
+		Input:        cloneBytes(arg.Input),
+		Status:       "pending",
+		TriggeredBy:  arg.TriggeredBy,
+		CreatedAt:    q.timestamp(),
+	}
+	q.runs[run.ID.Bytes] = run
+
+	return cloneWorkflowRun(run), nil
+}
+
+func (q *Queries) GetWorkflowRun(ctx context.Context, id pgtype.UUID) (*db.WorkflowRun, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	run, ok := q.runs[id.Bytes]
+	if !ok || !id.Valid {
+		return nil, pgx.ErrNoRows
+	}
+	return cloneWorkflowRun(run), nil
+}
+
+func (q *Queries) ListWorkflowRuns(ctx context.Context, workflowID pgtype.UUID) ([]*db.WorkflowRun, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	items := []*db.WorkflowRun{}
+	for _, run := range q.runs {
+		if run.WorkflowID == workflowID {
+			items = append(items, cloneWorkflowRun(run))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return compareRow(items[i].CreatedAt, items[i].ID, items[j].CreatedAt, items[j].ID) > 0
+	})
+	return items, nil
+}
+
+func (q *Queries) FinishWorkflowRun(ctx context.Context, arg *db.FinishWorkflowRunParams) (*db.WorkflowRun, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	run, ok := q.runs[arg.ID.Bytes]
+	if !ok || !arg.ID.Valid {
+		return nil, pgx.ErrNoRows
+	}
+	run.Status = arg.Status
+	run.Error = arg.Error
+	run.FinishedAt = pgtype.Timestamptz{Time: q.timestamp(), Valid: true}
+
+	return cloneWorkflowRun(run), nil
+}
+
+func (q *Queries) StartWorkflowRun(ctx context.Context, id pgtype.UUID) (*db.WorkflowRun, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	run, ok := q.runs[id.Bytes]
+	if !ok || !id.Valid {
+		return nil, pgx.ErrNoRows
+	}
+	run.Status = "running"
+	run.Error = pgtype.Text{}
+	run.StartedAt = pgtype.Timestamptz{Time: q.timestamp(), Valid: true}
+	run.FinishedAt = pgtype.Timestamptz{}
+
+	return cloneWorkflowRun(run), nil
+}
+
+func cloneWorkflowRun(run *db.WorkflowRun) *db.WorkflowRun {
+	c := *run
+	c.Input = cloneBytes(run.Input)
+	return &c
+}
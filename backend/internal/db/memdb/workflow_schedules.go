@@ -0,0 +1,96 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (q *Queries) UpsertWorkflowSchedule(ctx context.Context, arg *db.UpsertWorkflowScheduleParams) (*db.WorkflowSchedule, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !arg.WorkflowID.Valid {
+		return nil, notNullViolation("workflow_schedules", "workflow_id")
+	}
+	if _, ok := q.workflows[arg.WorkflowID.Bytes]; !ok {
+		return nil, foreignKeyViolation("workflow_schedules", "workflow_schedules_workflow_id_fkey")
+	}
+
+	now := q.timestamp()
+	schedule, ok := q.schedules[arg.WorkflowID.Bytes]
+	if !ok {
+		schedule = &db.WorkflowSchedule{WorkflowID: arg.WorkflowID, CreatedAt: now}
+		q.schedules[arg.WorkflowID.Bytes] = schedule
+	}
+	schedule.CronExpression = arg.CronExpression
+	schedule.TimeZone = arg.TimeZone
+	schedule.NextFireAt = arg.NextFireAt
+	schedule.UpdatedAt = now
+
+	c := *schedule
+	return &c, nil
+}
+
+func (q *Queries) GetWorkflowSchedule(ctx context.Context, workflowID pgtype.UUID) (*db.WorkflowSchedule, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	schedule, ok := q.schedules[workflowID.Bytes]
+	if !ok || !workflowID.Valid {
+		return nil, pgx.ErrNoRows
+	}
+	c := *schedule
+	return &c, nil
+}
+
+func (q *Queries) DeleteWorkflowSchedule(ctx context.Context, workflowID pgtype.UUID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if workflowID.Valid {
+		delete(q.schedules, workflowID.Bytes)
+	}
+	return nil
+}
+
+// ClaimDueWorkflowSchedules returns the due schedules. There are no row locks; callers
+// get the same exclusion from ExecTx, which runs one transaction at a time.
+func (q *Queries) ClaimDueWorkflowSchedules(ctx context.Context, arg *db.ClaimDueWorkflowSchedulesParams) ([]*db.WorkflowSchedule, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	items := []*db.WorkflowSchedule{}
+	for _, schedule := range q.schedules {
+		if !schedule.NextFireAt.After(arg.Now) {
+			c := *schedule
+			items = append(items, &c)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].NextFireAt.Before(items[j].NextFireAt)
+	})
+	if arg.ClaimLimit >= 0 && len(items) > int(arg.ClaimLimit) {
+		items = items[:arg.ClaimLimit]
+	}
+	return items, nil
+}
+
+func (q *Queries) RecordWorkflowScheduleFire(ctx context.Context, arg *db.RecordWorkflowScheduleFireParams) (*db.WorkflowSchedule, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	schedule, ok := q.schedules[arg.WorkflowID.Bytes]
+	if !ok || !arg.WorkflowID.Valid {
+		return nil, pgx.ErrNoRows
+	}
+	schedule.LastFireAt = arg.LastFireAt
+	schedule.NextFireAt = arg.NextFireAt
+	schedule.UpdatedAt = q.timestamp()
+
+	c := *schedule
+	return &c, nil
+}
@@ -0,0 +1,46 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (q *Queries) CreateWorkflowSigningSecret(ctx context.Context, arg *db.CreateWorkflowSigningSecretParams) (*db.WorkflowSigningSecret, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !arg.WorkflowID.Valid {
+		return nil, notNullViolation("workflow_signing_secrets", "workflow_id")
+	}
+	if _, ok := q.workflows[arg.WorkflowID.Bytes]; !ok {
+		return nil, foreignKeyViolation("workflow_signing_secrets", "workflow_signing_secrets_workflow_id_fkey")
+	}
+	if _, ok := q.secrets[arg.WorkflowID.Bytes]; ok {
+		return nil, uniqueViolation("workflow_signing_secrets", "workflow_signing_secrets_pkey")
+	}
+
+	secret := &db.WorkflowSigningSecret{
+		WorkflowID: arg.WorkflowID,
+		Secret:     arg.Secret,
+		CreatedAt:  q.timestamp(),
+	}
+	q.secrets[secret.WorkflowID.Bytes] = secret
+
+	c := *secret
+	return &c, nil
+}
+
+func (q *Queries) GetWorkflowSigningSecret(ctx context.Context, workflowID pgtype.UUID) (*db.WorkflowSigningSecret, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	secret, ok := q.secrets[workflowID.Bytes]
+	if !ok || !workflowID.Valid {
+		return nil, pgx.ErrNoRows
+	}
+	c := *secret
+	return &c, nil
+}
@@ -0,0 +1,75 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (q *Queries) CreateWorkflowWebhook(ctx context.Context, arg *db.CreateWorkflowWebhookParams) (*db.WorkflowWebhook, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !arg.WorkflowID.Valid {
+		return nil, notNullViolation("workflow_webhooks", "workflow_id")
+	}
+	if _, ok := q.workflows[arg.WorkflowID.Bytes]; !ok {
+		return nil, foreignKeyViolation("workflow_webhooks", "workflow_webhooks_workflow_id_fkey")
+	}
+	if _, ok := q.webhooks[arg.WorkflowID.Bytes]; ok {
+		return nil, uniqueViolation("workflow_webhooks", "workflow_webhooks_pkey")
+	}
+	for _, webhook := range q.webhooks {
+		if webhook.Token == arg.Token {
+			return nil, uniqueViolation("workflow_webhooks", "workflow_webhooks_token_key")
+		}
+	}
+
+	webhook := &db.WorkflowWebhook{
+		WorkflowID: arg.WorkflowID,
+		Token:      arg.Token,
+		Secret:     arg.Secret,
+		CreatedAt:  q.timestamp(),
+	}
+	q.webhooks[webhook.WorkflowID.Bytes] = webhook
+
+	c := *webhook
+	return &c, nil
+}
+
+func (q *Queries) DeleteWorkflowWebhook(ctx context.Context, workflowID pgtype.UUID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if workflowID.Valid {
+		delete(q.webhooks, workflowID.Bytes)
+	}
+	return nil
+}
+
+func (q *Queries) GetWorkflowWebhook(ctx context.Context, workflowID pgtype.UUID) (*db.WorkflowWebhook, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	webhook, ok := q.webhooks[workflowID.Bytes]
+	if !ok || !workflowID.Valid {
+		return nil, pgx.ErrNoRows
+	}
+	c := *webhook
+	return &c, nil
+}
+
+func (q *Queries) GetWorkflowWebhookByToken(ctx context.Context, token string) (*db.WorkflowWebhook, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	for _, webhook := range q.webhooks {
+		if webhook.Token == token {
+			c := *webhook
+			return &c, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
@@ -0,0 +1,320 @@
+package memdb
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (q *Queries) CreateWorkflow(ctx context.Context, arg *db.CreateWorkflowParams) (*db.Workflow, error) {
+	if !arg.OwnerID.Valid {
+		return nil, notNullViolation("workflows", "owner_id")
+	}
+	if arg.Trigger == nil {
+		return nil, notNullViolation("workflows", "trigger")
+	}
+	if arg.Actions == nil {
+		return nil, notNullViolation("workflows", "actions")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.timestamp()
+	workflow := &db.Workflow{
+		ID:          newID(),
+		Name:        arg.Name,
+		Description: arg.Description,
+		Status:      arg.Status,
+		OwnerID:     arg.OwnerID,
+		SchemaID:    arg.SchemaID,
+		Trigger:     cloneBytes(arg.Trigger),
+		Actions:     cloneBytes(arg.Actions),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	q.workflows[workflow.ID.Bytes] = workflow
+
+	return cloneWorkflow(workflow), nil
+}
+
+func (q *Queries) GetWorkflow(ctx context.Context, id pgtype.UUID) (*db.Workflow, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	workflow, ok := q.workflows[id.Bytes]
+	if !ok || !id.Valid {
+		return nil, pgx.ErrNoRows
+	}
+	return cloneWorkflow(workflow), nil
+}
+
+func (q *Queries) ListWorkflows(ctx context.Context, ownerID pgtype.UUID) ([]*db.Workflow, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	items := []*db.Workflow{}
+	for _, workflow := range q.workflows {
+		if ownerID.Valid && workflow.OwnerID == ownerID {
+			items = append(items, cloneWorkflow(workflow))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.After(items[j].CreatedAt)
+	})
+	return items, nil
+}
+
+func (q *Queries) UpdateWorkflow(ctx context.Context, arg *db.UpdateWorkflowParams) (*db.Workflow, error) {
+	if arg.Trigger == nil {
+		return nil, notNullViolation("workflows", "trigger")
+	}
+	if arg.Actions == nil {
+		return nil, notNullViolation("workflows", "actions")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	workflow, ok := q.workflows[arg.ID.Bytes]
+	if !ok || !arg.ID.Valid {
+		return nil, pgx.ErrNoRows
+	}
+
+	workflow.Name = arg.Name
+	workflow.Description = arg.Description
+	workflow.SchemaID = arg.SchemaID
+	workflow.Trigger = cloneBytes(arg.Trigger)
+	workflow.Actions = cloneBytes(arg.Actions)
+	workflow.UpdatedAt = q.timestamp()
+
+	return cloneWorkflow(workflow), nil
+}
+
+func (q *Queries) UpdateWorkflowStatus(ctx context.Context, arg *db.UpdateWorkflowStatusParams) (*db.Workflow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	workflow, ok := q.workflows[arg.ID.Bytes]
+	if !ok || !arg.ID.Valid {
+		return nil, pgx.ErrNoRows
+	}
+
+	workflow.Status = arg.Status
+	workflow.UpdatedAt = q.timestamp()
+
+	return cloneWorkflow(workflow), nil
+}
+
+func (q *Queries) TransitionWorkflowStatus(ctx context.Context, arg *db.TransitionWorkflowStatusParams) (*db.Workflow, error) {
+	if !arg.ChangedBy.Valid {
+		return nil, notNullViolation("workflow_status_transitions", "changed_by")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	workflow, ok := q.workflows[arg.ID.Bytes]
+	if !ok || !arg.ID.Valid || workflow.Status != arg.ExpectedStatus {
+		return nil, pgx.ErrNoRows
+	}
+
+	now := q.timestamp()
+	workflow.Status = arg.Status
+	workflow.UpdatedAt = now
+
+	transition := &db.WorkflowStatusTransition{
+		ID:         newID(),
+		WorkflowID: workflow.ID,
+		FromStatus: arg.ExpectedStatus,
+		ToStatus:   arg.Status,
+		ChangedBy:  arg.ChangedBy,
+		ChangedAt:  now,
+	}
+	q.transitions[transition.ID.Bytes] = transition
+
+	return cloneWorkflow(workflow), nil
+}
+
+func (q *Queries) DeleteWorkflow(ctx context.Context, id pgtype.UUID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.workflows[id.Bytes]; !ok || !id.Valid {
+		return nil
+	}
+	delete(q.workflows, id.Bytes)
+
+	// Everything referencing the workflow is ON DELETE CASCADE
+	for key, submission := range q.submissions {
+		if submission.WorkflowID == id {
+			delete(q.submissions, key)
+		}
+	}
+	for key, event := range q.formEvents {
+		if event.WorkflowID == id {
+			delete(q.formEvents, key)
+		}
+	}
+	for key, transition := range q.transitions {
+		if transition.WorkflowID == id {
+			delete(q.transitions, key)
+		}
+	}
+	for key, run := range q.runs {
+		if run.WorkflowID == id {
+			delete(q.runs, key)
+		}
+	}
+	delete(q.webhooks, id.Bytes)
+	delete(q.schedules, id.Bytes)
+	delete(q.secrets, id.Bytes)
+
+	// and so are the action runs of the submissions and runs deleted above
+	for key, run := range q.actionRuns {
+		_, submission := q.submissions[run.SubmissionID.Bytes]
+		_, workflowRun := q.runs[run.WorkflowRunID.Bytes]
+		if !submission || (run.WorkflowRunID.Valid && !workflowRun) {
+			delete(q.actionRuns, key)
+		}
+	}
+
+	return nil
+}
+
+func (q *Queries) CountWorkflowsBySchema(ctx context.Context, schemaID pgtype.UUID) (int64, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	var count int64
+	for _, workflow := range q.workflows {
+		if schemaID.Valid && workflow.SchemaID == schemaID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *Queries) ListWorkflowStatusTransitions(ctx context.Context, workflowID pgtype.UUID) ([]*db.WorkflowStatusTransition, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	items := []*db.WorkflowStatusTransition{}
+	for _, transition := range q.transitions {
+		if workflowID.Valid && transition.WorkflowID == workflowID {
+			c := *transition
+			items = append(items, &c)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return compareRow(items[i].ChangedAt, items[i].ID, items[j].ChangedAt, items[j].ID) > 0
+	})
+	return items, nil
+}
+
+func (q *Queries) GetWorkflowSubmissionSummary(ctx context.Context, id pgtype.UUID) (*db.GetWorkflowSubmissionSummaryRow, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if _, ok := q.workflows[id.Bytes]; !ok || !id.Valid {
+		return nil, pgx.ErrNoRows
+	}
+
+	row := db.GetWorkflowSubmissionSummaryRow(q.summary(id))
+	return &row, nil
+}
+
+func (q *Queries) ListWorkflowSubmissionSummaries(ctx context.Context, ownerID pgtype.UUID) ([]*db.ListWorkflowSubmissionSummariesRow, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	items := []*db.ListWorkflowSubmissionSummariesRow{}
+	for _, workflow := range q.workflows {
+		if !ownerID.Valid || workflow.OwnerID != ownerID {
+			continue
+		}
+		s := q.summary(workflow.ID)
+		items = append(items, &db.ListWorkflowSubmissionSummariesRow{
+			WorkflowID:            workflow.ID,
+			TotalVisits:           s.TotalVisits,
+			CompletedVisits:       s.CompletedVisits,
+			TotalSubmissions:      s.TotalSubmissions,
+			AverageTimeToComplete: s.AverageTimeToComplete,
+			LastSubmissionAt:      s.LastSubmissionAt,
+		})
+	}
+	return items, nil
+}
+
+// summary computes the analytics counters of one workflow the way the summary
+// queries do. Submissions join form events through metadata->>'sessionId'.
+// The caller must hold the lock.
+func (q *Queries) summary(workflowID pgtype.UUID) db.GetWorkflowSubmissionSummaryRow {
+	var row db.GetWorkflowSubmissionSummaryRow
+
+	sessions := make(map[string][]time.Time)
+	for _, submission := range q.submissions {
+		if submission.WorkflowID != workflowID {
+			continue
+		}
+		row.TotalSubmissions++
+		if !row.LastSubmissionAt.Valid || submission.CreatedAt.After(row.LastSubmissionAt.Time) {
+			row.LastSubmissionAt = pgtype.Timestamptz{Time: submission.CreatedAt, Valid: true}
+		}
+		if sessionID := metadataSessionID(submission.Metadata); sessionID != "" {
+			sessions[sessionID] = append(sessions[sessionID], submission.CreatedAt)
+		}
+	}
+
+	var totalSeconds float64
+	var completions int
+	for _, event := range q.formEvents {
+		if event.WorkflowID != workflowID {
+			continue
+		}
+		submitted := sessions[uuid.UUID(event.SessionID.Bytes).String()]
+
+		switch event.EventType {
+		case "view":
+			row.TotalVisits++
+			if len(submitted) > 0 {
+				row.CompletedVisits++
+			}
+		case "start":
+			for _, createdAt := range submitted {
+				if !createdAt.Before(event.CreatedAt) {
+					totalSeconds += createdAt.Sub(event.CreatedAt).Seconds()
+					completions++
+				}
+			}
+		}
+	}
+	if completions > 0 {
+		row.AverageTimeToComplete = totalSeconds / float64(completions)
+	}
+
+	return row
+}
+
+// metadataSessionID mimics metadata->>'sessionId' for string values.
+func metadataSessionID(metadata []byte) string {
+	var m map[string]interface{}
+	if err := json.Unmarshal(metadata, &m); err != nil {
+		return ""
+	}
+	sessionID, _ := m["sessionId"].(string)
+	return sessionID
+}
+
+func cloneWorkflow(workflow *db.Workflow) *db.Workflow {
+	c := *workflow
+	c.Trigger = cloneBytes(workflow.Trigger)
+	c.Actions = cloneBytes(workflow.Actions)
+	return &c
+}
@@ -2,78 +2,276 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/hungaikev/rootd/backend/internal/migrations"
 	_ "github.com/lib/pq"
 )
 
 type MigrateConfig struct {
-	MigrationsPath string
+	MigrationsPath string // Directory to read migrations from instead of the embedded ones.
 	DatabaseURL    string
 }
 
-func RunMigrations(cfg MigrateConfig) error {
+// Migrator applies and inspects the schema migrations of one database.
+type Migrator struct {
+	db     *sql.DB
+	m      *migrate.Migrate
+	source fs.FS
+}
+
+// MigrationStatus describes one migration file and whether it has been applied.
+type MigrationStatus struct {
+	Version uint
+	Name    string
+	Applied bool
+	Dirty   bool // The migration failed part way and the schema needs manual repair.
+}
+
+// migrationFile matches golang-migrate file names, e.g. 000001_create_workflows_table.up.sql.
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// NewMigrator creates a migrator for the migrations embedded in the binary,
+// or for those in cfg.MigrationsPath when it is set
+func NewMigrator(cfg MigrateConfig) (*Migrator, error) {
+	var source fs.FS = migrations.FS
+	if cfg.MigrationsPath != "" {
+		source = os.DirFS(cfg.MigrationsPath)
+	}
+
+	sourceDriver, err := iofs.New(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
 	// Open database connection
 	db, err := sql.Open("postgres", cfg.DatabaseURL)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
-	defer db.Close()
 
 	// Create postgres driver instance
 	driver, err := postgres.WithInstance(db, &postgres.Config{})
 	if err != nil {
-		return fmt.Errorf("failed to create postgres driver: %w", err)
+		db.Close()
+		return nil, fmt.Errorf("failed to create postgres driver: %w", err)
 	}
 
 	// Create migrate instance
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://"+cfg.MigrationsPath,
-		"postgres", driver)
+	m, err := migrate.NewWithInstance("iofs", sourceDriver, "postgres", driver)
 	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
+		db.Close()
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
 	}
-	defer m.Close()
 
-	// Run migrations
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+	return &Migrator{db: db, m: m, source: source}, nil
+}
+
+// Close releases the database connection.
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	if err := m.db.Close(); err != nil && dbErr == nil {
+		dbErr = err
+	}
+	if srcErr != nil {
+		return srcErr
+	}
+	return dbErr
+}
+
+// Up applies the next n pending migrations, or all of them when n is 0.
+func (m *Migrator) Up(n int) error {
+	var err error
+	if n > 0 {
+		err = m.m.Steps(n)
+	} else {
+		err = m.m.Up()
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
+	return nil
+}
 
-	log.Println("Database migrations completed successfully")
+// Down rolls back the last n applied migrations, or all of them when n is 0.
+func (m *Migrator) Down(n int) error {
+	var err error
+	if n > 0 {
+		err = m.m.Steps(-n)
+	} else {
+		err = m.m.Down()
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to rollback migrations: %w", err)
+	}
 	return nil
 }
 
-func RollbackMigrations(cfg MigrateConfig) error {
-	// Open database connection
-	db, err := sql.Open("postgres", cfg.DatabaseURL)
+// Goto migrates up or down to the given version.
+func (m *Migrator) Goto(version uint) error {
+	if err := m.m.Migrate(version); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to migrate to version %d: %w", version, err)
+	}
+	return nil
+}
+
+// Version returns the current schema version. A version of 0 means no migration has been applied.
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
 	}
-	defer db.Close()
+	return version, dirty, nil
+}
 
-	// Create postgres driver instance
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+// Force sets the schema version without running any migration and clears the dirty flag.
+// It is used after repairing a migration that failed part way. A version of -1 means no version.
+func (m *Migrator) Force(version int) error {
+	if err := m.m.Force(version); err != nil {
+		return fmt.Errorf("failed to force version %d: %w", version, err)
+	}
+	return nil
+}
+
+// Status lists every known migration, oldest first.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	current, dirty, err := m.Version()
 	if err != nil {
-		return fmt.Errorf("failed to create postgres driver: %w", err)
+		return nil, err
 	}
 
-	// Create migrate instance
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://"+cfg.MigrationsPath,
-		"postgres", driver)
+	files, err := ListMigrations(m.source)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range files {
+		files[i].Applied = files[i].Version <= current
+		files[i].Dirty = dirty && files[i].Version == current
+	}
+
+	return files, nil
+}
+
+// ListMigrations returns the migrations found at the root of fsys, oldest first.
+func ListMigrations(fsys fs.FS) ([]MigrationStatus, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byVersion := make(map[uint]MigrationStatus)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		byVersion[uint(version)] = MigrationStatus{Version: uint(version), Name: match[2]}
+	}
+
+	result := make([]MigrationStatus, 0, len(byVersion))
+	for _, migration := range byVersion {
+		result = append(result, migration)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+
+	return result, nil
+}
+
+// migrationName restricts new migration names to what the file pattern accepts.
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// CreateMigration writes an empty up and down migration named name into dir,
+// numbered after the newest existing migration, and returns their paths.
+func CreateMigration(dir, name string) (string, string, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), "_"))
+	if !migrationName.MatchString(name) {
+		return "", "", fmt.Errorf("migration name may only contain letters, digits and underscores")
+	}
+
+	existing, err := ListMigrations(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	next := uint(1)
+	if len(existing) > 0 {
+		next = existing[len(existing)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%06d_%s", next, name))
+	files := []struct {
+		path string
+		body string
+	}{
+		{base + ".up.sql", "\n"},
+		{base + ".down.sql", "\n"},
+	}
+
+	for _, file := range files {
+		// O_EXCL so an existing migration is never overwritten
+		f, err := os.OpenFile(file.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to create migration file: %w", err)
+		}
+		_, err = f.WriteString(file.body)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("failed to write migration file: %w", err)
+		}
+	}
+
+	return files[0].path, files[1].path, nil
+}
+
+func RunMigrations(cfg MigrateConfig) error {
+	m, err := NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	// Run migrations
+	if err := m.Up(0); err != nil {
+		return err
+	}
+
+	log.Println("Database migrations completed successfully")
+	return nil
+}
+
+func RollbackMigrations(cfg MigrateConfig) error {
+	m, err := NewMigrator(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
+		return err
 	}
 	defer m.Close()
 
 	// Rollback one migration
-	if err := m.Steps(-1); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("failed to rollback migration: %w", err)
+	if err := m.Down(1); err != nil {
+		return err
 	}
 
 	log.Println("Database migration rolled back successfully")
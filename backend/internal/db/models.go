@@ -20,6 +20,14 @@ type Form struct {
 	UpdatedAt   time.Time   `json:"updated_at"`
 }
 
+type FormEvent struct {
+	ID         pgtype.UUID `json:"id"`
+	WorkflowID pgtype.UUID `json:"workflow_id"`
+	SessionID  pgtype.UUID `json:"session_id"`
+	EventType  string      `json:"event_type"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
 type Submission struct {
 	ID         pgtype.UUID `json:"id"`
 	WorkflowID pgtype.UUID `json:"workflow_id"`
@@ -43,3 +51,86 @@ type Workflow struct {
 	CreatedAt   time.Time   `json:"created_at"`
 	UpdatedAt   time.Time   `json:"updated_at"`
 }
+
+type User struct {
+	ID           pgtype.UUID `json:"id"`
+	Email        string      `json:"email"`
+	Name         string      `json:"name"`
+	PasswordHash string      `json:"password_hash"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+type WorkflowStatusTransition struct {
+	ID         pgtype.UUID `json:"id"`
+	WorkflowID pgtype.UUID `json:"workflow_id"`
+	FromStatus string      `json:"from_status"`
+	ToStatus   string      `json:"to_status"`
+	ChangedBy  pgtype.UUID `json:"changed_by"`
+	ChangedAt  time.Time   `json:"changed_at"`
+}
+
+type WorkflowWebhook struct {
+	WorkflowID pgtype.UUID `json:"workflow_id"`
+	Token      string      `json:"token"`
+	Secret     string      `json:"secret"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+type WorkflowRun struct {
+	ID           pgtype.UUID        `json:"id"`
+	WorkflowID   pgtype.UUID        `json:"workflow_id"`
+	SubmissionID pgtype.UUID        `json:"submission_id"`
+	Input        []byte             `json:"input"`
+	Status       string             `json:"status"`
+	Error        pgtype.Text        `json:"error"`
+	TriggeredBy  pgtype.UUID        `json:"triggered_by"`
+	CreatedAt    time.Time          `json:"created_at"`
+	StartedAt    pgtype.Timestamptz `json:"started_at"`
+	FinishedAt   pgtype.Timestamptz `json:"finished_at"`
+}
+
+type WorkflowSchedule struct {
+	WorkflowID     pgtype.UUID        `json:"workflow_id"`
+	CronExpression string             `json:"cron_expression"`
+	TimeZone       string             `json:"time_zone"`
+	NextFireAt     time.Time          `json:"next_fire_at"`
+	LastFireAt     pgtype.Timestamptz `json:"last_fire_at"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+type Job struct {
+	ID          pgtype.UUID        `json:"id"`
+	Kind        string             `json:"kind"`
+	Payload     []byte             `json:"payload"`
+	Status      string             `json:"status"`
+	Attempts    int32              `json:"attempts"`
+	RunAt       time.Time          `json:"run_at"`
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	LastError   pgtype.Text        `json:"last_error"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+type ActionRun struct {
+	ID            pgtype.UUID `json:"id"`
+	SubmissionID  pgtype.UUID `json:"submission_id"`
+	WorkflowRunID pgtype.UUID `json:"workflow_run_id"`
+	ActionID      string      `json:"action_id"`
+	ActionType    string      `json:"action_type"`
+	Attempt       int32       `json:"attempt"`
+	Step          int32       `json:"step"`
+	Status        string      `json:"status"`
+	Config        []byte      `json:"config"`
+	Output        []byte      `json:"output"`
+	Error         pgtype.Text `json:"error"`
+	StartedAt     time.Time   `json:"started_at"`
+	FinishedAt    time.Time   `json:"finished_at"`
+}
+
+type WorkflowSigningSecret struct {
+	WorkflowID pgtype.UUID `json:"workflow_id"`
+	Secret     string      `json:"secret"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
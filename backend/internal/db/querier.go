@@ -6,29 +6,77 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	// Locks the due schedules until the transaction ends. Rows locked
+	// by another scheduler are skipped, so each fire time is claimed by one replica.
+	ClaimDueWorkflowSchedules(ctx context.Context, arg *ClaimDueWorkflowSchedulesParams) ([]*WorkflowSchedule, error)
+	// Leases the next runnable job: a queued job that is due, or a running job whose
+	// lease expired because its worker died. Jobs locked by another worker are skipped.
+	ClaimJob(ctx context.Context, arg *ClaimJobParams) (*Job, error)
+	// The attempts check makes this a no-op for a worker whose lease was taken over.
+	CompleteJob(ctx context.Context, arg *CompleteJobParams) (int64, error)
+	CountJobsByStatus(ctx context.Context) ([]*CountJobsByStatusRow, error)
+	CountSubmissions(ctx context.Context, arg *CountSubmissionsParams) (int64, error)
+	CountWorkflowsBySchema(ctx context.Context, schemaID pgtype.UUID) (int64, error)
+	CreateActionRun(ctx context.Context, arg *CreateActionRunParams) (*ActionRun, error)
 	CreateForm(ctx context.Context, arg *CreateFormParams) (*Form, error)
+	CreateFormEvent(ctx context.Context, arg *CreateFormEventParams) error
 	CreateSubmission(ctx context.Context, arg *CreateSubmissionParams) (*Submission, error)
+	CreateUser(ctx context.Context, arg *CreateUserParams) (*User, error)
 	CreateWorkflow(ctx context.Context, arg *CreateWorkflowParams) (*Workflow, error)
+	CreateWorkflowRun(ctx context.Context, arg *CreateWorkflowRunParams) (*WorkflowRun, error)
+	CreateWorkflowSigningSecret(ctx context.Context, arg *CreateWorkflowSigningSecretParams) (*WorkflowSigningSecret, error)
+	CreateWorkflowWebhook(ctx context.Context, arg *CreateWorkflowWebhookParams) (*WorkflowWebhook, error)
+	DeadLetterJob(ctx context.Context, arg *DeadLetterJobParams) (int64, error)
+	// Removes the log entries that finished before the retention cutoff.
+	DeleteActionRunsFinishedBefore(ctx context.Context, finishedAt time.Time) (int64, error)
 	DeleteForm(ctx context.Context, id pgtype.UUID) error
 	DeleteSubmission(ctx context.Context, id pgtype.UUID) error
 	DeleteWorkflow(ctx context.Context, id pgtype.UUID) error
+	DeleteWorkflowSchedule(ctx context.Context, workflowID pgtype.UUID) error
+	DeleteWorkflowWebhook(ctx context.Context, workflowID pgtype.UUID) error
+	EnqueueJob(ctx context.Context, arg *EnqueueJobParams) (*Job, error)
+	FinishWorkflowRun(ctx context.Context, arg *FinishWorkflowRunParams) (*WorkflowRun, error)
 	GetForm(ctx context.Context, id pgtype.UUID) (*Form, error)
+	GetJob(ctx context.Context, id pgtype.UUID) (*Job, error)
 	GetSubmission(ctx context.Context, id pgtype.UUID) (*Submission, error)
+	GetUser(ctx context.Context, id pgtype.UUID) (*User, error)
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
 	GetWorkflow(ctx context.Context, id pgtype.UUID) (*Workflow, error)
-	GetWorkflowSubmissionSummary(ctx context.Context, workflowID pgtype.UUID) (*GetWorkflowSubmissionSummaryRow, error)
+	GetWorkflowRun(ctx context.Context, id pgtype.UUID) (*WorkflowRun, error)
+	GetWorkflowSchedule(ctx context.Context, workflowID pgtype.UUID) (*WorkflowSchedule, error)
+	GetWorkflowSigningSecret(ctx context.Context, workflowID pgtype.UUID) (*WorkflowSigningSecret, error)
+	GetWorkflowSubmissionSummary(ctx context.Context, id pgtype.UUID) (*GetWorkflowSubmissionSummaryRow, error)
+	GetWorkflowWebhook(ctx context.Context, workflowID pgtype.UUID) (*WorkflowWebhook, error)
+	GetWorkflowWebhookByToken(ctx context.Context, token string) (*WorkflowWebhook, error)
+	ListActionRunsBySubmission(ctx context.Context, submissionID pgtype.UUID) ([]*ActionRun, error)
 	ListForms(ctx context.Context, ownerID pgtype.UUID) ([]*Form, error)
+	ListJobsByStatus(ctx context.Context, status string) ([]*Job, error)
 	ListSubmissions(ctx context.Context, workflowID pgtype.UUID) ([]*Submission, error)
 	ListSubmissionsByOwner(ctx context.Context, ownerID pgtype.UUID) ([]*Submission, error)
+	ListSubmissionsPageAsc(ctx context.Context, arg *ListSubmissionsPageAscParams) ([]*Submission, error)
+	ListSubmissionsPageDesc(ctx context.Context, arg *ListSubmissionsPageDescParams) ([]*Submission, error)
+	ListWorkflowRuns(ctx context.Context, workflowID pgtype.UUID) ([]*WorkflowRun, error)
+	ListWorkflowStatusTransitions(ctx context.Context, workflowID pgtype.UUID) ([]*WorkflowStatusTransition, error)
+	ListWorkflowSubmissionSummaries(ctx context.Context, ownerID pgtype.UUID) ([]*ListWorkflowSubmissionSummariesRow, error)
 	ListWorkflows(ctx context.Context, ownerID pgtype.UUID) ([]*Workflow, error)
+	RecordWorkflowScheduleFire(ctx context.Context, arg *RecordWorkflowScheduleFireParams) (*WorkflowSchedule, error)
+	RetryJob(ctx context.Context, arg *RetryJobParams) (int64, error)
+	// Marks a run as running, clearing the outcome of an earlier attempt at it.
+	StartWorkflowRun(ctx context.Context, id pgtype.UUID) (*WorkflowRun, error)
+	// Changes the status only if it still equals expected_status and records the
+	// transition in the same statement, so both commit or neither does.
+	TransitionWorkflowStatus(ctx context.Context, arg *TransitionWorkflowStatusParams) (*Workflow, error)
 	UpdateForm(ctx context.Context, arg *UpdateFormParams) (*Form, error)
 	UpdateSubmissionStatus(ctx context.Context, arg *UpdateSubmissionStatusParams) (*Submission, error)
 	UpdateWorkflow(ctx context.Context, arg *UpdateWorkflowParams) (*Workflow, error)
 	UpdateWorkflowStatus(ctx context.Context, arg *UpdateWorkflowStatusParams) (*Workflow, error)
+	UpsertWorkflowSchedule(ctx context.Context, arg *UpsertWorkflowScheduleParams) (*WorkflowSchedule, error)
 }
 
 var _ Querier = (*Queries)(nil)
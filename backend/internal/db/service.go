@@ -3,13 +3,12 @@ package db
 import (
 	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 )
 
+// Service bundles the connection pool with the queries that run on it.
 type Service struct {
 	*DB
-	Queries *Queries
+	*Queries
 }
 
 type ServiceConfig struct {
@@ -45,15 +44,6 @@ func NewService(cfg ServiceConfig) (*Service, error) {
 }
 
 func (s *Service) RunMigrations(ctx context.Context) error {
-	// Get current working directory
-	wd, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
-	}
-
-	// Construct migrations path
-	migrationsPath := filepath.Join(wd, "internal", "migrations")
-
 	// Construct database URL
 	sslmode := "disable"
 	if s.DB.Pool.Config().ConnConfig.TLSConfig != nil {
@@ -69,9 +59,9 @@ func (s *Service) RunMigrations(ctx context.Context) error {
 		sslmode)
 
 	// Run migrations
+	// Migrations are embedded in the binary, so the working directory does not matter
 	cfg := MigrateConfig{
-		MigrationsPath: migrationsPath,
-		DatabaseURL:    databaseURL,
+		DatabaseURL: databaseURL,
 	}
 
 	return RunMigrations(cfg)
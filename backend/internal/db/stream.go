@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// StreamSubmissionsByWorkflow is not generated by sqlc because sqlc collects :many results
+// into a slice. Rows are decoded one at a time as Postgres sends them instead.
+const StreamSubmissionsByWorkflow = `
+SELECT id, workflow_id, schema_id, data, metadata, status, created_at, updated_at FROM submissions 
+WHERE workflow_id = $1 
+ORDER BY created_at, id
+`
+
+// SubmissionStreamer is implemented by queriers that can stream a workflow's submissions.
+type SubmissionStreamer interface {
+	StreamSubmissions(ctx context.Context, workflowID pgtype.UUID, fn func(*Submission) error) error
+}
+
+var _ SubmissionStreamer = (*Queries)(nil)
+
+// StreamSubmissions calls fn for every submission of a workflow, oldest first, without
+// holding the result set in memory. Iteration stops at the first error returned by fn.
+// The submission passed to fn is reused between calls and must not be retained.
+func (q *Queries) StreamSubmissions(ctx context.Context, workflowID pgtype.UUID, fn func(*Submission) error) error {
+	rows, err := q.db.Query(ctx, StreamSubmissionsByWorkflow, workflowID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var i Submission
+	for rows.Next() {
+		if err := rows.Scan(
+			&i.ID,
+			&i.WorkflowID,
+			&i.SchemaID,
+			&i.Data,
+			&i.Metadata,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return err
+		}
+		if err := fn(&i); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -11,6 +11,40 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const CountSubmissions = `-- name: CountSubmissions :one
+SELECT COUNT(*) FROM submissions s
+JOIN workflows w ON s.workflow_id = w.id
+WHERE ($1::uuid IS NULL OR s.workflow_id = $1)
+  AND ($2::uuid IS NULL OR w.owner_id = $2)
+  AND ($3::text IS NULL OR s.status = $3)
+  AND ($4::timestamptz IS NULL OR s.created_at >= $4)
+  AND ($5::timestamptz IS NULL OR s.created_at < $5)
+  AND ($6::text IS NULL OR s.data @@ $6::text::jsonpath)
+`
+
+type CountSubmissionsParams struct {
+	WorkflowID  pgtype.UUID        `json:"workflow_id"`
+	OwnerID     pgtype.UUID        `json:"owner_id"`
+	Status      pgtype.Text        `json:"status"`
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+	DataFilter  pgtype.Text        `json:"data_filter"`
+}
+
+func (q *Queries) CountSubmissions(ctx context.Context, arg *CountSubmissionsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, CountSubmissions,
+		arg.WorkflowID,
+		arg.OwnerID,
+		arg.Status,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.DataFilter,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const CreateSubmission = `-- name: CreateSubmission :one
 INSERT INTO submissions (
     workflow_id, schema_id, data, metadata, status
@@ -151,6 +185,138 @@ func (q *Queries) ListSubmissionsByOwner(ctx context.Context, ownerID pgtype.UUI
 	return items, nil
 }
 
+const ListSubmissionsPageAsc = `-- name: ListSubmissionsPageAsc :many
+SELECT s.id, s.workflow_id, s.schema_id, s.data, s.metadata, s.status, s.created_at, s.updated_at FROM submissions s
+JOIN workflows w ON s.workflow_id = w.id
+WHERE ($1::uuid IS NULL OR s.workflow_id = $1)
+  AND ($2::uuid IS NULL OR w.owner_id = $2)
+  AND ($3::text IS NULL OR s.status = $3)
+  AND ($4::timestamptz IS NULL OR s.created_at >= $4)
+  AND ($5::timestamptz IS NULL OR s.created_at < $5)
+  AND ($6::text IS NULL OR s.data @@ $6::text::jsonpath)
+  AND ($7::timestamptz IS NULL
+       OR (s.created_at, s.id) > ($7, $8::uuid))
+ORDER BY s.created_at ASC, s.id ASC
+LIMIT $9
+`
+
+type ListSubmissionsPageAscParams struct {
+	WorkflowID      pgtype.UUID        `json:"workflow_id"`
+	OwnerID         pgtype.UUID        `json:"owner_id"`
+	Status          pgtype.Text        `json:"status"`
+	CreatedFrom     pgtype.Timestamptz `json:"created_from"`
+	CreatedTo       pgtype.Timestamptz `json:"created_to"`
+	DataFilter      pgtype.Text        `json:"data_filter"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `json:"cursor_id"`
+	PageLimit       int32              `json:"page_limit"`
+}
+
+func (q *Queries) ListSubmissionsPageAsc(ctx context.Context, arg *ListSubmissionsPageAscParams) ([]*Submission, error) {
+	rows, err := q.db.Query(ctx, ListSubmissionsPageAsc,
+		arg.WorkflowID,
+		arg.OwnerID,
+		arg.Status,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.DataFilter,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Submission{}
+	for rows.Next() {
+		var i Submission
+		if err := rows.Scan(
+			&i.ID,
+			&i.WorkflowID,
+			&i.SchemaID,
+			&i.Data,
+			&i.Metadata,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListSubmissionsPageDesc = `-- name: ListSubmissionsPageDesc :many
+SELECT s.id, s.workflow_id, s.schema_id, s.data, s.metadata, s.status, s.created_at, s.updated_at FROM submissions s
+JOIN workflows w ON s.workflow_id = w.id
+WHERE ($1::uuid IS NULL OR s.workflow_id = $1)
+  AND ($2::uuid IS NULL OR w.owner_id = $2)
+  AND ($3::text IS NULL OR s.status = $3)
+  AND ($4::timestamptz IS NULL OR s.created_at >= $4)
+  AND ($5::timestamptz IS NULL OR s.created_at < $5)
+  AND ($6::text IS NULL OR s.data @@ $6::text::jsonpath)
+  AND ($7::timestamptz IS NULL
+       OR (s.created_at, s.id) < ($7, $8::uuid))
+ORDER BY s.created_at DESC, s.id DESC
+LIMIT $9
+`
+
+type ListSubmissionsPageDescParams struct {
+	WorkflowID      pgtype.UUID        `json:"workflow_id"`
+	OwnerID         pgtype.UUID        `json:"owner_id"`
+	Status          pgtype.Text        `json:"status"`
+	CreatedFrom     pgtype.Timestamptz `json:"created_from"`
+	CreatedTo       pgtype.Timestamptz `json:"created_to"`
+	DataFilter      pgtype.Text        `json:"data_filter"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `json:"cursor_id"`
+	PageLimit       int32              `json:"page_limit"`
+}
+
+func (q *Queries) ListSubmissionsPageDesc(ctx context.Context, arg *ListSubmissionsPageDescParams) ([]*Submission, error) {
+	rows, err := q.db.Query(ctx, ListSubmissionsPageDesc,
+		arg.WorkflowID,
+		arg.OwnerID,
+		arg.Status,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.DataFilter,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Submission{}
+	for rows.Next() {
+		var i Submission
+		if err := rows.Scan(
+			&i.ID,
+			&i.WorkflowID,
+			&i.SchemaID,
+			&i.Data,
+			&i.Metadata,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const UpdateSubmissionStatus = `-- name: UpdateSubmissionStatus :one
 UPDATE submissions 
 SET 
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DefaultTxAttempts is how often ExecTx runs a function that keeps failing with a
+// serialization failure or deadlock when TxOptions.MaxAttempts is zero.
+const DefaultTxAttempts = 5
+
+// TxOptions configures a unit of work. The zero value runs once per attempt at the
+// database's default isolation level, read committed.
+type TxOptions struct {
+	IsoLevel    pgx.TxIsoLevel
+	ReadOnly    bool
+	MaxAttempts int
+}
+
+// Store is a Querier that can also run several queries as one unit of work.
+type Store interface {
+	Querier
+	// ExecTx runs fn inside a transaction and commits if fn returns nil. When the
+	// transaction fails with a serialization failure or deadlock it is rolled back
+	// and fn runs again, so fn must not have side effects outside the database.
+	ExecTx(ctx context.Context, opts TxOptions, fn func(q Querier) error) error
+}
+
+var _ Store = (*Service)(nil)
+
+func (s *Service) ExecTx(ctx context.Context, opts TxOptions, fn func(q Querier) error) error {
+	attempts := opts.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultTxAttempts
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = s.execTx(ctx, opts, fn)
+		if err == nil || !IsRetryable(err) || attempt == attempts {
+			return err
+		}
+
+		// Back off a little so the conflicting transaction can finish
+		delay := time.Duration(attempt)*10*time.Millisecond + time.Duration(rand.IntN(10))*time.Millisecond
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+
+	return err
+}
+
+func (s *Service) execTx(ctx context.Context, opts TxOptions, fn func(q Querier) error) error {
+	txOptions := pgx.TxOptions{IsoLevel: opts.IsoLevel}
+	if opts.ReadOnly {
+		txOptions.AccessMode = pgx.ReadOnly
+	}
+
+	tx, err := s.DB.Pool.BeginTx(ctx, txOptions)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer tx.Rollback(ctx)
+
+	if err := fn(s.Queries.WithTx(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// IsRetryable reports whether err aborted a transaction only because of a concurrent
+// one, i.e. a serialization failure (40001) or a deadlock (40P01).
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	return false
+}
@@ -11,6 +11,18 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const CountWorkflowsBySchema = `-- name: CountWorkflowsBySchema :one
+SELECT COUNT(*) FROM workflows 
+WHERE schema_id = $1
+`
+
+func (q *Queries) CountWorkflowsBySchema(ctx context.Context, schemaID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, CountWorkflowsBySchema, schemaID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const CreateWorkflow = `-- name: CreateWorkflow :one
 INSERT INTO workflows (
     name, description, status, owner_id, schema_id, trigger, actions
@@ -90,33 +102,122 @@ func (q *Queries) GetWorkflow(ctx context.Context, id pgtype.UUID) (*Workflow, e
 
 const GetWorkflowSubmissionSummary = `-- name: GetWorkflowSubmissionSummary :one
 SELECT 
-    COUNT(DISTINCT s.id) as total_submissions,
-    COUNT(DISTINCT s.id) as total_visits, -- For now, same as submissions
-    COALESCE(AVG(EXTRACT(EPOCH FROM (s.created_at - s.created_at))), 0) as average_time_to_complete,
-    MAX(s.created_at) as last_submission_at
-FROM submissions s
-WHERE s.workflow_id = $1
+    v.total_visits::bigint as total_visits,
+    v.completed_visits::bigint as completed_visits,
+    s.total_submissions::bigint as total_submissions,
+    COALESCE(d.average_time_to_complete, 0)::float8 as average_time_to_complete,
+    s.last_submission_at::timestamptz as last_submission_at
+FROM workflows w
+CROSS JOIN LATERAL (
+    SELECT COUNT(*) as total_submissions, MAX(created_at) as last_submission_at
+    FROM submissions WHERE workflow_id = w.id
+) s
+CROSS JOIN LATERAL (
+    SELECT 
+        COUNT(*) as total_visits,
+        COUNT(*) FILTER (WHERE EXISTS (
+            SELECT 1 FROM submissions sub 
+            WHERE sub.workflow_id = e.workflow_id AND sub.metadata->>'sessionId' = e.session_id::text
+        )) as completed_visits
+    FROM form_events e WHERE e.workflow_id = w.id AND e.event_type = 'view'
+) v
+CROSS JOIN LATERAL (
+    SELECT AVG(EXTRACT(EPOCH FROM (sub.created_at - e.created_at))) as average_time_to_complete
+    FROM form_events e
+    JOIN submissions sub ON sub.workflow_id = e.workflow_id AND sub.metadata->>'sessionId' = e.session_id::text
+    WHERE e.workflow_id = w.id AND e.event_type = 'start' AND sub.created_at >= e.created_at
+) d
+WHERE w.id = $1
 `
 
 type GetWorkflowSubmissionSummaryRow struct {
-	TotalSubmissions      int64       `json:"total_submissions"`
-	TotalVisits           int64       `json:"total_visits"`
-	AverageTimeToComplete interface{} `json:"average_time_to_complete"`
-	LastSubmissionAt      interface{} `json:"last_submission_at"`
+	TotalVisits           int64              `json:"total_visits"`
+	CompletedVisits       int64              `json:"completed_visits"`
+	TotalSubmissions      int64              `json:"total_submissions"`
+	AverageTimeToComplete float64            `json:"average_time_to_complete"`
+	LastSubmissionAt      pgtype.Timestamptz `json:"last_submission_at"`
 }
 
-func (q *Queries) GetWorkflowSubmissionSummary(ctx context.Context, workflowID pgtype.UUID) (*GetWorkflowSubmissionSummaryRow, error) {
-	row := q.db.QueryRow(ctx, GetWorkflowSubmissionSummary, workflowID)
+func (q *Queries) GetWorkflowSubmissionSummary(ctx context.Context, id pgtype.UUID) (*GetWorkflowSubmissionSummaryRow, error) {
+	row := q.db.QueryRow(ctx, GetWorkflowSubmissionSummary, id)
 	var i GetWorkflowSubmissionSummaryRow
 	err := row.Scan(
-		&i.TotalSubmissions,
 		&i.TotalVisits,
+		&i.CompletedVisits,
+		&i.TotalSubmissions,
 		&i.AverageTimeToComplete,
 		&i.LastSubmissionAt,
 	)
 	return &i, err
 }
 
+const ListWorkflowSubmissionSummaries = `-- name: ListWorkflowSubmissionSummaries :many
+SELECT 
+    w.id as workflow_id,
+    v.total_visits::bigint as total_visits,
+    v.completed_visits::bigint as completed_visits,
+    s.total_submissions::bigint as total_submissions,
+    COALESCE(d.average_time_to_complete, 0)::float8 as average_time_to_complete,
+    s.last_submission_at::timestamptz as last_submission_at
+FROM workflows w
+CROSS JOIN LATERAL (
+    SELECT COUNT(*) as total_submissions, MAX(created_at) as last_submission_at
+    FROM submissions WHERE workflow_id = w.id
+) s
+CROSS JOIN LATERAL (
+    SELECT 
+        COUNT(*) as total_visits,
+        COUNT(*) FILTER (WHERE EXISTS (
+            SELECT 1 FROM submissions sub 
+            WHERE sub.workflow_id = e.workflow_id AND sub.metadata->>'sessionId' = e.session_id::text
+        )) as completed_visits
+    FROM form_events e WHERE e.workflow_id = w.id AND e.event_type = 'view'
+) v
+CROSS JOIN LATERAL (
+    SELECT AVG(EXTRACT(EPOCH FROM (sub.created_at - e.created_at))) as average_time_to_complete
+    FROM form_events e
+    JOIN submissions sub ON sub.workflow_id = e.workflow_id AND sub.metadata->>'sessionId' = e.session_id::text
+    WHERE e.workflow_id = w.id AND e.event_type = 'start' AND sub.created_at >= e.created_at
+) d
+WHERE w.owner_id = $1
+`
+
+type ListWorkflowSubmissionSummariesRow struct {
+	WorkflowID            pgtype.UUID        `json:"workflow_id"`
+	TotalVisits           int64              `json:"total_visits"`
+	CompletedVisits       int64              `json:"completed_visits"`
+	TotalSubmissions      int64              `json:"total_submissions"`
+	AverageTimeToComplete float64            `json:"average_time_to_complete"`
+	LastSubmissionAt      pgtype.Timestamptz `json:"last_submission_at"`
+}
+
+func (q *Queries) ListWorkflowSubmissionSummaries(ctx context.Context, ownerID pgtype.UUID) ([]*ListWorkflowSubmissionSummariesRow, error) {
+	rows, err := q.db.Query(ctx, ListWorkflowSubmissionSummaries, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*ListWorkflowSubmissionSummariesRow{}
+	for rows.Next() {
+		var i ListWorkflowSubmissionSummariesRow
+		if err := rows.Scan(
+			&i.WorkflowID,
+			&i.TotalVisits,
+			&i.CompletedVisits,
+			&i.TotalSubmissions,
+			&i.AverageTimeToComplete,
+			&i.LastSubmissionAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const ListWorkflows = `-- name: ListWorkflows :many
 SELECT id, name, description, status, owner_id, schema_id, trigger, actions, created_at, updated_at FROM workflows 
 WHERE owner_id = $1 
@@ -154,6 +255,56 @@ func (q *Queries) ListWorkflows(ctx context.Context, ownerID pgtype.UUID) ([]*Wo
 	return items, nil
 }
 
+const TransitionWorkflowStatus = `-- name: TransitionWorkflowStatus :one
+WITH updated AS (
+    UPDATE workflows 
+    SET 
+        status = $1,
+        updated_at = NOW()
+    WHERE id = $2 AND status = $3
+    RETURNING id, name, description, status, owner_id, schema_id, trigger, actions, created_at, updated_at
+), transition AS (
+    INSERT INTO workflow_status_transitions (
+        workflow_id, from_status, to_status, changed_by
+    )
+    SELECT updated.id, $3, updated.status, $4
+    FROM updated
+)
+SELECT id, name, description, status, owner_id, schema_id, trigger, actions, created_at, updated_at FROM updated
+`
+
+type TransitionWorkflowStatusParams struct {
+	Status         string      `json:"status"`
+	ID             pgtype.UUID `json:"id"`
+	ExpectedStatus string      `json:"expected_status"`
+	ChangedBy      pgtype.UUID `json:"changed_by"`
+}
+
+// Changes the status only if it still equals expected_status and records the
+// transition in the same statement, so both commit or neither does.
+func (q *Queries) TransitionWorkflowStatus(ctx context.Context, arg *TransitionWorkflowStatusParams) (*Workflow, error) {
+	row := q.db.QueryRow(ctx, TransitionWorkflowStatus,
+		arg.Status,
+		arg.ID,
+		arg.ExpectedStatus,
+		arg.ChangedBy,
+	)
+	var i Workflow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Status,
+		&i.OwnerID,
+		&i.SchemaID,
+		&i.Trigger,
+		&i.Actions,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
 const UpdateWorkflow = `-- name: UpdateWorkflow :one
 UPDATE workflows 
 SET 
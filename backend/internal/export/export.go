@@ -0,0 +1,101 @@
+// Package export writes tabular submission data in the formats offered for download.
+// Every writer streams its output, so exports of any size use constant memory.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format identifies an export file format.
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatXLSX   Format = "xlsx"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat validates a format name, defaulting to CSV when it is empty.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case "":
+		return FormatCSV, nil
+	case FormatCSV, FormatXLSX, FormatNDJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q, use csv, xlsx or ndjson", name)
+	}
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Extension returns the file name extension of the format, without the dot.
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// Writer receives a header followed by any number of rows with one value per column.
+// Values are JSON-like: nil, string, float64, bool, []interface{} or map[string]interface{}.
+// Close must be called to flush the output.
+type Writer interface {
+	WriteHeader(columns []string) error
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// NewWriter creates a writer for the given format
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w), nil
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// formatCell renders a value as text for the spreadsheet formats.
+func formatCell(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	case time.Time:
+		return value.UTC().Format(time.RFC3339)
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = formatCell(item)
+		}
+		return strings.Join(items, ", ")
+	default:
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(raw)
+	}
+}
@@ -0,0 +1,228 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// write runs the header and rows through a writer of the given format.
+func write(t *testing.T, format Format, columns []string, rows ...[]interface{}) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	if err := w.WriteHeader(columns); err != nil {
+		t.Fatalf("WriteHeader: %v", err)
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatalf("WriteRow: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Format
+		wantErr bool
+	}{
+		{"", FormatCSV, false},
+		{"csv", FormatCSV, false},
+		{"XLSX", FormatXLSX, false},
+		{"ndjson", FormatNDJSON, false},
+		{"pdf", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	out := write(t, FormatCSV, []string{"name", "score", "vip", "notes"},
+		[]interface{}{"Ada", 9.5, true, nil},
+		[]interface{}{"=HYPERLINK(\"http://x\")", 1.0, false, "+1 555"},
+		[]interface{}{"@SUM(A1)", 2.0, false, "line one\nline two"},
+		[]interface{}{"Grace", -3.0, false, []interface{}{"=cmd|' /C calc'!A0", "b"}},
+	)
+
+	records, err := csv.NewReader(bytes.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v\n%s", err, out)
+	}
+	want := [][]string{
+		{"name", "score", "vip", "notes"},
+		{"Ada", "9.5", "true", ""},
+		{"'=HYPERLINK(\"http://x\")", "1", "false", "'+1 555"},
+		{"'@SUM(A1)", "2", "false", "line one\nline two"},
+		{"Grace", "'-3", "false", "'=cmd|' /C calc'!A0, b"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %q, want %q", records, want)
+	}
+}
+
+func TestEscapeFormula(t *testing.T) {
+	tests := map[string]string{
+		"":          "",
+		"hello":     "hello",
+		"=1+1":      "'=1+1",
+		"+1":        "'+1",
+		"-1":        "'-1",
+		"@cmd":      "'@cmd",
+		"\tx":       "'\tx",
+		"\rx":       "'\rx",
+		"a=b":       "a=b",
+		"'=already": "'=already",
+	}
+	for in, want := range tests {
+		if got := escapeFormula(in); got != want {
+			t.Errorf("escapeFormula(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// xlsxSheet is the part of the sheet XML the tests read back.
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestXLSXWriter(t *testing.T) {
+	out := write(t, FormatXLSX, []string{"name", "score", "vip", "notes"},
+		[]interface{}{`Tom & "Jerry" <cat>`, 9.5, true, nil},
+		[]interface{}{"  padded  ", -2.0, false, []interface{}{"a", "b"}},
+	)
+
+	archive, err := zip.NewReader(bytes.NewReader(out), int64(len(out)))
+	if err != nil {
+		t.Fatalf("opening the workbook: %v", err)
+	}
+
+	parts := map[string][]byte{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("reading %s: %v", f.Name, err)
+		}
+		parts[f.Name] = content
+
+		// Every part must be well-formed XML
+		dec := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed: %v\n%s", f.Name, err, content)
+			}
+		}
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("workbook is missing %s", name)
+		}
+	}
+
+	var sheet xlsxSheet
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatalf("parsing the sheet: %v", err)
+	}
+
+	type cell struct{ Type, Value string }
+	want := [][]cell{
+		{{"inlineStr", "name"}, {"inlineStr", "score"}, {"inlineStr", "vip"}, {"inlineStr", "notes"}},
+		{{"inlineStr", `Tom & "Jerry" <cat>`}, {"", "9.5"}, {"b", "1"}, {"", ""}},
+		{{"inlineStr", "  padded  "}, {"", "-2"}, {"b", "0"}, {"inlineStr", "a, b"}},
+	}
+	var got [][]cell
+	for _, row := range sheet.Rows {
+		var cells []cell
+		for _, c := range row.Cells {
+			value := c.Value
+			if c.Type == "inlineStr" {
+				value = c.Inline
+			}
+			cells = append(cells, cell{c.Type, value})
+		}
+		got = append(got, cells)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sheet = %q, want %q", got, want)
+	}
+}
+
+func TestXLSXWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatXLSX, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("opening the workbook: %v", err)
+	}
+	if len(archive.File) != 5 {
+		t.Errorf("workbook has %d parts, want 5", len(archive.File))
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	out := write(t, FormatNDJSON, []string{"name", "score", "tags"},
+		[]interface{}{"Ada\nLovelace", 9.5, []interface{}{"vip"}},
+		[]interface{}{nil, 1.0, nil},
+	)
+
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want one per row:\n%s", len(lines), out)
+	}
+	if !strings.HasPrefix(lines[0], `{"name":`) || !strings.Contains(lines[0], `,"score":9.5,"tags":`) {
+		t.Errorf("keys are not in column order: %s", lines[0])
+	}
+
+	want := []map[string]interface{}{
+		{"name": "Ada\nLovelace", "score": 9.5, "tags": []interface{}{"vip"}},
+		{"name": nil, "score": 1.0, "tags": nil},
+	}
+	for i, line := range lines {
+		var got map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d %q: %v", i+1, line, err)
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("line %d = %v, want %v", i+1, got, want[i])
+		}
+	}
+}
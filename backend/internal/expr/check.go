@@ -0,0 +1,144 @@
+package expr
+
+// checker assigns static types to a syntax tree and rejects operations that can
+// never succeed, such as adding a boolean to a number.
+type checker struct {
+	src    string
+	fields map[string]Type // nil allows any reference.
+	refs   map[string]bool // The fields referenced so far.
+}
+
+func (c *checker) check(n node) (Type, error) {
+	switch n := n.(type) {
+	case *literal:
+		return typeOf(n.value), nil
+
+	case *ref:
+		c.refs[n.id] = true
+		if c.fields == nil {
+			return Any, nil
+		}
+		typ, ok := c.fields[n.id]
+		if !ok {
+			return Any, errorAt(c.src, n.at, "unknown field {%s}", n.id)
+		}
+		n.typ = typ
+		return typ, nil
+
+	case *unary:
+		x, err := c.check(n.x)
+		if err != nil {
+			return Any, err
+		}
+		want := Number
+		if n.op == "!" {
+			want = Bool
+		}
+		if !is(x, want) {
+			return Any, errorAt(c.src, n.at, "operator %s needs a %s, not a %s", n.op, want, x)
+		}
+		return want, nil
+
+	case *binary:
+		x, err := c.check(n.x)
+		if err != nil {
+			return Any, err
+		}
+		y, err := c.check(n.y)
+		if err != nil {
+			return Any, err
+		}
+		return c.binary(n, x, y)
+
+	case *call:
+		return c.call(n)
+	}
+	return Any, nil
+}
+
+func (c *checker) binary(n *binary, x, y Type) (Type, error) {
+	switch n.op {
+	case "&&", "||":
+		if is(x, Bool) && is(y, Bool) {
+			return Bool, nil
+		}
+		return Any, errorAt(c.src, n.at, "operator %s needs booleans, not %s and %s", n.op, x, y)
+
+	case "==", "!=":
+		return Bool, nil
+
+	case "<", "<=", ">", ">=":
+		if orderable(x) && orderable(y) && (x != Number || y != Date) && (x != Date || y != Number) {
+			return Bool, nil
+		}
+		return Any, errorAt(c.src, n.at, "cannot compare %s and %s with %s", x, y, n.op)
+
+	case "+":
+		switch {
+		case x == String || y == String:
+			return String, nil
+		case x == Number && y == Number:
+			return Number, nil
+		case is(x, Number) && is(y, Number):
+			return Any, nil // Text or a number, depending on the values
+		}
+		return Any, errorAt(c.src, n.at, "cannot add %s and %s", x, y)
+
+	default: // - * / %
+		if is(x, Number) && is(y, Number) {
+			return Number, nil
+		}
+		return Any, errorAt(c.src, n.at, "operator %s needs numbers, not %s and %s", n.op, x, y)
+	}
+}
+
+func (c *checker) call(n *call) (Type, error) {
+	fn := n.fn
+	if len(n.args) < fn.minArgs || (!fn.variadic && len(n.args) > len(fn.params)) {
+		return Any, errorAt(c.src, n.at, "%s takes %s, not %d", n.name, fn.arity(), len(n.args))
+	}
+
+	types := make([]Type, len(n.args))
+	for i, arg := range n.args {
+		typ, err := c.check(arg)
+		if err != nil {
+			return Any, err
+		}
+		if want := fn.param(i); !assignable(typ, want) {
+			return Any, errorAt(c.src, arg.pos(), "argument %d of %s must be a %s, not a %s", i+1, n.name, want, typ)
+		}
+		types[i] = typ
+	}
+
+	if fn.resultOf != nil {
+		return fn.resultOf(types), nil
+	}
+	return fn.result, nil
+}
+
+// is reports whether a value of type t may be a want.
+func is(t, want Type) bool {
+	return t == Any || t == want
+}
+
+// assignable reports whether a value of type t may be passed for a parameter of type want.
+func assignable(t, want Type) bool {
+	return is(t, want) || want == Any || (want == Date && t == String)
+}
+
+func orderable(t Type) bool {
+	return t == Any || t == Number || t == String || t == Date
+}
+
+// common returns the type all of types share, or Any.
+func common(types []Type) Type {
+	if len(types) == 0 {
+		return Any
+	}
+	for _, t := range types[1:] {
+		if t != types[0] {
+			return Any
+		}
+	}
+	return types[0]
+}
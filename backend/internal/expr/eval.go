@@ -0,0 +1,376 @@
+package expr
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type evaluator struct {
+	src string
+	env Env
+}
+
+func (e *evaluator) errorf(n node, format string, args ...interface{}) error {
+	return errorAt(e.src, n.pos(), format, args...)
+}
+
+func (e *evaluator) eval(n node) (interface{}, error) {
+	switch n := n.(type) {
+	case *literal:
+		return n.value, nil
+
+	case *ref:
+		value := normalize(e.env.Data[n.id])
+		if value == nil {
+			return nil, nil
+		}
+		switch n.typ {
+		case Number:
+			f, ok := toNumber(value)
+			if !ok {
+				return nil, e.errorf(n, "{%s} is a %s, not a number", n.id, typeName(value))
+			}
+			return f, nil
+		case Date:
+			d, err := toDate(value)
+			if err != nil {
+				return nil, e.errorf(n, "{%s} is not a date: %v", n.id, err)
+			}
+			return d, nil
+		}
+		return value, nil
+
+	case *unary:
+		x, err := e.eval(n.x)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			b, err := e.truth(n.x, x)
+			return !b, err
+		}
+		if x == nil {
+			return nil, nil
+		}
+		f, ok := toNumber(x)
+		if !ok {
+			return nil, e.errorf(n, "cannot negate a %s", typeName(x))
+		}
+		return -f, nil
+
+	case *binary:
+		return e.binary(n)
+
+	case *call:
+		return e.call(n)
+	}
+	return nil, fmt.Errorf("unknown node %T", n)
+}
+
+// truth is the value of x, the result of n, as a condition.
+func (e *evaluator) truth(n node, x interface{}) (bool, error) {
+	switch v := x.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	default:
+		return false, e.errorf(n, "%s is a %s, not a boolean", describe(n), typeName(x))
+	}
+}
+
+func (e *evaluator) binary(n *binary) (interface{}, error) {
+	x, err := e.eval(n.x)
+	if err != nil {
+		return nil, err
+	}
+
+	// The right side of && and || is evaluated only when it decides the result
+	if n.op == "&&" || n.op == "||" {
+		b, err := e.truth(n.x, x)
+		if err != nil || b == (n.op == "||") {
+			return b, err
+		}
+		y, err := e.eval(n.y)
+		if err != nil {
+			return nil, err
+		}
+		return e.truth(n.y, y)
+	}
+
+	y, err := e.eval(n.y)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(x, y), nil
+	case "!=":
+		return !equal(x, y), nil
+	case "<", "<=", ">", ">=":
+		c, ok := compare(x, y)
+		if !ok {
+			return false, nil
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	}
+
+	if x == nil || y == nil {
+		return nil, nil
+	}
+	if n.op == "+" {
+		_, xs := x.(string)
+		_, ys := y.(string)
+		if xs || ys {
+			return text(x) + text(y), nil
+		}
+	}
+
+	a, aok := toNumber(x)
+	b, bok := toNumber(y)
+	if !aok || !bok {
+		return nil, e.errorf(n, "operator %s needs numbers, not %s and %s", n.op, typeName(x), typeName(y))
+	}
+	switch n.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, e.errorf(n, "division by zero")
+		}
+		return a / b, nil
+	default:
+		if b == 0 {
+			return nil, e.errorf(n, "division by zero")
+		}
+		return math.Mod(a, b), nil
+	}
+}
+
+func (e *evaluator) call(n *call) (interface{}, error) {
+	// if evaluates only the branch it returns
+	if n.name == "if" {
+		cond, err := e.eval(n.args[0])
+		if err != nil {
+			return nil, err
+		}
+		b, err := e.truth(n.args[0], cond)
+		if err != nil {
+			return nil, err
+		}
+		if b {
+			return e.eval(n.args[1])
+		}
+		return e.eval(n.args[2])
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		value, err := e.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil && !n.fn.handlesNull {
+			return nil, nil
+		}
+
+		switch n.fn.param(i) {
+		case Number:
+			f, ok := toNumber(value)
+			if !ok {
+				return nil, e.errorf(arg, "argument %d of %s must be a number, not a %s", i+1, n.name, typeName(value))
+			}
+			value = f
+		case String:
+			value = text(value)
+		case Date:
+			d, err := toDate(value)
+			if err != nil {
+				return nil, e.errorf(arg, "argument %d of %s: %v", i+1, n.name, err)
+			}
+			value = d
+		}
+		args[i] = value
+	}
+
+	result, err := n.fn.call(args, &e.env)
+	if err != nil {
+		return nil, e.errorf(n, "%s: %v", n.name, err)
+	}
+	return result, nil
+}
+
+// normalize converts the numbers of decoded JSON and Go callers to float64.
+func normalize(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	}
+	return v
+}
+
+// typeOf returns the static type of a literal value.
+func typeOf(v interface{}) Type {
+	switch v.(type) {
+	case float64:
+		return Number
+	case string:
+		return String
+	case bool:
+		return Bool
+	case time.Time:
+		return Date
+	case []interface{}:
+		return List
+	}
+	return Any
+}
+
+// typeName names the type of a value in error messages.
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	}
+	if t := typeOf(normalize(v)); t != Any {
+		return t.String()
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// toNumber converts numbers and numeric strings to float64.
+func toNumber(v interface{}) (float64, bool) {
+	switch n := normalize(v).(type) {
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// toDate converts dates and strings in YYYY-MM-DD or RFC 3339 format to time.Time.
+func toDate(v interface{}) (time.Time, error) {
+	switch d := v.(type) {
+	case time.Time:
+		return d, nil
+	case string:
+		s := strings.TrimSpace(d)
+		if t, err := time.Parse(time.DateOnly, s); err == nil {
+			return t, nil
+		}
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, nil
+		}
+		return time.Time{}, fmt.Errorf("%q is not a YYYY-MM-DD date or RFC 3339 timestamp", d)
+	}
+	return time.Time{}, fmt.Errorf("a %s is not a date", typeName(v))
+}
+
+// text formats a value for string operations.
+func text(v interface{}) string {
+	switch t := normalize(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case time.Time:
+		if t.Equal(t.Truncate(24 * time.Hour)) {
+			return t.Format(time.DateOnly)
+		}
+		return t.Format(time.RFC3339)
+	case []interface{}:
+		parts := make([]string, len(t))
+		for i, item := range t {
+			parts[i] = text(item)
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(v)
+}
+
+// equal compares values the way conditionals always have: numerically when both are
+// numbers or numeric strings, as dates when either is a date, and by their text otherwise.
+func equal(a, b interface{}) bool {
+	a, b = normalize(a), normalize(b)
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			return x == y
+		}
+	}
+	_, ad := a.(time.Time)
+	_, bd := b.(time.Time)
+	if ad || bd {
+		x, err := toDate(a)
+		if err != nil {
+			return false
+		}
+		y, err := toDate(b)
+		return err == nil && x.Equal(y)
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// compare orders two numbers, dates or strings. It reports false for values that
+// cannot be ordered, null among them.
+func compare(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			return cmp.Compare(x, y), true
+		}
+	}
+	_, ad := a.(time.Time)
+	_, bd := b.(time.Time)
+	if ad || bd {
+		x, err := toDate(a)
+		if err != nil {
+			return 0, false
+		}
+		y, err := toDate(b)
+		if err != nil {
+			return 0, false
+		}
+		return x.Compare(y), true
+	}
+	x, xs := a.(string)
+	y, ys := b.(string)
+	if xs && ys {
+		return strings.Compare(x, y), true
+	}
+	return 0, false
+}
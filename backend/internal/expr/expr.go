@@ -0,0 +1,199 @@
+// Package expr compiles and evaluates the expressions of forms and workflows: field
+// and action conditionals, and the formulas of calculation fields.
+//
+// An expression combines literals, field references and function calls with
+// operators. Literals are numbers (12, 0.5, 1e3), strings in single or double quotes
+// with backslash escapes, true, false and null. A field reference is the field ID in
+// braces, e.g. {field_id} or {crm.status}; a field without a value is null.
+//
+// The operators, from lowest to highest precedence, are
+//
+//	||
+//	&&
+//	==  !=
+//	<  <=  >  >=
+//	+  -
+//	*  /  %
+//	unary -  !
+//
+// + adds numbers and joins text when either side is a string. Comparisons compare
+// numbers, numeric strings, dates and strings; values that cannot be ordered, null
+// among them, compare false. Arithmetic and most functions return null when an
+// operand is null, so a formula over an empty field is empty; coalesce supplies a
+// default. && and || treat null as false, and they and if only evaluate the operands
+// they need.
+//
+// Expressions are type checked when they are compiled, against the types of the
+// fields they may reference, and evaluation has no side effects. Compile and
+// evaluation errors are *Error values that give the column they are about.
+package expr
+
+import (
+	"fmt"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxLength is the longest source Compile accepts, in bytes.
+const MaxLength = 4096
+
+// maxDepth bounds how deeply expressions nest.
+const maxDepth = 64
+
+// Type is the static type of an expression or field.
+type Type int
+
+const (
+	Any    Type = iota // Known only when the expression is evaluated.
+	Number             // float64
+	String             // string
+	Bool               // bool
+	Date               // time.Time; strings in YYYY-MM-DD or RFC 3339 format convert to it.
+	List               // []interface{}, e.g. the checked options of a checkbox field.
+)
+
+func (t Type) String() string {
+	switch t {
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Bool:
+		return "boolean"
+	case Date:
+		return "date"
+	case List:
+		return "list"
+	default:
+		return "any"
+	}
+}
+
+// Error is a compile or evaluation error.
+type Error struct {
+	Column  int // 1-based position, in characters, of the part of the source at fault; 0 when there is no source.
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Column == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("column %d: %s", e.Column, e.Message)
+}
+
+// errorAt returns an Error about the byte offset pos of src.
+func errorAt(src string, pos int, format string, args ...interface{}) *Error {
+	err := &Error{Message: fmt.Sprintf(format, args...)}
+	if src != "" {
+		err.Column = utf8.RuneCountInString(src[:min(pos, len(src))]) + 1
+	}
+	return err
+}
+
+// Program is a compiled expression. It is safe for concurrent use.
+type Program struct {
+	source string
+	root   node
+	typ    Type
+	refs   []string
+}
+
+// Compile parses and type checks an expression. fields holds the types of the fields
+// it may reference; with nil fields any reference is allowed and has type Any.
+func Compile(source string, fields map[string]Type) (*Program, error) {
+	if len(source) > MaxLength {
+		return nil, &Error{Message: fmt.Sprintf("expression is longer than %d characters", MaxLength)}
+	}
+
+	root, err := parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return newProgram(source, root, fields)
+}
+
+// Comparison compiles a structured conditional: the field fieldID compared with value
+// by op, which is one of ==, !=, includes, >= and <=. The value is a literal.
+func Comparison(fieldID, op string, value interface{}, fields map[string]Type) (*Program, error) {
+	if fieldID == "" {
+		return nil, &Error{Message: "conditional field ID is required"}
+	}
+
+	field := &ref{id: fieldID}
+	operand := &literal{value: normalize(value)}
+	var root node
+	switch op {
+	case "==", "!=", ">=", "<=":
+		root = &binary{op: op, x: field, y: operand}
+	case "includes":
+		root = &call{name: op, fn: functions[op], args: []node{field, operand}}
+	default:
+		return nil, &Error{Message: fmt.Sprintf("unsupported operator %q", op)}
+	}
+	return newProgram("", root, fields)
+}
+
+func newProgram(source string, root node, fields map[string]Type) (*Program, error) {
+	c := &checker{src: source, fields: fields, refs: make(map[string]bool)}
+	typ, err := c.check(root)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]string, 0, len(c.refs))
+	for id := range c.refs {
+		refs = append(refs, id)
+	}
+	slices.Sort(refs)
+
+	return &Program{source: source, root: root, typ: typ, refs: refs}, nil
+}
+
+// Type returns the static type of the expression's value.
+func (p *Program) Type() Type {
+	return p.typ
+}
+
+// References returns the IDs of the fields the expression references, sorted.
+func (p *Program) References() []string {
+	return slices.Clone(p.refs)
+}
+
+// String returns the source of the expression.
+func (p *Program) String() string {
+	return p.source
+}
+
+// Env is what a program is evaluated against.
+type Env struct {
+	Data map[string]interface{} // Field values by ID, as decoded from JSON.
+	Now  time.Time              // The time today() is taken from. Defaults to the current time.
+}
+
+// Eval evaluates the expression. The result is nil, a float64, string, bool,
+// time.Time or []interface{}.
+func (p *Program) Eval(env Env) (interface{}, error) {
+	if env.Now.IsZero() {
+		env.Now = time.Now()
+	}
+	e := &evaluator{src: p.source, env: env}
+	return e.eval(p.root)
+}
+
+// EvalBool evaluates an expression used as a condition. Null is false.
+func (p *Program) EvalBool(env Env) (bool, error) {
+	value, err := p.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	default:
+		return false, errorAt(p.source, p.root.pos(), "condition is a %s, not a boolean", typeName(value))
+	}
+}
@@ -0,0 +1,296 @@
+package expr
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testFields = map[string]Type{
+	"qty":     Number,
+	"price":   Number,
+	"name":    String,
+	"agree":   Bool,
+	"born":    Date,
+	"tags":    List,
+	"unknown": Any,
+	"missing": Any, // Never has a value.
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		column int
+		want   string
+	}{
+		{"", 0, "expression is empty"},
+		{"   ", 4, "expression is empty"},
+		{"{qty} +", 8, "unexpected end of expression"},
+		{"{qty} = 1", 7, `unexpected "="; compare with ==`},
+		{"{agree} & true", 9, `unexpected '&'; use &&`},
+		{"qty + 1", 1, `unknown name "qty"; write field references as {qty}`},
+		{"{qty", 1, "field reference is not closed with }"},
+		{"{ }", 1, "field reference is empty"},
+		{"'abc", 1, "string is not closed with '"},
+		{`"a\qb"`, 3, `unknown escape \q`},
+		{"(1 + 2", 7, `expected ")", found end of expression`},
+		{"1 2", 3, "unexpected number 2"},
+		{"1 # 2", 3, "unexpected character '#'"},
+		{"'é' + #", 7, "unexpected character '#'"},
+		{"nope(1)", 1, "unknown function nope"},
+		{"{nope} > 1", 1, "unknown field {nope}"},
+		{"{agree} + 1", 9, "cannot add boolean and number"},
+		{"{qty} * {name}", 7, "operator * needs numbers, not number and string"},
+		{"{qty} && true", 7, "operator && needs booleans, not number and boolean"},
+		{"!{qty}", 1, "operator ! needs a boolean, not a number"},
+		{"-{name}", 1, "operator - needs a number, not a string"},
+		{"{qty} < {born}", 7, "cannot compare number and date with <"},
+		{"{tags} > 1", 8, "cannot compare list and number with >"},
+		{"round()", 1, "round takes 1 to 2 arguments, not 0"},
+		{"today(1)", 1, "today takes 0 arguments, not 1"},
+		{"max()", 1, "max takes at least 1 argument, not 0"},
+		{"abs({name})", 5, "argument 1 of abs must be a number, not a string"},
+		{"year({qty})", 6, "argument 1 of year must be a date, not a number"},
+		{"if({qty}, 1, 2)", 4, "argument 1 of if must be a boolean, not a number"},
+	}
+
+	for _, tt := range tests {
+		_, err := Compile(tt.source, testFields)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("Compile(%q) error = %v, want an *Error", tt.source, err)
+			continue
+		}
+		if e.Column != tt.column || e.Message != tt.want {
+			t.Errorf("Compile(%q) error = column %d: %q, want column %d: %q", tt.source, e.Column, e.Message, tt.column, tt.want)
+		}
+	}
+}
+
+func TestCompileLimits(t *testing.T) {
+	long := make([]byte, MaxLength+1)
+	for i := range long {
+		long[i] = '1'
+	}
+	if _, err := Compile(string(long), nil); err == nil {
+		t.Error("Compile accepted an expression longer than MaxLength")
+	}
+
+	nested := ""
+	for range maxDepth + 1 {
+		nested += "("
+	}
+	if _, err := Compile(nested+"1", nil); err == nil {
+		t.Error("Compile accepted an expression nested deeper than maxDepth")
+	}
+}
+
+func TestEval(t *testing.T) {
+	data := map[string]interface{}{
+		"qty":     float64(3),
+		"price":   "2.50",
+		"name":    "Ada Lovelace",
+		"agree":   true,
+		"born":    "1990-07-15",
+		"tags":    []interface{}{"a", float64(2)},
+		"unknown": "x",
+	}
+	now := time.Date(2025, 3, 10, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		source string
+		want   interface{}
+	}{
+		{"1 + 2 * 3", float64(7)},
+		{"(1 + 2) * 3", float64(9)},
+		{"-2 - -3", float64(1)},
+		{"7 % 4", float64(3)},
+		{"1e3 / 8", float64(125)},
+		{"{qty} * {price}", float64(7.5)},
+		{"{qty} >= 3 && {agree}", true},
+		{"{qty} > 5 || !{agree}", false},
+		{"{name} + ' (' + {qty} + ')'", "Ada Lovelace (3)"},
+		{`"a\tb" == 'a\tb'`, true},
+		{"{name} < 'B'", true},
+		{"{price} == 2.5", true},
+		{"{born} < '2000-01-01'", true},
+		{"{missing}", nil},
+		{"{missing} + 1", nil},
+		{"{missing} == null", true},
+		{"{missing} > 1", false},
+		{"{missing} && true", false},
+		{"coalesce({missing}, 0) + 1", float64(1)},
+		{"if({qty} > 2, 'many', 'few')", "many"},
+		{"if({agree}, 1, 1 / 0)", float64(1)},
+		{"false && 1 / 0 == 1", false},
+		{"round(2.345, 2)", 2.35},
+		{"round(2.5)", float64(3)},
+		{"abs(-2) + floor(1.7) + ceil(1.2)", float64(5)},
+		{"min(3, 1, 2) + max(3, 1, 2) + sum(1, 2, 3)", float64(10)},
+		{"number('12') + number('x')", nil},
+		{"text(1.5) + text(null)", "1.5"},
+		{"len({name}) + len({tags}) + len(null)", float64(14)},
+		{"upper(trim('  ab ')) + lower('CD')", "ABcd"},
+		{"contains({name}, 'Love') && startsWith({name}, 'Ada') && endsWith({name}, 'ace')", true},
+		{"includes({tags}, 'a') && includes({tags}, '2') && !includes({tags}, 'b')", true},
+		{"isEmpty({missing}) && isEmpty(' ') && !isEmpty({tags})", true},
+		{"year({born}) * 10000 + month({born}) * 100 + day({born})", float64(19900715)},
+		{"daysBetween({born}, '1990-08-01')", float64(17)},
+		{"text(addDays({born}, 20))", "1990-08-04"},
+		{"text(today())", "2025-03-10"},
+		{"date('2025-03-10T12:00:00Z') > today()", true},
+		{"{unknown} + 1", "x1"},
+	}
+
+	for _, tt := range tests {
+		program, err := Compile(tt.source, testFields)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.source, err)
+			continue
+		}
+		got, err := program.Eval(Env{Data: data, Now: now})
+		if err != nil {
+			t.Errorf("Eval(%q): %v", tt.source, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Eval(%q) = %#v, want %#v", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	data := map[string]interface{}{
+		"qty":   "three",
+		"born":  "yesterday",
+		"zero":  float64(0),
+		"other": "x",
+	}
+	fields := map[string]Type{"qty": Number, "born": Date, "zero": Number, "other": Any}
+
+	tests := []struct {
+		source string
+		column int
+		want   string
+	}{
+		{"{qty} + 1", 1, "{qty} is a string, not a number"},
+		{"year({born})", 6, `{born} is not a date: "yesterday" is not a YYYY-MM-DD date or RFC 3339 timestamp`},
+		{"1 / {zero}", 3, "division by zero"},
+		{"round(1, 20)", 1, "round: digits must be between 0 and 15"},
+		{"{other} && true", 1, "{other} is a string, not a boolean"},
+	}
+
+	for _, tt := range tests {
+		program, err := Compile(tt.source, fields)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.source, err)
+			continue
+		}
+		_, err = program.Eval(Env{Data: data})
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("Eval(%q) error = %v, want an *Error", tt.source, err)
+			continue
+		}
+		if e.Column != tt.column || e.Message != tt.want {
+			t.Errorf("Eval(%q) error = column %d: %q, want column %d: %q", tt.source, e.Column, e.Message, tt.column, tt.want)
+		}
+	}
+}
+
+func TestEvalBool(t *testing.T) {
+	tests := []struct {
+		source  string
+		want    bool
+		wantErr bool
+	}{
+		{"{a} == 1", true, false},
+		{"{missing}", false, false},
+		{"{a} + 1", false, true},
+	}
+
+	for _, tt := range tests {
+		program, err := Compile(tt.source, nil)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", tt.source, err)
+		}
+		got, err := program.EvalBool(Env{Data: map[string]interface{}{"a": 1}})
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("EvalBool(%q) = %v, %v; want %v, error %v", tt.source, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestComparison(t *testing.T) {
+	data := map[string]interface{}{
+		"plan":  "pro",
+		"seats": float64(10),
+		"tags":  []interface{}{"vip", "eu"},
+		"note":  "urgent: call back",
+	}
+
+	tests := []struct {
+		field string
+		op    string
+		value interface{}
+		want  bool
+	}{
+		{"plan", "==", "pro", true},
+		{"plan", "!=", "pro", false},
+		{"seats", "==", "10", true},
+		{"seats", ">=", 10, true},
+		{"seats", "<=", 9.5, false},
+		{"tags", "includes", "vip", true},
+		{"tags", "includes", "us", false},
+		{"note", "includes", "urgent", true},
+		{"missing", "==", "pro", false},
+		{"missing", "!=", "pro", true},
+		{"missing", ">=", 1, false},
+		{"missing", "includes", "vip", false},
+	}
+
+	for _, tt := range tests {
+		program, err := Comparison(tt.field, tt.op, tt.value, nil)
+		if err != nil {
+			t.Fatalf("Comparison(%q, %q, %v): %v", tt.field, tt.op, tt.value, err)
+		}
+		got, err := program.EvalBool(Env{Data: data})
+		if err != nil || got != tt.want {
+			t.Errorf("Comparison(%q, %q, %v) = %v, %v; want %v", tt.field, tt.op, tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ field, op string }{{"", "=="}, {"plan", "~="}} {
+		if _, err := Comparison(tt.field, tt.op, "x", nil); err == nil {
+			t.Errorf("Comparison(%q, %q) succeeded, want an error", tt.field, tt.op)
+		}
+	}
+	if _, err := Comparison("agree", ">=", 1, map[string]Type{"agree": Bool}); err == nil {
+		t.Error("Comparison of a boolean field with >= succeeded, want a type error")
+	}
+}
+
+func TestProgram(t *testing.T) {
+	program, err := Compile("{b} + {a} * {b}", map[string]Type{"a": Number, "b": Number})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := program.References(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("References() = %v, want [a b]", got)
+	}
+	if program.Type() != Number {
+		t.Errorf("Type() = %s, want number", program.Type())
+	}
+	if program.String() != "{b} + {a} * {b}" {
+		t.Errorf("String() = %q", program.String())
+	}
+
+	program, err = Compile("{x} + 1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if program.Type() != Any {
+		t.Errorf("Type() = %s, want any for an untyped reference", program.Type())
+	}
+}
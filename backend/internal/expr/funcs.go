@@ -0,0 +1,190 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// function is a built-in function. The evaluator converts arguments to the types of
+// their parameters before call sees them.
+type function struct {
+	params   []Type
+	minArgs  int
+	variadic bool // The last parameter repeats.
+	result   Type
+	resultOf func(args []Type) Type // Derives the result type from the argument types when set.
+
+	// handlesNull passes null arguments to call; otherwise a null argument makes the result null.
+	handlesNull bool
+	call        func(args []interface{}, env *Env) (interface{}, error)
+}
+
+// param returns the type of the i-th argument.
+func (f *function) param(i int) Type {
+	if i >= len(f.params) {
+		return f.params[len(f.params)-1]
+	}
+	return f.params[i]
+}
+
+// arity describes the number of arguments f takes.
+func (f *function) arity() string {
+	plural := func(n int) string {
+		if n == 1 {
+			return "1 argument"
+		}
+		return fmt.Sprintf("%d arguments", n)
+	}
+	switch {
+	case f.variadic:
+		return "at least " + plural(f.minArgs)
+	case f.minArgs < len(f.params):
+		return fmt.Sprintf("%d to %s", f.minArgs, plural(len(f.params)))
+	}
+	return plural(len(f.params))
+}
+
+// functions are the functions expressions may call, by name.
+var functions map[string]*function
+
+func init() {
+	number := func(fn func(float64) float64) *function {
+		return &function{params: []Type{Number}, minArgs: 1, result: Number, call: func(args []interface{}, env *Env) (interface{}, error) {
+			return fn(args[0].(float64)), nil
+		}}
+	}
+	str := func(fn func(string) string) *function {
+		return &function{params: []Type{String}, minArgs: 1, result: String, call: func(args []interface{}, env *Env) (interface{}, error) {
+			return fn(args[0].(string)), nil
+		}}
+	}
+	match := func(fn func(s, sub string) bool) *function {
+		return &function{params: []Type{String, String}, minArgs: 2, result: Bool, call: func(args []interface{}, env *Env) (interface{}, error) {
+			return fn(args[0].(string), args[1].(string)), nil
+		}}
+	}
+	datePart := func(fn func(time.Time) int) *function {
+		return &function{params: []Type{Date}, minArgs: 1, result: Number, call: func(args []interface{}, env *Env) (interface{}, error) {
+			return float64(fn(args[0].(time.Time))), nil
+		}}
+	}
+	fold := func(fn func(a, b float64) float64) *function {
+		return &function{params: []Type{Number}, minArgs: 1, variadic: true, result: Number, call: func(args []interface{}, env *Env) (interface{}, error) {
+			acc := args[0].(float64)
+			for _, arg := range args[1:] {
+				acc = fn(acc, arg.(float64))
+			}
+			return acc, nil
+		}}
+	}
+
+	functions = map[string]*function{
+		// Numbers
+		"abs":   number(math.Abs),
+		"ceil":  number(math.Ceil),
+		"floor": number(math.Floor),
+		"round": {params: []Type{Number, Number}, minArgs: 1, result: Number, call: func(args []interface{}, env *Env) (interface{}, error) {
+			digits := 0.0
+			if len(args) > 1 {
+				digits = math.Trunc(args[1].(float64))
+			}
+			if digits < 0 || digits > 15 {
+				return nil, fmt.Errorf("digits must be between 0 and 15")
+			}
+			scale := math.Pow(10, digits)
+			return math.Round(args[0].(float64)*scale) / scale, nil
+		}},
+		"min": fold(math.Min),
+		"max": fold(math.Max),
+		"sum": fold(func(a, b float64) float64 { return a + b }),
+		"number": {params: []Type{Any}, minArgs: 1, result: Number, call: func(args []interface{}, env *Env) (interface{}, error) {
+			if f, ok := toNumber(args[0]); ok {
+				return f, nil
+			}
+			return nil, nil // Text that is not a number converts to null
+		}},
+
+		// Text
+		"text": {params: []Type{Any}, minArgs: 1, result: String, handlesNull: true, call: func(args []interface{}, env *Env) (interface{}, error) {
+			return text(args[0]), nil
+		}},
+		"len": {params: []Type{Any}, minArgs: 1, result: Number, handlesNull: true, call: func(args []interface{}, env *Env) (interface{}, error) {
+			switch v := args[0].(type) {
+			case nil:
+				return 0.0, nil
+			case string:
+				return float64(utf8.RuneCountInString(v)), nil
+			case []interface{}:
+				return float64(len(v)), nil
+			}
+			return nil, fmt.Errorf("a %s has no length", typeName(args[0]))
+		}},
+		"lower":      str(strings.ToLower),
+		"upper":      str(strings.ToUpper),
+		"trim":       str(strings.TrimSpace),
+		"contains":   match(strings.Contains),
+		"startsWith": match(strings.HasPrefix),
+		"endsWith":   match(strings.HasSuffix),
+
+		// Logic and lists
+		"if": {params: []Type{Bool, Any, Any}, minArgs: 3, handlesNull: true, resultOf: func(args []Type) Type {
+			return common(args[1:])
+		}},
+		"coalesce": {params: []Type{Any}, minArgs: 1, variadic: true, handlesNull: true, resultOf: common, call: func(args []interface{}, env *Env) (interface{}, error) {
+			for _, arg := range args {
+				if arg != nil {
+					return arg, nil
+				}
+			}
+			return nil, nil
+		}},
+		"isEmpty": {params: []Type{Any}, minArgs: 1, result: Bool, handlesNull: true, call: func(args []interface{}, env *Env) (interface{}, error) {
+			switch v := args[0].(type) {
+			case nil:
+				return true, nil
+			case string:
+				return strings.TrimSpace(v) == "", nil
+			case []interface{}:
+				return len(v) == 0, nil
+			}
+			return false, nil
+		}},
+		"includes": {params: []Type{Any, Any}, minArgs: 2, result: Bool, handlesNull: true, call: func(args []interface{}, env *Env) (interface{}, error) {
+			switch c := args[0].(type) {
+			case []interface{}:
+				return slices.ContainsFunc(c, func(item interface{}) bool { return equal(item, args[1]) }), nil
+			case string:
+				return strings.Contains(c, fmt.Sprint(args[1])), nil
+			}
+			return false, nil
+		}},
+
+		// Dates
+		"date": {params: []Type{Date}, minArgs: 1, result: Date, call: func(args []interface{}, env *Env) (interface{}, error) {
+			return args[0], nil
+		}},
+		"today": {result: Date, call: func(args []interface{}, env *Env) (interface{}, error) {
+			return midnight(env.Now), nil
+		}},
+		"year":  datePart(func(t time.Time) int { return t.Year() }),
+		"month": datePart(func(t time.Time) int { return int(t.Month()) }),
+		"day":   datePart(func(t time.Time) int { return t.Day() }),
+		"addDays": {params: []Type{Date, Number}, minArgs: 2, result: Date, call: func(args []interface{}, env *Env) (interface{}, error) {
+			return args[0].(time.Time).AddDate(0, 0, int(args[1].(float64))), nil
+		}},
+		"daysBetween": {params: []Type{Date, Date}, minArgs: 2, result: Number, call: func(args []interface{}, env *Env) (interface{}, error) {
+			from, to := midnight(args[0].(time.Time)), midnight(args[1].(time.Time))
+			return math.Round(to.Sub(from).Hours() / 24), nil
+		}},
+	}
+}
+
+// midnight returns the start of t's day, in UTC.
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,392 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// node is an element of the syntax tree. pos is its byte offset in the source.
+type node interface {
+	pos() int
+}
+
+type literal struct {
+	at    int
+	value interface{}
+}
+
+type ref struct {
+	at  int
+	id  string
+	typ Type // Set by the checker.
+}
+
+type unary struct {
+	at int
+	op string
+	x  node
+}
+
+type binary struct {
+	at   int
+	op   string
+	x, y node
+}
+
+type call struct {
+	at   int
+	name string
+	fn   *function
+	args []node
+}
+
+func (n *literal) pos() int { return n.at }
+func (n *ref) pos() int     { return n.at }
+func (n *unary) pos() int   { return n.at }
+func (n *binary) pos() int  { return n.at }
+func (n *call) pos() int    { return n.at }
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenRef
+	tokenIdent
+	tokenOp // An operator, a parenthesis or a comma.
+)
+
+type token struct {
+	kind  tokenKind
+	text  string // The operator, identifier, field ID or decoded string.
+	value float64
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenNumber:
+		return "number " + strconv.FormatFloat(t.value, 'g', -1, 64)
+	case tokenString:
+		return "string " + strconv.Quote(t.text)
+	case tokenRef:
+		return "field {" + t.text + "}"
+	default:
+		return strconv.Quote(t.text)
+	}
+}
+
+// operators are matched longest first.
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "+", "-", "*", "/", "%", "!", "<", ">", "(", ")", ","}
+
+// precedence of the binary operators; higher binds tighter.
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+type parser struct {
+	src   string
+	pos   int // Offset of the next token to scan.
+	tok   token
+	depth int
+}
+
+func parse(src string) (node, error) {
+	p := &parser{src: src}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenEOF {
+		return nil, p.errorf(p.tok.pos, "expression is empty")
+	}
+
+	root, err := p.expression(1)
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenEOF {
+		return nil, p.errorf(p.tok.pos, "unexpected %s", p.tok)
+	}
+	return root, nil
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return errorAt(p.src, pos, format, args...)
+}
+
+// expression parses binary operations whose operators bind at least as tightly as minPrec.
+func (p *parser) expression(minPrec int) (node, error) {
+	if p.depth++; p.depth > maxDepth {
+		return nil, p.errorf(p.tok.pos, "expression is nested too deeply")
+	}
+	defer func() { p.depth-- }()
+
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.tok.kind == tokenOp {
+		op := p.tok
+		prec, ok := precedence[op.text]
+		if !ok || prec < minPrec {
+			break
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.expression(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{at: op.pos, op: op.text, x: left, y: right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.tok.kind == tokenOp && (p.tok.text == "-" || p.tok.text == "!") {
+		op := p.tok
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.depth++; p.depth > maxDepth {
+			return nil, p.errorf(op.pos, "expression is nested too deeply")
+		}
+		defer func() { p.depth-- }()
+
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{at: op.pos, op: op.text, x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokenNumber:
+		return &literal{at: tok.pos, value: tok.value}, p.advance()
+	case tokenString:
+		return &literal{at: tok.pos, value: tok.text}, p.advance()
+	case tokenRef:
+		return &ref{at: tok.pos, id: tok.text}, p.advance()
+	case tokenIdent:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		switch tok.text {
+		case "true":
+			return &literal{at: tok.pos, value: true}, nil
+		case "false":
+			return &literal{at: tok.pos, value: false}, nil
+		case "null":
+			return &literal{at: tok.pos, value: nil}, nil
+		}
+		if p.tok.kind != tokenOp || p.tok.text != "(" {
+			return nil, p.errorf(tok.pos, "unknown name %q; write field references as {%s}", tok.text, tok.text)
+		}
+		return p.call(tok)
+	case tokenOp:
+		if tok.text == "(" {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			x, err := p.expression(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	}
+	return nil, p.errorf(tok.pos, "unexpected %s", tok)
+}
+
+// call parses the arguments of a call to the function name; the current token is "(".
+func (p *parser) call(name token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, p.errorf(name.pos, "unknown function %s", name.text)
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	n := &call{at: name.pos, name: name.text, fn: fn}
+	if p.tok.kind == tokenOp && p.tok.text == ")" {
+		return n, p.advance()
+	}
+	for {
+		arg, err := p.expression(1)
+		if err != nil {
+			return nil, err
+		}
+		n.args = append(n.args, arg)
+
+		if p.tok.kind == tokenOp && p.tok.text == "," {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+}
+
+func (p *parser) expect(op string) error {
+	if p.tok.kind != tokenOp || p.tok.text != op {
+		return p.errorf(p.tok.pos, "expected %q, found %s", op, p.tok)
+	}
+	return p.advance()
+}
+
+// advance scans the next token into p.tok.
+func (p *parser) advance() error {
+	for p.pos < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	start := p.pos
+	if start == len(p.src) {
+		p.tok = token{kind: tokenEOF, pos: start}
+		return nil
+	}
+
+	c := p.src[start]
+	switch {
+	case c == '{':
+		end := strings.IndexByte(p.src[start:], '}')
+		if end < 0 {
+			return p.errorf(start, "field reference is not closed with }")
+		}
+		id := strings.TrimSpace(p.src[start+1 : start+end])
+		if id == "" {
+			return p.errorf(start, "field reference is empty")
+		}
+		p.pos = start + end + 1
+		p.tok = token{kind: tokenRef, text: id, pos: start}
+		return nil
+	case c == '"' || c == '\'':
+		return p.scanString(c)
+	case isDigit(c) || (c == '.' && start+1 < len(p.src) && isDigit(p.src[start+1])):
+		return p.scanNumber()
+	case isLetter(c):
+		for p.pos < len(p.src) && (isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokenIdent, text: p.src[start:p.pos], pos: start}
+		return nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(p.src[start:], op) {
+			p.pos += len(op)
+			p.tok = token{kind: tokenOp, text: op, pos: start}
+			return nil
+		}
+	}
+	switch c {
+	case '=':
+		return p.errorf(start, "unexpected \"=\"; compare with ==")
+	case '&', '|':
+		return p.errorf(start, "unexpected %q; use %c%c", c, c, c)
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[start:])
+	return p.errorf(start, "unexpected character %q", r)
+}
+
+func (p *parser) scanNumber() error {
+	start := p.pos
+	digits := func() {
+		for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+			p.pos++
+		}
+	}
+	digits()
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		p.pos++
+		digits()
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		digits()
+	}
+
+	value, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		return p.errorf(start, "invalid number %q", p.src[start:p.pos])
+	}
+	p.tok = token{kind: tokenNumber, value: value, pos: start}
+	return nil
+}
+
+func (p *parser) scanString(quote byte) error {
+	start := p.pos
+	var b strings.Builder
+	for p.pos++; p.pos < len(p.src); p.pos++ {
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			p.tok = token{kind: tokenString, text: b.String(), pos: start}
+			return nil
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			switch e := p.src[p.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"', '\'':
+				b.WriteByte(e)
+			default:
+				return p.errorf(p.pos-1, "unknown escape \\%c", e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return p.errorf(start, "string is not closed with %c", quote)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+// describe names a node in error messages.
+func describe(n node) string {
+	switch n := n.(type) {
+	case *ref:
+		return "{" + n.id + "}"
+	case *call:
+		return n.name + "()"
+	case *literal:
+		return fmt.Sprintf("%v", n.value)
+	default:
+		return "expression"
+	}
+}
@@ -0,0 +1,137 @@
+package logic
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hungaikev/rootd/backend/internal/models"
+)
+
+// actionGraph maps the IDs of a workflow's actions to their positions. It is nil for
+// workflows whose actions do not route, which run in order.
+type actionGraph map[string]int
+
+func newActionGraph(actions []models.Action) actionGraph {
+	if !slices.ContainsFunc(actions, func(action models.Action) bool {
+		return action.Next != "" || action.Else != ""
+	}) {
+		return nil
+	}
+
+	graph := make(actionGraph, len(actions))
+	for i, action := range actions {
+		if _, ok := graph[action.ID]; !ok {
+			graph[action.ID] = i
+		}
+	}
+	return graph
+}
+
+// next returns the position of the action that runs after actions[i], which either
+// returned result or was skipped with a nil result, or -1 when the path ends there.
+func (g actionGraph) next(actions []models.Action, i int, result *ActionResult) (int, error) {
+	if g == nil {
+		return i + 1, nil
+	}
+
+	action := actions[i]
+	next := action.Next
+	if action.Type == models.ActionTypeCondition && result != nil {
+		if matched, _ := result.Output["matched"].(bool); !matched {
+			next = action.Else
+		}
+	}
+	if next == "" {
+		return -1, nil
+	}
+
+	j, ok := g[next]
+	if !ok {
+		return -1, fmt.Errorf("action %s: next action %q does not exist", action.ID, next)
+	}
+	return j, nil
+}
+
+// successors returns the IDs of the actions an action can continue with.
+func successors(action models.Action) []string {
+	var ids []string
+	for _, id := range []string{action.Next, action.Else} {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// validateActionGraph checks the IDs and routing of a workflow's actions. Every action
+// needs a unique ID. In a graph every reference must name an action, every action must
+// be reachable from the first one, and no path may lead back to an action on it.
+func validateActionGraph(actions []models.Action) error {
+	ids := make(map[string]int, len(actions))
+	for i, action := range actions {
+		if action.ID == "" {
+			return fmt.Errorf("action %d has no ID", i+1)
+		}
+		if _, ok := ids[action.ID]; ok {
+			return fmt.Errorf("duplicate action ID %q", action.ID)
+		}
+		ids[action.ID] = i
+	}
+
+	if newActionGraph(actions) == nil {
+		return nil
+	}
+
+	for _, action := range actions {
+		if action.Else != "" && action.Type != models.ActionTypeCondition {
+			return fmt.Errorf("action %s: only condition actions have an else branch", action.ID)
+		}
+		for _, next := range successors(action) {
+			if _, ok := ids[next]; !ok {
+				return fmt.Errorf("action %s: next action %q does not exist", action.ID, next)
+			}
+		}
+	}
+
+	// Walk depth-first from the first action. An edge to an action on the current
+	// path closes a cycle; actions never visited are unreachable.
+	const (
+		unvisited = iota
+		onPath
+		visited
+	)
+	state := make([]int, len(actions))
+	var path []string
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		state[i] = onPath
+		path = append(path, actions[i].ID)
+		for _, next := range successors(actions[i]) {
+			j := ids[next]
+			switch state[j] {
+			case onPath:
+				start := slices.Index(path, next)
+				return fmt.Errorf("actions form a cycle: %s", strings.Join(append(path[start:], next), " -> "))
+			case unvisited:
+				if err := visit(j); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		return nil
+	}
+	if err := visit(0); err != nil {
+		return err
+	}
+
+	for i, action := range actions {
+		if state[i] == unvisited {
+			return fmt.Errorf("action %s cannot be reached from the first action %s", action.ID, actions[0].ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package logic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hungaikev/rootd/backend/internal/models"
+)
+
+// routingActions is a graph that greets VIPs and everyone else differently, then
+// notifies the team:
+//
+//	check ─┬─ vip ───┬─ team
+//	       └─ basic ─┘
+func routingActions() []models.Action {
+	return []models.Action{
+		{ID: "check", Type: models.ActionTypeCondition, Conditional: &models.Conditional{FieldID: "tier", Operator: "==", Value: "vip"}, Next: "vip", Else: "basic"},
+		{ID: "basic", Type: models.ActionTypeNotification, Next: "team"},
+		{ID: "vip", Type: models.ActionTypeNotification, Next: "team"},
+		{ID: "team", Type: models.ActionTypeNotification},
+	}
+}
+
+func TestActionGraphRouting(t *testing.T) {
+	tests := []struct {
+		name  string
+		tier  string
+		calls []string
+	}{
+		{"matched", "vip", []string{"vip", "team"}},
+		{"not matched", "basic", []string{"basic", "team"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			recorder := &recordingExecutor{}
+			engine := NewExecutionEngine(env.queries, NewConditionExecutor(), recorder)
+			submission := env.submit(t, env.activeWorkflow(t, nil, withActions(routingActions()...)).ID, map[string]interface{}{"tier": tt.tier})
+
+			if err := engine.ProcessSubmission(env.ctx, submission.ID); err != nil {
+				t.Fatalf("ProcessSubmission: %v", err)
+			}
+
+			if len(recorder.calls) != len(tt.calls) {
+				t.Errorf("ran %d notifications, want %d", len(recorder.calls), len(tt.calls))
+			}
+
+			// The log holds the actions in the order they ran, numbered by step
+			runs, err := env.submissions.ListActionRuns(env.ctx, submission.ID)
+			if err != nil || len(runs) != 3 {
+				t.Fatalf("ListActionRuns = %v, %v, want 3 entries", runs, err)
+			}
+			for i, want := range append([]string{"check"}, tt.calls...) {
+				if runs[i].ActionID != want || runs[i].Step != i+1 {
+					t.Errorf("entry %d = %s step %d, want %s step %d", i, runs[i].ActionID, runs[i].Step, want, i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestActionGraphEndsPaths(t *testing.T) {
+	env := newTestEnv(t)
+	recorder := &recordingExecutor{}
+	engine := NewExecutionEngine(env.queries, NewConditionExecutor(), recorder)
+
+	// A condition without an else ends the workflow when it does not match, and an
+	// action without a next ends it after running, even when actions follow in the list
+	workflow := env.activeWorkflow(t, nil, withActions(
+		models.Action{ID: "check", Type: models.ActionTypeCondition, Conditional: &models.Conditional{FieldID: "tier", Operator: "==", Value: "vip"}, Next: "vip"},
+		models.Action{ID: "vip", Type: models.ActionTypeNotification, Next: "skipped"},
+		models.Action{ID: "skipped", Type: models.ActionTypeNotification, Conditional: &models.Conditional{FieldID: "tier", Operator: "!=", Value: "vip"}, Next: "last"},
+		models.Action{ID: "last", Type: models.ActionTypeNotification},
+	))
+
+	tests := []struct {
+		tier  string
+		calls int
+	}{
+		{"basic", 0},
+		{"vip", 2}, // A skipped action still continues with its next
+	}
+	for _, tt := range tests {
+		recorder.calls = nil
+		submission := env.submit(t, workflow.ID, map[string]interface{}{"tier": tt.tier})
+		if err := engine.ProcessSubmission(env.ctx, submission.ID); err != nil {
+			t.Fatalf("ProcessSubmission: %v", err)
+		}
+		if len(recorder.calls) != tt.calls {
+			t.Errorf("tier %s: ran %d actions, want %d", tt.tier, len(recorder.calls), tt.calls)
+		}
+	}
+}
+
+func TestValidateActionGraph(t *testing.T) {
+	notify := func(id, next string) models.Action {
+		return models.Action{ID: id, Type: models.ActionTypeNotification, Next: next}
+	}
+	condition := func(id, next, otherwise string) models.Action {
+		return models.Action{ID: id, Type: models.ActionTypeCondition, Next: next, Else: otherwise,
+			Conditional: &models.Conditional{FieldID: "tier", Operator: "==", Value: "vip"}}
+	}
+
+	tests := []struct {
+		name    string
+		actions []models.Action
+		want    string
+	}{
+		{"empty", nil, ""},
+		{"list", []models.Action{notify("a", ""), notify("b", "")}, ""},
+		{"graph", routingActions(), ""},
+		{"graph ending early", []models.Action{condition("check", "a", ""), notify("a", "")}, ""},
+		{"missing ID", []models.Action{notify("a", ""), notify("", "")}, "action 2 has no ID"},
+		{"duplicate ID", []models.Action{notify("a", ""), notify("a", "")}, `duplicate action ID "a"`},
+		{"dangling next", []models.Action{notify("a", "b")}, `action a: next action "b" does not exist`},
+		{"dangling else", []models.Action{condition("a", "", "b")}, `action a: next action "b" does not exist`},
+		{"else on an action", []models.Action{{ID: "a", Type: models.ActionTypeNotification, Else: "b"}, notify("b", "")}, "only condition actions"},
+		{"self loop", []models.Action{notify("a", "a")}, "cycle: a -> a"},
+		{"cycle", []models.Action{notify("a", "b"), condition("b", "c", "d"), notify("c", "b"), notify("d", "")}, "cycle: b -> c -> b"},
+		{"unreachable", []models.Action{notify("a", "c"), notify("b", "c"), notify("c", "")}, "action b cannot be reached from the first action a"},
+		{"unreachable cycle", []models.Action{notify("a", ""), notify("b", "c"), notify("c", "b")}, "cannot be reached"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateActionGraph(tt.actions)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("validateActionGraph = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("validateActionGraph = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowRejectsInvalidActions(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions []models.Action
+	}{
+		{"cycle", []models.Action{{ID: "a", Type: models.ActionTypeNotification, Next: "a"}}},
+		{"unsupported operator", []models.Action{{ID: "a", Type: models.ActionTypeNotification,
+			Conditional: &models.Conditional{FieldID: "tier", Operator: "~=", Value: "vip"}}}},
+		{"condition without conditional", []models.Action{{ID: "a", Type: models.ActionTypeCondition}}},
+		{"condition without field", []models.Action{{ID: "a", Type: models.ActionTypeCondition,
+			Conditional: &models.Conditional{Operator: "==", Value: "vip"}}}},
+		{"invalid expression", []models.Action{{ID: "a", Type: models.ActionTypeNotification,
+			Conditional: &models.Conditional{Expression: "{tier} = 'vip'"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			workflow := env.createWorkflow(t, nil)
+
+			_, err := env.workflows.UpdateWorkflow(env.ctx, workflow.ID, UpdateWorkflowRequest{Actions: tt.actions})
+			assertError(t, err, KindValidation, "validation_failed")
+
+			saved, err := env.workflows.GetWorkflow(env.ctx, workflow.ID)
+			if err != nil || len(saved.Actions) != 0 {
+				t.Errorf("saved actions = %v, %v, want them unchanged", saved.Actions, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// maxLoggedError is the length in bytes beyond which errors are cut in the execution log.
+const maxLoggedError = 4096
+
+// recordActionRun adds the outcome of actx.Action to the execution log of the
+// submission. Runs on an input payload have no stored submission and are not logged.
+// The log is best effort: failing to write it does not fail the action.
+func (e *engine) recordActionRun(ctx context.Context, actx *ActionContext, step int, startedAt time.Time, status models.ActionRunStatus, result *ActionResult, actionErr error) {
+	submissionID, err := uuid.Parse(actx.Submission.ID)
+	if err != nil {
+		return
+	}
+
+	params := db.CreateActionRunParams{
+		SubmissionID: pgtype.UUID{Bytes: submissionID, Valid: true},
+		ActionID:     actx.Action.ID,
+		ActionType:   string(actx.Action.Type),
+		Attempt:      int32(max(actx.Attempt, 1)),
+		Step:         int32(step),
+		Status:       string(status),
+		StartedAt:    startedAt,
+		FinishedAt:   time.Now(),
+	}
+	if actx.Run != nil {
+		if runID, err := uuid.Parse(actx.Run.ID); err == nil {
+			params.WorkflowRunID = pgtype.UUID{Bytes: runID, Valid: true}
+		}
+	}
+	if len(actx.Action.Config) > 0 && json.Valid(actx.Action.Config) {
+		params.Config = actx.Action.Config
+		if actx.Action.Type == models.ActionTypeCallWebhook {
+			params.Config = redactWebhookConfig(actx.Action.Config)
+		}
+	}
+	if result != nil && len(result.Output) > 0 {
+		if params.Output, err = json.Marshal(result.Output); err != nil {
+			params.Output = nil
+		}
+	}
+	if actionErr != nil {
+		params.Error = pgtype.Text{String: truncate(actionErr.Error(), maxLoggedError), Valid: true}
+	}
+
+	if _, err := e.queries.CreateActionRun(ctx, &params); err != nil {
+		log.Printf("engine: failed to log action %s of submission %s: %v", actx.Action.ID, actx.Submission.ID, err)
+	}
+}
+
+// completedActions returns the results of the actions that earlier attempts at the
+// execution of actx completed, read from the execution log, so that a retry does not
+// repeat their side effects. An action whose entry was not written or was already
+// pruned runs again, as do actions of runs on an input payload, which are not logged.
+func (e *engine) completedActions(ctx context.Context, actx *ActionContext) (map[string]*ActionResult, error) {
+	if actx.Attempt <= 1 {
+		return nil, nil
+	}
+	submissionID, err := uuid.Parse(actx.Submission.ID)
+	if err != nil {
+		return nil, nil
+	}
+	var runID pgtype.UUID
+	if actx.Run != nil {
+		if id, err := uuid.Parse(actx.Run.ID); err == nil {
+			runID = pgtype.UUID{Bytes: id, Valid: true}
+		}
+	}
+
+	entries, err := e.queries.ListActionRunsBySubmission(ctx, pgtype.UUID{Bytes: submissionID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the execution log: %w", err)
+	}
+
+	completed := make(map[string]*ActionResult)
+	for _, entry := range entries {
+		if entry.WorkflowRunID != runID || int(entry.Attempt) >= actx.Attempt || entry.Status != string(models.ActionRunStatusCompleted) {
+			continue
+		}
+		result := &ActionResult{}
+		json.Unmarshal(entry.Output, &result.Output)
+		completed[entry.ActionID] = result
+	}
+	return completed, nil
+}
+
+func (e *engine) PruneActionRuns(ctx context.Context, before time.Time) (int64, error) {
+	n, err := e.queries.DeleteActionRunsFinishedBefore(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune the execution log: %w", err)
+	}
+	return n, nil
+}
+
+func actionRunToModel(run db.ActionRun) *models.ActionRun {
+	var output map[string]interface{}
+	json.Unmarshal(run.Output, &output)
+
+	result := &models.ActionRun{
+		ID:           run.ID.String(),
+		SubmissionID: run.SubmissionID.String(),
+		ActionID:     run.ActionID,
+		ActionType:   models.ActionType(run.ActionType),
+		Attempt:      int(run.Attempt),
+		Step:         int(run.Step),
+		Status:       models.ActionRunStatus(run.Status),
+		Config:       json.RawMessage(run.Config),
+		Output:       output,
+		Error:        run.Error.String,
+		StartedAt:    run.StartedAt,
+		FinishedAt:   run.FinishedAt,
+	}
+	if run.WorkflowRunID.Valid {
+		result.WorkflowRunID = run.WorkflowRunID.String()
+	}
+	return result
+}
+
+// summarizeActionRuns condenses an execution log ordered by start time. An execution
+// is one processing attempt or one on-demand run; the counts are of the last one.
+func summarizeActionRuns(runs []*db.ActionRun) *models.ActionRunSummary {
+	summary := &models.ActionRunSummary{}
+	if len(runs) == 0 {
+		return summary
+	}
+
+	type execution struct {
+		run     [16]byte
+		attempt int32
+	}
+	key := func(run *db.ActionRun) execution {
+		return execution{run: run.WorkflowRunID.Bytes, attempt: run.Attempt}
+	}
+
+	seen := make(map[execution]bool)
+	for _, run := range runs {
+		seen[key(run)] = true
+	}
+	summary.Executions = len(seen)
+
+	latest := key(runs[len(runs)-1])
+	for _, run := range runs {
+		if key(run) != latest {
+			continue
+		}
+		switch models.ActionRunStatus(run.Status) {
+		case models.ActionRunStatusCompleted:
+			summary.Completed++
+		case models.ActionRunStatusFailed:
+			summary.Failed++
+			summary.LastError = run.Error.String
+		case models.ActionRunStatusSkipped:
+			summary.Skipped++
+		}
+		if summary.LastRunAt == nil || run.FinishedAt.After(*summary.LastRunAt) {
+			finishedAt := run.FinishedAt
+			summary.LastRunAt = &finishedAt
+		}
+	}
+
+	return summary
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,231 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/mail"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/hungaikev/rootd/backend/internal/queue"
+)
+
+// emailStub is a send_email executor that reports a message ID.
+type emailStub struct{}
+
+func (emailStub) Type() models.ActionType {
+	return models.ActionTypeSendEmail
+}
+
+func (emailStub) Execute(ctx context.Context, actx *ActionContext) (*ActionResult, error) {
+	return &ActionResult{Output: map[string]interface{}{"messageId": "m-1"}}, nil
+}
+
+func TestExecutionLog(t *testing.T) {
+	env := newTestEnv(t)
+	recorder := &recordingExecutor{}
+	engine := NewExecutionEngine(env.queries, emailStub{}, recorder)
+	pool := queue.NewPool(env.queries, queue.Config{MaxAttempts: 2, BaseBackoff: time.Nanosecond})
+	pool.Handle(JobProcessSubmission, engine.ProcessSubmissionJob)
+	pool.Handle(JobRunWorkflow, engine.ProcessRunJob)
+
+	workflow := env.activeWorkflow(t, nil, withActions(
+		models.Action{ID: "email", Type: models.ActionTypeSendEmail, Config: json.RawMessage(`{"to":["ops@example.com"],"body":"New submission"}`)},
+		models.Action{ID: "vip", Type: models.ActionTypeNotification, Conditional: &models.Conditional{FieldID: "tier", Operator: "==", Value: "vip"}},
+		models.Action{ID: "notify", Type: models.ActionTypeNotification},
+	))
+	submission := env.submit(t, workflow.ID, map[string]interface{}{"tier": "basic"})
+
+	// The first attempt fails on its last action and the retry succeeds without sending
+	// the email again
+	recorder.err = errors.New("smtp unavailable")
+	if claimed, err := pool.Work(env.ctx); err != nil || !claimed {
+		t.Fatalf("Work = %v, %v, want a job run", claimed, err)
+	}
+	recorder.err = nil
+	if claimed, err := pool.Work(env.ctx); err != nil || !claimed {
+		t.Fatalf("Work = %v, %v, want a job run", claimed, err)
+	}
+
+	runs, err := env.submissions.ListActionRuns(env.ctx, submission.ID)
+	if err != nil {
+		t.Fatalf("ListActionRuns: %v", err)
+	}
+
+	type entry struct {
+		action  string
+		attempt int
+		step    int
+		status  models.ActionRunStatus
+	}
+	var got []entry
+	for _, run := range runs {
+		got = append(got, entry{run.ActionID, run.Attempt, run.Step, run.Status})
+	}
+	want := []entry{
+		{"email", 1, 1, models.ActionRunStatusCompleted},
+		{"vip", 1, 2, models.ActionRunStatusSkipped},
+		{"notify", 1, 3, models.ActionRunStatusFailed},
+		{"vip", 2, 2, models.ActionRunStatusSkipped},
+		{"notify", 2, 3, models.ActionRunStatusCompleted},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("log = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	email := runs[0]
+	if string(email.Config) != `{"to":["ops@example.com"],"body":"New submission"}` || email.Output["messageId"] != "m-1" || email.Error != "" {
+		t.Errorf("email entry = %+v, want its config and output", email)
+	}
+	if email.FinishedAt.Before(email.StartedAt) || email.SubmissionID != submission.ID || email.WorkflowRunID != "" {
+		t.Errorf("email entry = %+v, want it timed and linked to the submission only", email)
+	}
+	if !strings.Contains(runs[2].Error, "smtp unavailable") {
+		t.Errorf("failed entry error = %q, want the executor's error", runs[2].Error)
+	}
+
+	detail, err := env.submissions.GetSubmission(env.ctx, submission.ID)
+	if err != nil {
+		t.Fatalf("GetSubmission: %v", err)
+	}
+	summary := detail.Runs
+	if summary == nil || summary.Executions != 2 || summary.Completed != 1 || summary.Skipped != 1 || summary.Failed != 0 || summary.LastError != "" {
+		t.Fatalf("summary = %+v, want 2 executions, the last with 1 completed and 1 skipped", summary)
+	}
+	if summary.LastRunAt == nil || !summary.LastRunAt.Equal(runs[4].FinishedAt) {
+		t.Errorf("summary last run at = %v, want %v", summary.LastRunAt, runs[4].FinishedAt)
+	}
+
+	// An on-demand run against the submission is logged as an execution of its own
+	run, err := NewRunService(env.queries).StartRun(env.ctx, workflow.ID, StartRunRequest{SubmissionID: &submission.ID, TriggeredBy: env.owner})
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	recorder.err = errors.New("webhook timed out")
+	if claimed, err := pool.Work(env.ctx); err != nil || !claimed {
+		t.Fatalf("Work = %v, %v, want the run's job run", claimed, err)
+	}
+
+	runs, err = env.submissions.ListActionRuns(env.ctx, submission.ID)
+	if err != nil {
+		t.Fatalf("ListActionRuns: %v", err)
+	}
+	if len(runs) != 8 || runs[7].WorkflowRunID != run.ID || runs[7].Attempt != 1 {
+		t.Fatalf("log has %d entries, the last %+v, want 8 with the run's last", len(runs), runs[len(runs)-1])
+	}
+	if detail, _ = env.submissions.GetSubmission(env.ctx, submission.ID); detail.Runs.Executions != 3 || detail.Runs.Failed != 1 || !strings.Contains(detail.Runs.LastError, "webhook timed out") {
+		t.Errorf("summary = %+v, want 3 executions with the run's failure", detail.Runs)
+	}
+}
+
+func TestRetrySkipsCompletedActions(t *testing.T) {
+	env := newTestEnv(t)
+	var outbox mail.Capture
+	recorder := &recordingExecutor{err: errors.New("smtp unavailable")}
+	engine := NewExecutionEngine(env.queries, NewEmailExecutor(&outbox), recorder)
+	pool := queue.NewPool(env.queries, queue.Config{MaxAttempts: 3, BaseBackoff: time.Nanosecond})
+	pool.Handle(JobProcessSubmission, engine.ProcessSubmissionJob)
+
+	workflow := env.activeWorkflow(t, nil, withActions(
+		models.Action{ID: "email", Type: models.ActionTypeSendEmail, Config: json.RawMessage(`{"to":["ops@example.com"],"subject":"New submission","body":"Hello"}`)},
+		models.Action{ID: "notify", Type: models.ActionTypeNotification},
+	))
+	submission := env.submit(t, workflow.ID, map[string]interface{}{})
+
+	// Two attempts fail on the second action, the third succeeds
+	for attempt := 1; attempt <= 3; attempt++ {
+		if attempt == 3 {
+			recorder.err = nil
+		}
+		if claimed, err := pool.Work(env.ctx); err != nil || !claimed {
+			t.Fatalf("attempt %d: Work = %v, %v, want a job run", attempt, claimed, err)
+		}
+	}
+
+	if n := len(outbox.Messages()); n != 1 {
+		t.Errorf("sent %d emails, want 1", n)
+	}
+	if len(recorder.calls) != 3 {
+		t.Errorf("second action ran %d times, want 3", len(recorder.calls))
+	}
+	detail, err := env.submissions.GetSubmission(env.ctx, submission.ID)
+	if err != nil || detail.Status != models.SubmissionStatusCompleted {
+		t.Errorf("GetSubmission = %+v, %v, want it completed", detail, err)
+	}
+}
+
+func TestListActionRunsErrors(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.submissions.ListActionRuns(env.ctx, "not-a-uuid")
+	assertError(t, err, KindNotFound, "submission_not_found")
+
+	_, err = env.submissions.ListActionRuns(env.ctx, uuid.NewString())
+	assertError(t, err, KindNotFound, "submission_not_found")
+
+	// A submission that has not been processed has an empty log
+	submission := env.submit(t, env.activeWorkflow(t, nil).ID, map[string]interface{}{})
+	runs, err := env.submissions.ListActionRuns(env.ctx, submission.ID)
+	if err != nil || len(runs) != 0 {
+		t.Errorf("ListActionRuns = %v, %v, want an empty log", runs, err)
+	}
+	detail, err := env.submissions.GetSubmission(env.ctx, submission.ID)
+	if err != nil || detail.Runs == nil || detail.Runs.Executions != 0 || detail.Runs.LastRunAt != nil {
+		t.Errorf("summary = %+v, %v, want no executions", detail.Runs, err)
+	}
+}
+
+func TestPruneActionRuns(t *testing.T) {
+	env := newTestEnv(t)
+	engine := NewExecutionEngine(env.queries, &recordingExecutor{})
+	submission := env.submit(t, env.activeWorkflow(t, nil, withActions(models.Action{ID: "notify", Type: models.ActionTypeNotification})).ID, map[string]interface{}{})
+	if err := engine.ProcessSubmission(env.ctx, submission.ID); err != nil {
+		t.Fatalf("ProcessSubmission: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		before time.Time
+		pruned int64
+	}{
+		{"within retention", time.Now().Add(-time.Hour), 0},
+		{"past retention", time.Now().Add(time.Hour), 1},
+		{"already pruned", time.Now().Add(time.Hour), 0},
+	}
+
+	for _, tt := range tests {
+		n, err := engine.PruneActionRuns(env.ctx, tt.before)
+		if err != nil || n != tt.pruned {
+			t.Errorf("%s: PruneActionRuns = %d, %v, want %d", tt.name, n, err, tt.pruned)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"truncated", 5, "trunc"},
+		{"héllo", 2, "h"}, // é is two bytes and is not split
+		{"héllo", 3, "hé"},
+	}
+
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type analyticsService struct {
+	queries db.Store
+}
+
+// NewAnalyticsService creates a new analytics service
+func NewAnalyticsService(queries db.Store) AnalyticsService {
+	return &analyticsService{
+		queries: queries,
+	}
+}
+
+func (s *analyticsService) RecordFormEvent(ctx context.Context, workflowID string, req FormEventRequest) error {
+	// Validate business rules
+	if err := s.validateFormEvent(req); err != nil {
+		return validationFailed(err)
+	}
+
+	workflowUUID, err := uuid.Parse(workflowID)
+	if err != nil {
+		return invalidID("workflow", err)
+	}
+
+	// Check the status and record the event together, so no event lands after a pause
+	return inTx(ctx, s.queries, func(q db.Querier) error {
+		workflow, err := q.GetWorkflow(ctx, pgtype.UUID{Bytes: workflowUUID, Valid: true})
+		if err != nil {
+			return dbNotFound(err, "workflow", "failed to get workflow")
+		}
+
+		if workflow.Status != string(models.WorkflowStatusActive) {
+			return Conflict(ErrWorkflowNotActive.Code, "workflow is not active and cannot record form events", nil)
+		}
+
+		params := db.CreateFormEventParams{
+			WorkflowID: workflow.ID,
+			SessionID:  pgtype.UUID{Bytes: uuid.MustParse(req.SessionID), Valid: true},
+			EventType:  string(req.Type),
+		}
+
+		// Repeated events for the same session are ignored by the database
+		if err := q.CreateFormEvent(ctx, &params); err != nil {
+			return dbError(err, "failed to record form event")
+		}
+
+		return nil
+	})
+}
+
+// Helper methods
+func (s *analyticsService) validateFormEvent(req FormEventRequest) error {
+	if req.Type != models.FormEventTypeView && req.Type != models.FormEventTypeStart {
+		return fmt.Errorf("event type must be %q or %q", models.FormEventTypeView, models.FormEventTypeStart)
+	}
+	if _, err := uuid.Parse(req.SessionID); err != nil {
+		return fmt.Errorf("session ID must be a UUID")
+	}
+	return nil
+}
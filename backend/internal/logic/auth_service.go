@@ -0,0 +1,163 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/auth"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidCredentials = Unauthenticated("invalid_credentials", "invalid email or password", nil)
+	ErrEmailTaken         = Conflict("email_taken", "email is already registered", nil)
+	ErrUnauthenticated    = Unauthenticated("unauthenticated", "authentication required", nil)
+)
+
+// minPasswordLength is the shortest password accepted at registration.
+const minPasswordLength = 8
+
+// maxPasswordLength is the longest password accepted at registration, in bytes.
+// bcrypt refuses to hash anything longer.
+const maxPasswordLength = 72
+
+// dummyPasswordHash is compared against when a login names an unknown email, so
+// that the response takes as long as for a wrong password and does not reveal
+// which emails are registered. It uses bcrypt.DefaultCost, like stored hashes.
+var dummyPasswordHash = []byte("$2a$10$LLfPFVdW2dCIMfIHkhmVhOnVyJ44OlHvbUumsijjyzNojXWQdilnC")
+
+type authService struct {
+	queries db.Querier
+	tokens  *auth.TokenManager
+}
+
+// NewAuthService creates a new auth service
+func NewAuthService(queries db.Querier, tokens *auth.TokenManager) AuthService {
+	return &authService{
+		queries: queries,
+		tokens:  tokens,
+	}
+}
+
+func (s *authService) Register(ctx context.Context, req RegisterRequest) (*models.User, error) {
+	// Validate business rules
+	if err := s.validateRegister(req); err != nil {
+		return nil, validationFailed(err)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	params := db.CreateUserParams{
+		Email:        normalizeEmail(req.Email),
+		Name:         strings.TrimSpace(req.Name),
+		PasswordHash: string(hash),
+	}
+
+	user, err := s.queries.CreateUser(ctx, &params)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrEmailTaken
+		}
+		return nil, dbError(err, "failed to create user")
+	}
+
+	return s.dbToModel(*user), nil
+}
+
+func (s *authService) Login(ctx context.Context, req LoginRequest) (*LoginResponse, error) {
+	user, err := s.queries.GetUserByEmail(ctx, normalizeEmail(req.Email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+			return nil, ErrInvalidCredentials
+		}
+		return nil, dbError(err, "failed to get user")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	model := s.dbToModel(*user)
+	token, expiresAt, err := s.tokens.Issue(model.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue token: %w", err)
+	}
+
+	return &LoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      model,
+	}, nil
+}
+
+func (s *authService) Authenticate(ctx context.Context, token string) (string, error) {
+	claims, err := s.tokens.Verify(token)
+	if err != nil {
+		return "", Unauthenticated(ErrUnauthenticated.Code, ErrUnauthenticated.Message, err)
+	}
+
+	if _, err := uuid.Parse(claims.Subject); err != nil {
+		return "", Unauthenticated(ErrUnauthenticated.Code, ErrUnauthenticated.Message, errors.New("invalid token subject"))
+	}
+
+	return claims.Subject, nil
+}
+
+func (s *authService) GetUser(ctx context.Context, id string) (*models.User, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, invalidID("user", err)
+	}
+
+	user, err := s.queries.GetUser(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return nil, dbNotFound(err, "user", "failed to get user")
+	}
+
+	return s.dbToModel(*user), nil
+}
+
+// Helper methods
+func (s *authService) validateRegister(req RegisterRequest) error {
+	if req.Email == "" {
+		return fmt.Errorf("email is required")
+	}
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return fmt.Errorf("email is invalid")
+	}
+	if len(req.Password) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	if len(req.Password) > maxPasswordLength {
+		return fmt.Errorf("password must be at most %d bytes", maxPasswordLength)
+	}
+	return nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (s *authService) dbToModel(user db.User) *models.User {
+	return &models.User{
+		ID:        uuid.UUID(user.ID.Bytes[:]).String(),
+		Email:     user.Email,
+		Name:      user.Name,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
@@ -0,0 +1,88 @@
+package logic
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hungaikev/rootd/backend/internal/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (e *testEnv) authService() AuthService {
+	return NewAuthService(e.queries, auth.NewTokenManager([]byte("test-secret"), time.Hour))
+}
+
+func TestRegister(t *testing.T) {
+	env := newTestEnv(t)
+	users := env.authService()
+
+	tests := []struct {
+		name    string
+		req     RegisterRequest
+		wantErr bool
+	}{
+		{"valid", RegisterRequest{Email: "ada@example.com", Name: "Ada", Password: "correct horse"}, false},
+		{"longest password", RegisterRequest{Email: "grace@example.com", Password: strings.Repeat("p", maxPasswordLength)}, false},
+		{"missing email", RegisterRequest{Password: "correct horse"}, true},
+		{"invalid email", RegisterRequest{Email: "ada", Password: "correct horse"}, true},
+		{"short password", RegisterRequest{Email: "alan@example.com", Password: "short"}, true},
+		{"password over 72 bytes", RegisterRequest{Email: "alan@example.com", Password: strings.Repeat("p", maxPasswordLength+1)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := users.Register(env.ctx, tt.req)
+			if tt.wantErr {
+				assertError(t, err, KindValidation, "validation_failed")
+				return
+			}
+			if err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+			if user.Email != tt.req.Email {
+				t.Errorf("email = %q, want %q", user.Email, tt.req.Email)
+			}
+		})
+	}
+
+	_, err := users.Register(env.ctx, RegisterRequest{Email: "ADA@example.com", Password: "another one"})
+	assertIs(t, err, ErrEmailTaken)
+}
+
+func TestLogin(t *testing.T) {
+	env := newTestEnv(t)
+	users := env.authService()
+
+	registered, err := users.Register(env.ctx, RegisterRequest{Email: "ada@example.com", Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	resp, err := users.Login(env.ctx, LoginRequest{Email: " ADA@example.com", Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if resp.User.ID != registered.ID {
+		t.Errorf("user = %s, want %s", resp.User.ID, registered.ID)
+	}
+	if subject, err := users.Authenticate(env.ctx, resp.Token); err != nil || subject != registered.ID {
+		t.Errorf("Authenticate = %q, %v, want %q", subject, err, registered.ID)
+	}
+
+	_, err = users.Login(env.ctx, LoginRequest{Email: "ada@example.com", Password: "wrong horse"})
+	assertIs(t, err, ErrInvalidCredentials)
+
+	_, err = users.Login(env.ctx, LoginRequest{Email: "grace@example.com", Password: "correct horse"})
+	assertIs(t, err, ErrInvalidCredentials)
+}
+
+func TestDummyPasswordHash(t *testing.T) {
+	cost, err := bcrypt.Cost(dummyPasswordHash)
+	if err != nil {
+		t.Fatalf("dummy hash: %v", err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Errorf("dummy hash cost = %d, want %d so unknown emails take as long as known ones", cost, bcrypt.DefaultCost)
+	}
+}
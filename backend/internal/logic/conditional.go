@@ -0,0 +1,86 @@
+package logic
+
+import (
+	"sync"
+
+	"github.com/hungaikev/rootd/backend/internal/expr"
+	"github.com/hungaikev/rootd/backend/internal/models"
+)
+
+// validateConditional compiles a conditional the way evaluateConditional does, so that
+// a conditional it would reject is caught when it is saved. fields holds the types of
+// the fields it may reference, or nil when they are not known.
+func validateConditional(cond *models.Conditional, fields map[string]expr.Type) error {
+	_, err := compileConditional(cond, fields)
+	return err
+}
+
+// evaluateConditional checks a conditional rule against submitted data.
+// A missing field never satisfies a comparison except "!=".
+func evaluateConditional(cond *models.Conditional, data map[string]interface{}) (bool, error) {
+	program, err := compileConditional(cond, nil)
+	if err != nil {
+		return false, err
+	}
+	return program.EvalBool(expr.Env{Data: data})
+}
+
+// compileConditional compiles the Expression of a conditional when it has one, and the
+// comparison of its field with its value otherwise.
+func compileConditional(cond *models.Conditional, fields map[string]expr.Type) (*expr.Program, error) {
+	if cond.Expression != "" {
+		return compileExpression(cond.Expression, fields)
+	}
+	return expr.Comparison(cond.FieldID, cond.Operator, cond.Value, fields)
+}
+
+// expressionCacheSize bounds the number of programs compileExpression keeps.
+const expressionCacheSize = 1024
+
+var expressionCache = struct {
+	sync.Mutex
+	programs map[string]*expr.Program
+}{programs: make(map[string]*expr.Program)}
+
+// compileExpression compiles an expression. Without field types, as when conditionals
+// and formulas are evaluated, the program is cached so each source is parsed once; the
+// types were checked when the form or workflow was saved.
+func compileExpression(source string, fields map[string]expr.Type) (*expr.Program, error) {
+	if fields != nil {
+		return expr.Compile(source, fields)
+	}
+
+	expressionCache.Lock()
+	defer expressionCache.Unlock()
+	if program, ok := expressionCache.programs[source]; ok {
+		return program, nil
+	}
+	program, err := expr.Compile(source, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(expressionCache.programs) >= expressionCacheSize {
+		clear(expressionCache.programs)
+	}
+	expressionCache.programs[source] = program
+	return program, nil
+}
+
+// conditionalData is what the conditionals of workflow actions are evaluated against:
+// the submission data, plus each output of the actions that already ran as
+// "{actionId}.{key}", e.g. "crm.status". A field with the same ID takes precedence.
+func conditionalData(actx *ActionContext) map[string]interface{} {
+	if len(actx.Results) == 0 {
+		return actx.Submission.Data
+	}
+	data := make(map[string]interface{}, len(actx.Submission.Data))
+	for actionID, result := range actx.Results {
+		for key, value := range result.Output {
+			data[actionID+"."+key] = value
+		}
+	}
+	for id, value := range actx.Submission.Data {
+		data[id] = value
+	}
+	return data
+}
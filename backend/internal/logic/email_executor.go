@@ -0,0 +1,221 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/hungaikev/rootd/backend/internal/mail"
+	"github.com/hungaikev/rootd/backend/internal/models"
+)
+
+// emailConfig is the Action.Config for send_email actions. Every field is a template
+// rendered against emailTemplateData: Body with text/template, HTML with html/template
+// and the rest with text/template. A recipient template may render to a list of
+// comma-separated addresses, or to nothing, so a field the respondent left empty
+// adds no recipient. Only the template's own text may list several addresses: in
+// recipient templates, submission data is read with field, which fails for a value
+// that holds more than one, and .Data and .Submission are not set.
+//
+//	{"to": ["{{field \"email\"}}"], "subject": "Thanks, {{field \"name\"}}", "body": "..."}
+type emailConfig struct {
+	To      []string `json:"to"`
+	Cc      []string `json:"cc"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+	HTML    string   `json:"html"`
+}
+
+// maxEmailRecipients caps the to and cc recipients of one send_email action together.
+const maxEmailRecipients = 50
+
+// emailTemplateData is what the templates of a send_email action are rendered against.
+type emailTemplateData struct {
+	Data       map[string]interface{} // The submission data, keyed by field ID.
+	Submission *models.Submission
+	Workflow   *models.Workflow
+}
+
+// emailFuncs are the functions available to email templates. field returns the value
+// of a submission field, or "" when it is missing, and works for IDs that are not
+// valid template identifiers.
+func emailFuncs(data map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"field": func(id string) interface{} {
+			if value, ok := data[id]; ok && value != nil {
+				return value
+			}
+			return ""
+		},
+	}
+}
+
+// recipientFuncs are the functions available to recipient templates: field as in
+// emailFuncs, except that a value with more than one address is an error, so that a
+// respondent cannot add recipients.
+func recipientFuncs(data map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"field": func(id string) (interface{}, error) {
+			value, ok := data[id]
+			if !ok || value == nil {
+				return "", nil
+			}
+			if parsed, err := mail.ParseAddresses(fmt.Sprint(value)); err == nil && len(parsed) > 1 {
+				return nil, fmt.Errorf("field %s holds %d addresses; a field may only add one recipient", id, len(parsed))
+			}
+			return value, nil
+		},
+	}
+}
+
+// parseEmailConfig decodes a send_email configuration and checks that its templates parse.
+func parseEmailConfig(raw json.RawMessage) (*emailConfig, error) {
+	var cfg emailConfig
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid send_email config: %w", err)
+		}
+	}
+	if len(cfg.To) == 0 {
+		return nil, fmt.Errorf("send_email needs at least one to recipient")
+	}
+	if cfg.Body == "" && cfg.HTML == "" {
+		return nil, fmt.Errorf("send_email needs a body or an html body")
+	}
+
+	// Parse every template so that syntax errors surface when the workflow is saved
+	funcs := emailFuncs(nil)
+	templates := append(append([]string{cfg.Subject, cfg.Body}, cfg.To...), cfg.Cc...)
+	for _, text := range templates {
+		if _, err := texttemplate.New("email").Funcs(funcs).Parse(text); err != nil {
+			return nil, fmt.Errorf("invalid send_email template: %w", err)
+		}
+	}
+	if _, err := htmltemplate.New("html").Funcs(funcs).Parse(cfg.HTML); err != nil {
+		return nil, fmt.Errorf("invalid send_email template: %w", err)
+	}
+	return &cfg, nil
+}
+
+// render builds the message for one submission.
+func (cfg *emailConfig) render(data emailTemplateData) (*mail.Message, error) {
+	funcs := emailFuncs(data.Data)
+	msg := &mail.Message{}
+
+	// Recipient templates read submission data only through field
+	recipients := recipientFuncs(data.Data)
+	workflow := emailTemplateData{Workflow: data.Workflow}
+	var err error
+	if msg.To, err = renderRecipients("to", cfg.To, recipients, workflow); err != nil {
+		return nil, err
+	}
+	if msg.Cc, err = renderRecipients("cc", cfg.Cc, recipients, workflow); err != nil {
+		return nil, err
+	}
+	if n := len(msg.To) + len(msg.Cc); n > maxEmailRecipients {
+		return nil, fmt.Errorf("%d recipients, more than the %d allowed", n, maxEmailRecipients)
+	}
+	if msg.Subject, err = renderText("subject", cfg.Subject, funcs, data); err != nil {
+		return nil, err
+	}
+	// A subject is a single header line
+	msg.Subject = strings.Join(strings.Fields(msg.Subject), " ")
+
+	if msg.Text, err = renderText("body", cfg.Body, funcs, data); err != nil {
+		return nil, err
+	}
+	if cfg.HTML != "" {
+		tmpl, err := htmltemplate.New("html").Funcs(funcs).Parse(cfg.HTML)
+		if err != nil {
+			return nil, fmt.Errorf("invalid html template: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render html: %w", err)
+		}
+		msg.HTML = buf.String()
+	}
+
+	return msg, nil
+}
+
+// renderRecipients renders recipient templates into a list of addresses.
+func renderRecipients(name string, templates []string, funcs map[string]interface{}, data emailTemplateData) ([]string, error) {
+	var addresses []string
+	for i, text := range templates {
+		rendered, err := renderText(fmt.Sprintf("%s[%d]", name, i), text, funcs, data)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := mail.ParseAddresses(rendered)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s address %q: %w", name, rendered, err)
+		}
+		for _, address := range parsed {
+			addresses = append(addresses, address.String())
+		}
+	}
+	return addresses, nil
+}
+
+func renderText(name, text string, funcs map[string]interface{}, data emailTemplateData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := texttemplate.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+type emailExecutor struct {
+	mailer mail.Mailer
+}
+
+// NewEmailExecutor creates an executor that renders send_email actions and delivers
+// them with mailer
+func NewEmailExecutor(mailer mail.Mailer) ActionExecutor {
+	return &emailExecutor{mailer: mailer}
+}
+
+func (x *emailExecutor) Type() models.ActionType {
+	return models.ActionTypeSendEmail
+}
+
+func (x *emailExecutor) Execute(ctx context.Context, actx *ActionContext) (*ActionResult, error) {
+	cfg, err := parseEmailConfig(actx.Action.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := cfg.render(emailTemplateData{
+		Data:       actx.Submission.Data,
+		Submission: actx.Submission,
+		Workflow:   actx.Workflow,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(msg.To) == 0 {
+		return nil, fmt.Errorf("no to recipients: every to template rendered empty")
+	}
+
+	if err := x.mailer.Send(ctx, msg); err != nil {
+		return nil, fmt.Errorf("failed to send email: %w", err)
+	}
+
+	output := map[string]interface{}{"to": msg.To, "subject": msg.Subject}
+	if len(msg.Cc) > 0 {
+		output["cc"] = msg.Cc
+	}
+	return &ActionResult{Output: output}, nil
+}
@@ -0,0 +1,180 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hungaikev/rootd/backend/internal/mail"
+	"github.com/hungaikev/rootd/backend/internal/models"
+)
+
+// emailAction gives the workflow a send_email action with config.
+func emailAction(config string) workflowOption {
+	return withActions(models.Action{ID: "confirm", Type: models.ActionTypeSendEmail, Config: json.RawMessage(config)})
+}
+
+func TestEmailExecutor(t *testing.T) {
+	env := newTestEnv(t)
+	var outbox mail.Capture
+	engine := NewExecutionEngine(env.queries, NewEmailExecutor(&outbox))
+
+	workflow := env.activeWorkflow(t, nil, emailAction(`{
+		"to": ["{{field \"email\"}}"],
+		"cc": ["ops@example.com, {{field \"manager\"}}"],
+		"subject": "Thanks,\n{{field \"name\"}}",
+		"body": "Hi {{field \"name\"}}, we received your {{.Workflow.Name}} request.",
+		"html": "<p>Hi {{field \"name\"}}</p>"
+	}`))
+	submission := env.submit(t, workflow.ID, map[string]interface{}{"name": "Ada <b>", "email": "ada@example.com"})
+
+	if err := engine.ProcessSubmission(env.ctx, submission.ID); err != nil {
+		t.Fatalf("ProcessSubmission: %v", err)
+	}
+
+	messages := outbox.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(messages))
+	}
+	msg := messages[0]
+	if strings.Join(msg.To, ",") != "<ada@example.com>" {
+		t.Errorf("to = %q, want the respondent", msg.To)
+	}
+	// The manager field is missing, so the cc template adds only the fixed address
+	if strings.Join(msg.Cc, ",") != "<ops@example.com>" {
+		t.Errorf("cc = %q, want ops only", msg.Cc)
+	}
+	if msg.Subject != "Thanks, Ada <b>" {
+		t.Errorf("subject = %q, want it on one line", msg.Subject)
+	}
+	if msg.Text != "Hi Ada <b>, we received your Intake request." {
+		t.Errorf("text = %q, want the data unescaped", msg.Text)
+	}
+	if msg.HTML != "<p>Hi Ada &lt;b&gt;</p>" {
+		t.Errorf("html = %q, want the data escaped", msg.HTML)
+	}
+
+	runs, err := env.submissions.ListActionRuns(env.ctx, submission.ID)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("ListActionRuns = %v, %v, want one entry", runs, err)
+	}
+	if output := runs[0].Output; output["subject"] != "Thanks, Ada <b>" || output["to"] == nil || output["cc"] == nil {
+		t.Errorf("logged output = %v, want the recipients and subject", output)
+	}
+}
+
+func TestEmailExecutorFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		data   map[string]interface{}
+		fail   error
+		want   string
+	}{
+		{
+			name:   "recipient field left empty",
+			config: `{"to": ["{{field \"email\"}}"], "body": "hi"}`,
+			data:   map[string]interface{}{},
+			want:   "no to recipients",
+		},
+		{
+			name:   "recipient field not an address",
+			config: `{"to": ["{{field \"email\"}}"], "body": "hi"}`,
+			data:   map[string]interface{}{"email": "ada"},
+			want:   `invalid to address "ada"`,
+		},
+		{
+			name:   "recipient field lists addresses",
+			config: `{"to": ["{{field \"email\"}}"], "body": "hi"}`,
+			data:   map[string]interface{}{"email": "ada@example.com, eve@example.com"},
+			want:   "field email holds 2 addresses",
+		},
+		{
+			name:   "recipient reads data directly",
+			config: `{"to": ["{{.Data.email}}"], "body": "hi"}`,
+			data:   map[string]interface{}{"email": "ada@example.com, eve@example.com"},
+			want:   `invalid to address "<no value>"`,
+		},
+		{
+			name:   "too many recipients",
+			config: `{"to": ["` + strings.Repeat("ops@example.com, ", maxEmailRecipients) + `{{field \"email\"}}"], "body": "hi"}`,
+			data:   map[string]interface{}{"email": "ada@example.com"},
+			want:   "51 recipients, more than the 50 allowed",
+		},
+		{
+			name:   "template fails",
+			config: `{"to": ["ada@example.com"], "body": "{{template \"missing\"}}"}`,
+			data:   map[string]interface{}{},
+			want:   "failed to render body",
+		},
+		{
+			name:   "mailer fails",
+			config: `{"to": ["ada@example.com"], "body": "hi"}`,
+			data:   map[string]interface{}{},
+			fail:   errors.New("relay refused"),
+			want:   "failed to send email: relay refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			var outbox mail.Capture
+			outbox.Fail(tt.fail)
+			engine := NewExecutionEngine(env.queries, NewEmailExecutor(&outbox))
+			submission := env.submit(t, env.activeWorkflow(t, nil, emailAction(tt.config)).ID, tt.data)
+
+			err := engine.ProcessSubmission(env.ctx, submission.ID)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ProcessSubmission = %v, want an error containing %q", err, tt.want)
+			}
+			if n := len(outbox.Messages()); n != 0 {
+				t.Errorf("sent %d messages, want none", n)
+			}
+		})
+	}
+}
+
+func TestParseEmailConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"valid", `{"to": ["{{field \"email\"}}"], "subject": "Hi", "body": "Hello"}`, ""},
+		{"html only", `{"to": ["ops@example.com"], "html": "<p>Hello</p>"}`, ""},
+		{"not an object", `["ops@example.com"]`, "invalid send_email config"},
+		{"no recipient", `{"body": "Hello"}`, "at least one to recipient"},
+		{"no body", `{"to": ["ops@example.com"]}`, "a body or an html body"},
+		{"unclosed action", `{"to": ["ops@example.com"], "body": "{{field \"name\""}`, "invalid send_email template"},
+		{"unknown function", `{"to": ["ops@example.com"], "html": "{{upper .Data.name}}"}`, "invalid send_email template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseEmailConfig(json.RawMessage(tt.config))
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("parseEmailConfig = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseEmailConfig = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowRejectsInvalidEmailConfig(t *testing.T) {
+	env := newTestEnv(t)
+	invalid := []models.Action{{ID: "confirm", Type: models.ActionTypeSendEmail, Config: json.RawMessage(`{"body": "hi"}`)}}
+
+	_, err := env.workflows.CreateWorkflow(env.ctx, CreateWorkflowRequest{Name: "Confirm", OwnerID: env.owner, Actions: invalid})
+	assertError(t, err, KindValidation, "validation_failed")
+
+	workflow := env.createWorkflow(t, nil)
+	_, err = env.workflows.UpdateWorkflow(env.ctx, workflow.ID, UpdateWorkflowRequest{Actions: invalid})
+	assertError(t, err, KindValidation, "validation_failed")
+}
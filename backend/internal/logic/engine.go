@@ -0,0 +1,416 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/hungaikev/rootd/backend/internal/queue"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ActionExecutor performs a single type of workflow action.
+// Executors are registered on the engine by the action type they handle.
+type ActionExecutor interface {
+	Type() models.ActionType
+	Execute(ctx context.Context, actx *ActionContext) (*ActionResult, error)
+}
+
+// ActionContext carries everything an executor needs to run one action.
+type ActionContext struct {
+	Workflow   *models.Workflow
+	Submission *models.Submission
+	Action     models.Action
+	Run        *models.WorkflowRun      // The on-demand run being executed, nil when processing a new submission.
+	Attempt    int                      // The attempt at processing the submission or run, starting at 1.
+	Results    map[string]*ActionResult // Results of the actions that already ran, keyed by action ID.
+}
+
+// ActionResult is the outcome of a successfully executed action.
+type ActionResult struct {
+	Output map[string]interface{} `json:"output,omitempty"` // Values produced by the action, visible to later actions.
+	Stop   bool                   `json:"stop,omitempty"`   // Skip the remaining actions and complete the submission.
+}
+
+type engine struct {
+	queries     db.Store
+	workflows   *workflowService
+	submissions *submissionService
+	runs        *runService
+
+	mu        sync.RWMutex
+	executors map[models.ActionType]ActionExecutor
+}
+
+// NewExecutionEngine creates a new execution engine with the given executors registered
+func NewExecutionEngine(queries db.Store, executors ...ActionExecutor) ExecutionEngine {
+	e := &engine{
+		queries:     queries,
+		workflows:   &workflowService{queries: queries},
+		submissions: &submissionService{queries: queries},
+		runs:        &runService{queries: queries},
+		executors:   make(map[models.ActionType]ActionExecutor),
+	}
+	for _, executor := range executors {
+		e.RegisterExecutor(executor)
+	}
+	return e
+}
+
+func (e *engine) RegisterExecutor(executor ActionExecutor) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.executors[executor.Type()] = executor
+}
+
+func (e *engine) ProcessSubmission(ctx context.Context, id string) error {
+	submissionID, err := uuid.Parse(id)
+	if err != nil {
+		return invalidID("submission", err)
+	}
+
+	submission, err := e.claimSubmission(ctx, pgtype.UUID{Bytes: submissionID, Valid: true}, models.SubmissionStatusPending)
+	if err != nil {
+		return err
+	}
+
+	return e.process(ctx, submission, 1)
+}
+
+// JobProcessSubmission is the kind of the job enqueued with every new submission.
+const JobProcessSubmission = "process_submission"
+
+// submissionJob is the payload of a process_submission job.
+type submissionJob struct {
+	SubmissionID string `json:"submissionId"`
+}
+
+// enqueueSubmission adds the job that processes a new submission. Call it in the
+// transaction that creates the submission, so neither exists without the other.
+func enqueueSubmission(ctx context.Context, q db.Querier, submissionID pgtype.UUID) error {
+	_, err := queue.Enqueue(ctx, q, JobProcessSubmission, submissionJob{SubmissionID: submissionID.String()})
+	return err
+}
+
+func (e *engine) ProcessSubmissionJob(ctx context.Context, job *queue.Job) error {
+	var payload submissionJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return queue.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+	submissionID, err := uuid.Parse(payload.SubmissionID)
+	if err != nil {
+		return queue.Permanent(fmt.Errorf("invalid submission ID: %w", err))
+	}
+
+	// A retry takes over the submission from the attempt before it, which either
+	// failed or died while processing
+	from := []models.SubmissionStatus{models.SubmissionStatusPending}
+	if job.Attempt > 1 {
+		from = append(from, models.SubmissionStatusProcessing, models.SubmissionStatusFailed)
+	}
+
+	submission, err := e.claimSubmission(ctx, pgtype.UUID{Bytes: submissionID, Valid: true}, from...)
+	if err != nil {
+		var domainErr *Error
+		if errors.As(err, &domainErr) && (domainErr.Kind == KindNotFound || domainErr.Kind == KindConflict) {
+			// Deleted, or already processed some other way
+			log.Printf("engine: skipping submission %s: %v", payload.SubmissionID, err)
+			return nil
+		}
+		return err
+	}
+
+	return e.process(ctx, submission, job.Attempt)
+}
+
+// claimSubmission marks a submission as processing if its status is one of from. The
+// check and the update run in one transaction so two callers cannot both claim it.
+func (e *engine) claimSubmission(ctx context.Context, id pgtype.UUID, from ...models.SubmissionStatus) (*db.Submission, error) {
+	var submission *db.Submission
+	err := inTx(ctx, e.queries, func(q db.Querier) error {
+		existing, err := q.GetSubmission(ctx, id)
+		if err != nil {
+			return dbNotFound(err, "submission", "failed to get submission")
+		}
+
+		if !slices.Contains(from, models.SubmissionStatus(existing.Status)) {
+			return Conflict("submission_not_pending", fmt.Sprintf("submission is %s and cannot be processed", existing.Status), nil)
+		}
+
+		submission, err = q.UpdateSubmissionStatus(ctx, &db.UpdateSubmissionStatusParams{
+			ID:     existing.ID,
+			Status: string(models.SubmissionStatusProcessing),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to mark submission as processing: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return submission, nil
+}
+
+// JobRunWorkflow is the kind of the job enqueued with every workflow run.
+const JobRunWorkflow = "run_workflow"
+
+// runJob is the payload of a run_workflow job.
+type runJob struct {
+	RunID string `json:"runId"`
+}
+
+// enqueueRun adds the job that carries out a new workflow run. Call it in the
+// transaction that creates the run, so neither exists without the other.
+func enqueueRun(ctx context.Context, q db.Querier, runID pgtype.UUID) error {
+	_, err := queue.Enqueue(ctx, q, JobRunWorkflow, runJob{RunID: runID.String()})
+	return err
+}
+
+func (e *engine) ProcessRunJob(ctx context.Context, job *queue.Job) error {
+	var payload runJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return queue.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+	runID, err := uuid.Parse(payload.RunID)
+	if err != nil {
+		return queue.Permanent(fmt.Errorf("invalid run ID: %w", err))
+	}
+
+	// A retry takes over the run from the attempt before it, which either failed
+	// or died while running
+	from := []models.WorkflowRunStatus{models.WorkflowRunStatusPending}
+	if job.Attempt > 1 {
+		from = append(from, models.WorkflowRunStatusRunning, models.WorkflowRunStatusFailed)
+	}
+
+	run, err := e.claimRun(ctx, pgtype.UUID{Bytes: runID, Valid: true}, from...)
+	if err != nil {
+		var domainErr *Error
+		if errors.As(err, &domainErr) && (domainErr.Kind == KindNotFound || domainErr.Kind == KindConflict) {
+			// Deleted with its workflow, or already finished
+			log.Printf("engine: skipping run %s: %v", payload.RunID, err)
+			return nil
+		}
+		return err
+	}
+
+	return e.processRun(ctx, run, job.Attempt)
+}
+
+// claimRun marks a workflow run as running if its status is one of from. The check
+// and the update run in one transaction so two callers cannot both claim it.
+func (e *engine) claimRun(ctx context.Context, id pgtype.UUID, from ...models.WorkflowRunStatus) (*db.WorkflowRun, error) {
+	var run *db.WorkflowRun
+	err := inTx(ctx, e.queries, func(q db.Querier) error {
+		existing, err := q.GetWorkflowRun(ctx, id)
+		if err != nil {
+			return dbNotFound(err, "run", "failed to get workflow run")
+		}
+
+		if !slices.Contains(from, models.WorkflowRunStatus(existing.Status)) {
+			return Conflict("run_not_pending", fmt.Sprintf("run is %s and cannot be started", existing.Status), nil)
+		}
+
+		if run, err = q.StartWorkflowRun(ctx, existing.ID); err != nil {
+			return fmt.Errorf("failed to mark workflow run as running: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// process runs the workflow's actions for a submission that is already marked as processing
+// and records the final status. The returned error describes why the submission failed.
+func (e *engine) process(ctx context.Context, submission *db.Submission, attempt int) error {
+	runErr := e.execute(ctx, submission, attempt)
+
+	status := models.SubmissionStatusCompleted
+	if runErr != nil {
+		status = models.SubmissionStatusFailed
+	}
+
+	_, err := e.queries.UpdateSubmissionStatus(ctx, &db.UpdateSubmissionStatusParams{
+		ID:     submission.ID,
+		Status: string(status),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark submission as %s: %w", status, err)
+	}
+
+	return runErr
+}
+
+func (e *engine) execute(ctx context.Context, submission *db.Submission, attempt int) error {
+	workflow, err := e.queries.GetWorkflow(ctx, submission.WorkflowID)
+	if err != nil {
+		return fmt.Errorf("failed to get workflow: %w", err)
+	}
+
+	return e.runActions(ctx, &ActionContext{
+		Workflow:   e.workflows.dbToModel(*workflow),
+		Submission: e.submissions.dbToModel(*submission),
+		Attempt:    attempt,
+		Results:    make(map[string]*ActionResult),
+	})
+}
+
+// processRun runs the workflow's actions for a run that is already marked as running
+// and records the outcome on the run. Submissions a run is against keep their status.
+// The returned error describes why the run failed.
+func (e *engine) processRun(ctx context.Context, run *db.WorkflowRun, attempt int) error {
+	runErr := e.executeRun(ctx, run, attempt)
+
+	params := db.FinishWorkflowRunParams{
+		ID:     run.ID,
+		Status: string(models.WorkflowRunStatusCompleted),
+	}
+	if runErr != nil {
+		params.Status = string(models.WorkflowRunStatusFailed)
+		params.Error = pgtype.Text{String: runErr.Error(), Valid: true}
+	}
+
+	if _, err := e.queries.FinishWorkflowRun(ctx, &params); err != nil {
+		return fmt.Errorf("failed to mark workflow run as %s: %w", params.Status, err)
+	}
+
+	return runErr
+}
+
+func (e *engine) executeRun(ctx context.Context, run *db.WorkflowRun, attempt int) error {
+	workflow, err := e.queries.GetWorkflow(ctx, run.WorkflowID)
+	if err != nil {
+		return fmt.Errorf("failed to get workflow: %w", err)
+	}
+
+	actx := &ActionContext{
+		Workflow: e.workflows.dbToModel(*workflow),
+		Run:      e.runs.dbToModel(*run),
+		Attempt:  attempt,
+		Results:  make(map[string]*ActionResult),
+	}
+
+	switch {
+	case run.SubmissionID.Valid:
+		submission, err := e.queries.GetSubmission(ctx, run.SubmissionID)
+		if err != nil {
+			return fmt.Errorf("failed to get submission: %w", err)
+		}
+		actx.Submission = e.submissions.dbToModel(*submission)
+	case run.Input != nil:
+		// The input stands in for a submission that was never stored
+		actx.Submission = &models.Submission{
+			WorkflowID: actx.Workflow.ID,
+			SchemaID:   actx.Workflow.SchemaID,
+			CreatedAt:  run.CreatedAt,
+			UpdatedAt:  run.CreatedAt,
+			Data:       actx.Run.Input,
+			Status:     models.SubmissionStatusProcessing,
+		}
+		if actx.Submission.Data == nil {
+			actx.Submission.Data = map[string]interface{}{}
+		}
+	default:
+		return fmt.Errorf("the submission of the run was deleted")
+	}
+
+	return e.runActions(ctx, actx)
+}
+
+// runActions executes the workflow's actions against actx.Submission, in order or
+// along the graph they form, and records each one in the submission's execution log.
+// Actions an earlier attempt completed are not executed again; their logged output
+// stands in for their result and they get no new log entry.
+func (e *engine) runActions(ctx context.Context, actx *ActionContext) error {
+	actions := actx.Workflow.Actions
+	graph := newActionGraph(actions)
+
+	completed, err := e.completedActions(ctx, actx)
+	if err != nil {
+		return err
+	}
+
+	for i, step := 0, 1; i >= 0 && i < len(actions); step++ {
+		// Graphs are checked for cycles when they are saved; this guards the engine anyway
+		if step > len(actions) {
+			return fmt.Errorf("action %s: the workflow's actions form a cycle", actions[i].ID)
+		}
+
+		action := actions[i]
+		actx.Action = action
+
+		result, done := completed[action.ID]
+		if done {
+			actx.Results[action.ID] = result
+		} else {
+			startedAt := time.Now()
+			var skipped bool
+			result, skipped, err = e.runAction(ctx, actx)
+			switch {
+			case err != nil:
+				e.recordActionRun(ctx, actx, step, startedAt, models.ActionRunStatusFailed, nil, err)
+				return err
+			case skipped:
+				e.recordActionRun(ctx, actx, step, startedAt, models.ActionRunStatusSkipped, nil, nil)
+			default:
+				e.recordActionRun(ctx, actx, step, startedAt, models.ActionRunStatusCompleted, result, nil)
+				actx.Results[action.ID] = result
+				if result.Stop {
+					return nil
+				}
+			}
+		}
+
+		if i, err = graph.next(actions, i, result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runAction executes actx.Action, unless its conditional does not match the submission
+// and the action is skipped.
+func (e *engine) runAction(ctx context.Context, actx *ActionContext) (result *ActionResult, skipped bool, err error) {
+	action := actx.Action
+
+	// Condition actions evaluate their own conditional
+	if action.Conditional != nil && action.Type != models.ActionTypeCondition {
+		ok, err := evaluateConditional(action.Conditional, conditionalData(actx))
+		if err != nil {
+			return nil, false, fmt.Errorf("action %s: invalid conditional: %w", action.ID, err)
+		}
+		if !ok {
+			return nil, true, nil
+		}
+	}
+
+	e.mu.RLock()
+	executor, ok := e.executors[action.Type]
+	e.mu.RUnlock()
+	if !ok {
+		return nil, false, fmt.Errorf("action %s: no executor registered for action type %q", action.ID, action.Type)
+	}
+
+	result, err = executor.Execute(ctx, actx)
+	if err != nil {
+		return nil, false, fmt.Errorf("action %s (%s) failed: %w", action.ID, action.Type, err)
+	}
+	if result == nil {
+		result = &ActionResult{}
+	}
+	return result, false, nil
+}
@@ -0,0 +1,150 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrorKind classifies a domain error. The API layer maps each kind to an HTTP status code.
+type ErrorKind string
+
+const (
+	KindInvalidInput    ErrorKind = "invalid_input"   // The request is malformed, e.g. an unparsable parameter.
+	KindValidation      ErrorKind = "validation"      // The request is well-formed but breaks a business rule.
+	KindNotFound        ErrorKind = "not_found"       // The resource does not exist or is not visible to the caller.
+	KindConflict        ErrorKind = "conflict"        // The request conflicts with the current state of the resource.
+	KindUnauthenticated ErrorKind = "unauthenticated" // The caller is not signed in.
+	KindForbidden       ErrorKind = "forbidden"       // The caller is signed in but may not do this.
+	KindUnavailable     ErrorKind = "unavailable"     // A dependency such as the database is unreachable.
+)
+
+// Error is a typed domain error. Code is a stable identifier that clients can
+// rely on, e.g. "workflow_not_active"; Message is meant for people.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  map[string]string // Messages per field ID for validation errors.
+	Err     error             // The underlying cause, if any.
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an *Error with the same kind and code, so that
+// errors.Is matches sentinel errors even when they were re-created with more detail.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code != "" && t.Kind == e.Kind && t.Code == e.Code
+}
+
+// InvalidInput creates an error for a malformed request
+func InvalidInput(code, message string, err error) *Error {
+	return &Error{Kind: KindInvalidInput, Code: code, Message: message, Err: err}
+}
+
+// Validation creates an error for a request that breaks a business rule
+func Validation(code, message string, err error) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Err: err}
+}
+
+// NotFound creates an error for a missing resource
+func NotFound(code, message string, err error) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message, Err: err}
+}
+
+// Conflict creates an error for a request that conflicts with the current state
+func Conflict(code, message string, err error) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message, Err: err}
+}
+
+// Unauthenticated creates an error for a request without valid credentials
+func Unauthenticated(code, message string, err error) *Error {
+	return &Error{Kind: KindUnauthenticated, Code: code, Message: message, Err: err}
+}
+
+// Forbidden creates an error for a request the caller is not allowed to make
+func Forbidden(code, message string, err error) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message, Err: err}
+}
+
+// Unavailable creates an error for a dependency that cannot be reached
+func Unavailable(code, message string, err error) *Error {
+	return &Error{Kind: KindUnavailable, Code: code, Message: message, Err: err}
+}
+
+// AsError returns the first *Error in err's chain, or nil for untyped errors.
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return nil
+}
+
+// Helper methods
+
+// invalidID is returned for path IDs that are not UUIDs. Such resources cannot exist.
+func invalidID(resource string, err error) error {
+	return NotFound(resource+"_not_found", resource+" not found", err)
+}
+
+// validationFailed wraps the result of a validate* helper.
+func validationFailed(err error) error {
+	return Validation("validation_failed", "validation failed", err)
+}
+
+// dbNotFound converts pgx.ErrNoRows into a NotFound error for resource and
+// classifies other errors with dbError.
+func dbNotFound(err error, resource, message string) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return NotFound(resource+"_not_found", resource+" not found", err)
+	}
+	return dbError(err, message)
+}
+
+// dbError wraps a database error, marking connection failures as Unavailable.
+func dbError(err error, message string) error {
+	if isUnavailable(err) {
+		return Unavailable("database_unavailable", message, err)
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+func isUnavailable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) {
+		return true
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	// Class 08 is connection exceptions, 53 insufficient resources and 57P operator intervention
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "53") || strings.HasPrefix(pgErr.Code, "57P")
+	}
+
+	return false
+}
@@ -0,0 +1,97 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/hungaikev/rootd/backend/internal/models"
+)
+
+// DefaultExecutors returns the action executors that need no external configuration.
+func DefaultExecutors() []ActionExecutor {
+	return []ActionExecutor{
+		NewNotificationExecutor(),
+		NewConditionExecutor(),
+	}
+}
+
+type notificationExecutor struct{}
+
+// NewNotificationExecutor creates an executor that writes notification actions to the server log
+func NewNotificationExecutor() ActionExecutor {
+	return &notificationExecutor{}
+}
+
+// notificationConfig is the Action.Config for notification actions.
+type notificationConfig struct {
+	Message string `json:"message"`
+}
+
+func (x *notificationExecutor) Type() models.ActionType {
+	return models.ActionTypeNotification
+}
+
+func (x *notificationExecutor) Execute(ctx context.Context, actx *ActionContext) (*ActionResult, error) {
+	var cfg notificationConfig
+	if len(actx.Action.Config) > 0 {
+		if err := json.Unmarshal(actx.Action.Config, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid notification config: %w", err)
+		}
+	}
+
+	message := cfg.Message
+	if message == "" {
+		message = fmt.Sprintf("new submission %s", actx.Submission.ID)
+		if actx.Run != nil && actx.Run.SubmissionID == "" {
+			message = fmt.Sprintf("manual run %s", actx.Run.ID)
+		}
+	}
+
+	log.Printf("notification: workflow %s: %s", actx.Workflow.ID, message)
+
+	return &ActionResult{Output: map[string]interface{}{"message": message}}, nil
+}
+
+type conditionExecutor struct{}
+
+// NewConditionExecutor creates an executor that evaluates condition actions. One that does not
+// match stops the workflow, unless it has an Else action to continue with
+func NewConditionExecutor() ActionExecutor {
+	return &conditionExecutor{}
+}
+
+func (x *conditionExecutor) Type() models.ActionType {
+	return models.ActionTypeCondition
+}
+
+func (x *conditionExecutor) Execute(ctx context.Context, actx *ActionContext) (*ActionResult, error) {
+	cond, err := actionConditional(actx.Action)
+	if err != nil {
+		return nil, err
+	}
+
+	matched, err := evaluateConditional(cond, conditionalData(actx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ActionResult{
+		Output: map[string]interface{}{"matched": matched},
+		Stop:   !matched && actx.Action.Else == "",
+	}, nil
+}
+
+// actionConditional returns the conditional a condition action evaluates: its
+// Conditional, or else its Config.
+func actionConditional(action models.Action) (*models.Conditional, error) {
+	if action.Conditional != nil {
+		return action.Conditional, nil
+	}
+	var cfg models.Conditional
+	if err := json.Unmarshal(action.Config, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid condition config: %w", err)
+	}
+	return &cfg, nil
+}
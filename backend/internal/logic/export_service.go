@@ -0,0 +1,167 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/export"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type exportService struct {
+	queries     db.Store
+	submissions *submissionService
+}
+
+// NewExportService creates a new export service
+func NewExportService(queries db.Store) ExportService {
+	return &exportService{
+		queries:     queries,
+		submissions: &submissionService{queries: queries},
+	}
+}
+
+func (s *exportService) ExportSubmissions(ctx context.Context, workflowID string, format export.Format, w io.Writer) error {
+	workflowUUID, err := uuid.Parse(workflowID)
+	if err != nil {
+		return invalidID("workflow", err)
+	}
+
+	workflow, err := s.queries.GetWorkflow(ctx, pgtype.UUID{Bytes: workflowUUID, Valid: true})
+	if err != nil {
+		return dbNotFound(err, "workflow", "failed to get workflow")
+	}
+
+	var fields []models.Field
+	if workflow.SchemaID.Valid {
+		if fields, err = s.exportFields(ctx, workflow.SchemaID); err != nil {
+			return err
+		}
+	}
+
+	writer, err := export.NewWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	if err := writer.WriteHeader(exportColumns(fields)); err != nil {
+		return fmt.Errorf("failed to write export header: %w", err)
+	}
+
+	row := make([]interface{}, 0, len(fields)+7)
+	err = s.streamSubmissions(ctx, workflow.ID, func(record *db.Submission) error {
+		submission := s.submissions.dbToModel(*record)
+
+		row = append(row[:0],
+			submission.ID,
+			string(submission.Status),
+			submission.CreatedAt.UTC().Format(time.RFC3339),
+		)
+		for _, field := range fields {
+			row = append(row, submission.Data[field.ID])
+		}
+		row = append(row,
+			submission.Metadata.IPAddress,
+			submission.Metadata.UserAgent,
+			submission.Metadata.Referrer,
+			submission.Metadata.SessionID,
+		)
+
+		return writer.WriteRow(row)
+	})
+	if err != nil {
+		return dbError(err, "failed to export submissions")
+	}
+
+	return writer.Close()
+}
+
+// exportPageSize is the number of rows fetched per query when the querier cannot stream.
+const exportPageSize = 500
+
+// streamSubmissions calls fn for every submission of a workflow, oldest first. It uses the
+// querier's streaming query when available and otherwise walks the rows page by page.
+func (s *exportService) streamSubmissions(ctx context.Context, workflowID pgtype.UUID, fn func(*db.Submission) error) error {
+	if streamer, ok := s.queries.(db.SubmissionStreamer); ok {
+		return streamer.StreamSubmissions(ctx, workflowID, fn)
+	}
+
+	params := db.ListSubmissionsPageAscParams{
+		WorkflowID: workflowID,
+		PageLimit:  exportPageSize,
+	}
+	for {
+		page, err := s.queries.ListSubmissionsPageAsc(ctx, &params)
+		if err != nil {
+			return err
+		}
+
+		for _, submission := range page {
+			if err := fn(submission); err != nil {
+				return err
+			}
+		}
+
+		if len(page) < exportPageSize {
+			return nil
+		}
+
+		last := page[len(page)-1]
+		params.CursorCreatedAt = pgtype.Timestamptz{Time: last.CreatedAt, Valid: true}
+		params.CursorID = last.ID
+	}
+}
+
+// exportFields returns the form fields that hold submitted values, in schema order.
+func (s *exportService) exportFields(ctx context.Context, formID pgtype.UUID) ([]models.Field, error) {
+	form, err := s.queries.GetForm(ctx, formID)
+	if err != nil {
+		return nil, dbError(err, "failed to get form")
+	}
+
+	var schema models.FormSchema
+	if err := json.Unmarshal(form.Schema, &schema); err != nil {
+		return nil, fmt.Errorf("invalid form schema: %w", err)
+	}
+
+	fields := make([]models.Field, 0, len(schema.Fields))
+	for _, field := range schema.Fields {
+		if field.ID != "" && !displayFieldTypes[field.Type] {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields, nil
+}
+
+// exportColumns names the export columns. Field labels that repeat, or collide with
+// the fixed columns, are suffixed with the field ID so every column name is unique.
+func exportColumns(fields []models.Field) []string {
+	columns := []string{"id", "status", "createdAt"}
+	metadata := []string{"ipAddress", "userAgent", "referrer", "sessionId"}
+
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string{}, columns...), metadata...) {
+		seen[name] = true
+	}
+
+	for _, field := range fields {
+		name := field.Label
+		if name == "" {
+			name = field.ID
+		}
+		if seen[name] {
+			name = fmt.Sprintf("%s (%s)", name, field.ID)
+		}
+		seen[name] = true
+		columns = append(columns, name)
+	}
+
+	return append(columns, metadata...)
+}
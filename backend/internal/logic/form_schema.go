@@ -0,0 +1,261 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hungaikev/rootd/backend/internal/expr"
+	"github.com/hungaikev/rootd/backend/internal/models"
+)
+
+// fieldExprTypes maps a field type to the type its values have in expressions.
+// Display fields have no value and other types are not listed, so they are expr.Any.
+var fieldExprTypes = map[string]expr.Type{
+	"text":        expr.String,
+	"textarea":    expr.String,
+	"email":       expr.String,
+	"url":         expr.String,
+	"phone":       expr.String,
+	"select":      expr.String,
+	"dropdown":    expr.String,
+	"radio":       expr.String,
+	"time":        expr.String,
+	"number":      expr.Number,
+	"slider":      expr.Number,
+	"rating":      expr.Number,
+	"rank":        expr.List,
+	"date":        expr.Date,
+	"datetime":    expr.Date,
+	"calculation": expr.Any, // Whatever its formula returns.
+}
+
+// fieldTypes returns the expression types of the fields of a schema, by ID.
+func fieldTypes(schema *models.FormSchema) map[string]expr.Type {
+	types := make(map[string]expr.Type, len(schema.Fields))
+	for _, field := range schema.Fields {
+		if field.ID == "" || displayFieldTypes[field.Type] {
+			continue
+		}
+		switch typ, ok := fieldExprTypes[field.Type]; {
+		case field.Type == "checkbox" && len(field.Options) > 0:
+			types[field.ID] = expr.List
+		case field.Type == "checkbox":
+			types[field.ID] = expr.Bool
+		case ok:
+			types[field.ID] = typ
+		default:
+			types[field.ID] = expr.Any
+		}
+	}
+	return types
+}
+
+// validateFormSchema checks that a form schema decodes, that the conditionals and
+// formulas of its fields compile against the fields of the form, and that no
+// calculation depends on its own value.
+func validateFormSchema(raw map[string]interface{}) error {
+	schema, err := decodeFormSchema(raw)
+	if err != nil {
+		return err
+	}
+
+	types := fieldTypes(schema)
+	if _, err := calculations(schema, types); err != nil {
+		return err
+	}
+	for _, field := range schema.Fields {
+		if field.Conditional != nil {
+			if err := validateConditional(field.Conditional, types); err != nil {
+				return fmt.Errorf("field %s: conditional: %w", field.ID, err)
+			}
+		}
+		if field.Formula != "" && field.Type != "calculation" {
+			if _, err := expr.Compile(field.Formula, types); err != nil {
+				return fmt.Errorf("field %s: formula: %w", field.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// calculation is a calculation field and its compiled formula.
+type calculation struct {
+	field   models.Field
+	program *expr.Program
+}
+
+// calculations compiles the formulas of a schema's calculation fields and orders them
+// so that each comes after the calculations it references. types holds the expression
+// types of the schema's fields; the entry of each calculation is set to the type of its
+// formula. A formula that depends on its own value is an error, and so is one that does
+// not compile; the error returned is that of the first such field.
+func calculations(schema *models.FormSchema, types map[string]expr.Type) ([]calculation, error) {
+	calcs, broken := compileCalculations(schema, types)
+	for _, field := range schema.Fields {
+		if err, ok := broken[field.ID]; ok {
+			return nil, err
+		}
+	}
+	return calcs, nil
+}
+
+// compileCalculations is calculations for schemas that may be broken: a calculation
+// without a formula, whose formula does not compile or that is part of a cycle is left
+// out, with its error in broken by field ID. Calculations referencing it see no value.
+func compileCalculations(schema *models.FormSchema, types map[string]expr.Type) (calcs []calculation, broken map[string]error) {
+	broken = make(map[string]error)
+	fields := make(map[string]models.Field)
+	var ids []string
+	for _, field := range schema.Fields {
+		if field.Type != "calculation" || field.ID == "" {
+			continue
+		}
+		if field.Formula == "" {
+			broken[field.ID] = fmt.Errorf("field %s: calculation has no formula", field.ID)
+			continue
+		}
+		fields[field.ID] = field
+		ids = append(ids, field.ID)
+	}
+
+	// The references of each formula, before the types of the calculations are known
+	deps := make(map[string][]string, len(ids))
+	for _, id := range ids {
+		program, err := compileExpression(fields[id].Formula, nil)
+		if err != nil {
+			broken[id] = fmt.Errorf("field %s: formula: %w", id, err)
+			continue
+		}
+		deps[id] = program.References()
+	}
+
+	// Depth-first search; a calculation reached again while still on the path closes
+	// a cycle, which breaks every calculation on it
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := make(map[string]int, len(ids))
+	var order []string
+	var path []string
+	var visit func(id string)
+	visit = func(id string) {
+		switch state[id] {
+		case onPath:
+			start := slices.Index(path, id)
+			cycle := append(slices.Clone(path[start:]), id)
+			err := fmt.Errorf("calculation fields form a cycle: %s", strings.Join(cycle, " -> "))
+			for _, member := range path[start:] {
+				if _, ok := broken[member]; !ok {
+					broken[member] = err
+				}
+			}
+			return
+		case done:
+			return
+		}
+
+		state[id] = onPath
+		path = append(path, id)
+		for _, dep := range deps[id] {
+			if _, ok := fields[dep]; ok {
+				visit(dep)
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+		if _, ok := broken[id]; !ok {
+			order = append(order, id)
+		}
+	}
+	for _, id := range ids {
+		visit(id)
+	}
+
+	for _, id := range order {
+		program, err := expr.Compile(fields[id].Formula, types)
+		if err != nil {
+			broken[id] = fmt.Errorf("field %s: formula: %w", id, err)
+			continue
+		}
+		types[id] = program.Type()
+		calcs = append(calcs, calculation{field: fields[id], program: program})
+	}
+	return calcs, broken
+}
+
+// applyCalculations returns a copy of submitted data in which the value of every
+// calculation field is computed from its formula; values the client sent for them are
+// dropped. A formula that cannot be evaluated leaves its field empty and is reported
+// as ErrInvalidSubmission, along with the data. Calculations that are broken in the
+// schema itself, which forms saved before formulas were checked may have, are left
+// empty too and returned in broken, so that they do not stop the form's intake.
+func applyCalculations(schema *models.FormSchema, data map[string]interface{}) (result map[string]interface{}, broken map[string]error, err error) {
+	calcs, broken := compileCalculations(schema, fieldTypes(schema))
+	if len(calcs) == 0 && len(broken) == 0 {
+		return data, nil, nil
+	}
+
+	result = maps.Clone(data)
+	for _, field := range schema.Fields {
+		if field.Type == "calculation" {
+			delete(result, field.ID)
+		}
+	}
+
+	errs := make(map[string]string)
+	env := expr.Env{Data: result, Now: time.Now()}
+	for _, calc := range calcs {
+		value, err := calc.program.Eval(env)
+		if err == nil {
+			value, err = calculatedValue(value)
+		}
+		if err != nil {
+			errs[calc.field.ID] = "cannot be calculated: " + err.Error()
+			continue
+		}
+		if value != nil {
+			result[calc.field.ID] = value
+		}
+	}
+
+	if len(errs) > 0 {
+		return result, broken, invalidSubmission(errs)
+	}
+	return result, broken, nil
+}
+
+// calculatedValue converts the result of a formula to the value stored in submission data.
+func calculatedValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("result is not a finite number")
+		}
+	case time.Time:
+		if v.Equal(v.Truncate(24 * time.Hour)) {
+			return v.Format(time.DateOnly), nil
+		}
+		return v.Format(time.RFC3339), nil
+	}
+	return value, nil
+}
+
+// decodeFormSchema converts a schema as sent in requests to a models.FormSchema.
+func decodeFormSchema(raw map[string]interface{}) (*models.FormSchema, error) {
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid form schema: %w", err)
+	}
+	var schema models.FormSchema
+	if err := json.Unmarshal(encoded, &schema); err != nil {
+		return nil, fmt.Errorf("invalid form schema: %w", err)
+	}
+	return &schema, nil
+}
@@ -11,12 +11,19 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// ErrFormInUse is returned when deleting a form that workflows still reference.
+var ErrFormInUse = Conflict("form_in_use", "form is referenced by one or more workflows", nil)
+
+// ErrInvalidFormSchema is returned when data is checked against a stored form schema
+// that does not decode. Schemas are validated when saved, so the form needs fixing.
+var ErrInvalidFormSchema = Conflict("invalid_form_schema", "the form's schema is invalid and must be saved again", nil)
+
 type formService struct {
-	queries *db.Queries
+	queries db.Store
 }
 
 // NewFormService creates a new form service
-func NewFormService(queries *db.Queries) FormService {
+func NewFormService(queries db.Store) FormService {
 	return &formService{
 		queries: queries,
 	}
@@ -25,13 +32,16 @@ func NewFormService(queries *db.Queries) FormService {
 func (s *formService) CreateForm(ctx context.Context, req CreateFormRequest) (*models.Form, error) {
 	// Validate business rules
 	if err := s.validateCreateForm(req); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return nil, validationFailed(err)
 	}
 
 	// Convert request to database params
 	schema, _ := json.Marshal(req.Schema)
 
-	ownerUUID := uuid.MustParse(req.OwnerID)
+	ownerUUID, err := uuid.Parse(req.OwnerID)
+	if err != nil {
+		return nil, validationFailed(fmt.Errorf("owner ID must be a UUID"))
+	}
 	params := db.CreateFormParams{
 		Name:        req.Name,
 		Description: pgtype.Text{String: req.Description, Valid: req.Description != ""},
@@ -42,7 +52,7 @@ func (s *formService) CreateForm(ctx context.Context, req CreateFormRequest) (*m
 	// Create form in database
 	form, err := s.queries.CreateForm(ctx, &params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create form: %w", err)
+		return nil, dbError(err, "failed to create form")
 	}
 
 	// Convert database model to business model
@@ -52,12 +62,12 @@ func (s *formService) CreateForm(ctx context.Context, req CreateFormRequest) (*m
 func (s *formService) GetForm(ctx context.Context, id string) (*models.Form, error) {
 	formID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid form ID: %w", err)
+		return nil, invalidID("form", err)
 	}
 
 	form, err := s.queries.GetForm(ctx, pgtype.UUID{Bytes: formID, Valid: true})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get form: %w", err)
+		return nil, dbNotFound(err, "form", "failed to get form")
 	}
 
 	return s.dbToModel(*form), nil
@@ -71,7 +81,7 @@ func (s *formService) ListForms(ctx context.Context, ownerID string) ([]*models.
 
 	forms, err := s.queries.ListForms(ctx, pgtype.UUID{Bytes: ownerUUID, Valid: true})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list forms: %w", err)
+		return nil, dbError(err, "failed to list forms")
 	}
 
 	result := make([]*models.Form, len(forms))
@@ -85,43 +95,54 @@ func (s *formService) ListForms(ctx context.Context, ownerID string) ([]*models.
 func (s *formService) UpdateForm(ctx context.Context, id string, req UpdateFormRequest) (*models.Form, error) {
 	formID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid form ID: %w", err)
-	}
-
-	// Get existing form
-	existing, err := s.queries.GetForm(ctx, pgtype.UUID{Bytes: formID, Valid: true})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get existing form: %w", err)
-	}
-
-	// Prepare update params
-	params := db.UpdateFormParams{
-		ID: pgtype.UUID{Bytes: formID, Valid: true},
+		return nil, invalidID("form", err)
 	}
-
-	if req.Name != nil {
-		params.Name = *req.Name
-	} else {
-		params.Name = existing.Name
-	}
-
-	if req.Description != nil {
-		params.Description = pgtype.Text{String: *req.Description, Valid: true}
-	} else {
-		params.Description = existing.Description
-	}
-
 	if req.Schema != nil {
-		schema, _ := json.Marshal(req.Schema)
-		params.Schema = schema
-	} else {
-		params.Schema = existing.Schema
-	}
-
-	// Update form in database
-	form, err := s.queries.UpdateForm(ctx, &params)
+		if err := validateFormSchema(req.Schema); err != nil {
+			return nil, validationFailed(err)
+		}
+	}
+
+	// Read and update in one transaction so concurrent partial updates are not lost
+	var form *db.Form
+	err = inTx(ctx, s.queries, func(q db.Querier) error {
+		existing, err := q.GetForm(ctx, pgtype.UUID{Bytes: formID, Valid: true})
+		if err != nil {
+			return dbNotFound(err, "form", "failed to get existing form")
+		}
+
+		// Prepare update params
+		params := db.UpdateFormParams{
+			ID: pgtype.UUID{Bytes: formID, Valid: true},
+		}
+
+		if req.Name != nil {
+			params.Name = *req.Name
+		} else {
+			params.Name = existing.Name
+		}
+
+		if req.Description != nil {
+			params.Description = pgtype.Text{String: *req.Description, Valid: true}
+		} else {
+			params.Description = existing.Description
+		}
+
+		if req.Schema != nil {
+			schema, _ := json.Marshal(req.Schema)
+			params.Schema = schema
+		} else {
+			params.Schema = existing.Schema
+		}
+
+		// Update form in database
+		if form, err = q.UpdateForm(ctx, &params); err != nil {
+			return dbError(err, "failed to update form")
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update form: %w", err)
+		return nil, err
 	}
 
 	return s.dbToModel(*form), nil
@@ -130,14 +151,27 @@ func (s *formService) UpdateForm(ctx context.Context, id string, req UpdateFormR
 func (s *formService) DeleteForm(ctx context.Context, id string) error {
 	formID, err := uuid.Parse(id)
 	if err != nil {
-		return fmt.Errorf("invalid form ID: %w", err)
+		return invalidID("form", err)
 	}
 
-	// Check if form is being used by any workflows
-	// This would require a query to check workflow references
-	// For now, we'll allow deletion
+	// Business rule: Forms referenced by a workflow cannot be deleted. Counting and
+	// deleting in one transaction keeps a new reference from appearing in between.
+	return inTx(ctx, s.queries, func(q db.Querier) error {
+		count, err := q.CountWorkflowsBySchema(ctx, pgtype.UUID{Bytes: formID, Valid: true})
+		if err != nil {
+			return dbError(err, "failed to check form references")
+		}
+
+		if count > 0 {
+			return ErrFormInUse
+		}
+
+		if err := q.DeleteForm(ctx, pgtype.UUID{Bytes: formID, Valid: true}); err != nil {
+			return dbError(err, "failed to delete form")
+		}
 
-	return s.queries.DeleteForm(ctx, pgtype.UUID{Bytes: formID, Valid: true})
+		return nil
+	})
 }
 
 // Helper methods
@@ -151,7 +185,7 @@ func (s *formService) validateCreateForm(req CreateFormRequest) error {
 	if req.Schema == nil {
 		return fmt.Errorf("form schema is required")
 	}
-	return nil
+	return validateFormSchema(req.Schema)
 }
 
 func (s *formService) dbToModel(form db.Form) *models.Form {
@@ -0,0 +1,167 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/models"
+)
+
+func TestCreateForm(t *testing.T) {
+	env := newTestEnv(t)
+	schema := schemaMap(t, models.FormSchema{Fields: []models.Field{{ID: "name", Type: "text", Label: "Name"}}})
+
+	tests := []struct {
+		name    string
+		req     CreateFormRequest
+		wantErr bool
+	}{
+		{"valid", CreateFormRequest{Name: "Contact", Description: "Say hi", Schema: schema, OwnerID: env.owner}, false},
+		{"missing name", CreateFormRequest{Schema: schema, OwnerID: env.owner}, true},
+		{"missing owner", CreateFormRequest{Name: "Contact", Schema: schema}, true},
+		{"missing schema", CreateFormRequest{Name: "Contact", OwnerID: env.owner}, true},
+		{"owner not a UUID", CreateFormRequest{Name: "Contact", Schema: schema, OwnerID: "alice"}, true},
+		{"invalid schema", CreateFormRequest{Name: "Contact", Schema: map[string]interface{}{"fields": "name"}, OwnerID: env.owner}, true},
+		{"invalid formula", CreateFormRequest{Name: "Contact", Schema: schemaMap(t, models.FormSchema{Fields: []models.Field{
+			{ID: "total", Type: "calculation", Formula: "{name} * 2"},
+			{ID: "name", Type: "text"},
+		}}), OwnerID: env.owner}, true},
+		{"circular formulas", CreateFormRequest{Name: "Contact", Schema: schemaMap(t, models.FormSchema{Fields: []models.Field{
+			{ID: "a", Type: "calculation", Formula: "{b} + 1"},
+			{ID: "b", Type: "calculation", Formula: "{a} * 2"},
+		}}), OwnerID: env.owner}, true},
+		{"calculation without formula", CreateFormRequest{Name: "Contact", Schema: schemaMap(t, models.FormSchema{Fields: []models.Field{
+			{ID: "a", Type: "calculation"},
+		}}), OwnerID: env.owner}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form, err := env.forms.CreateForm(env.ctx, tt.req)
+			if tt.wantErr {
+				assertError(t, err, KindValidation, "validation_failed")
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateForm: %v", err)
+			}
+
+			if form.Name != tt.req.Name || form.Description != tt.req.Description || form.OwnerID != tt.req.OwnerID {
+				t.Errorf("got %+v, want fields of %+v", form, tt.req)
+			}
+			if _, ok := form.Schema["fields"]; !ok {
+				t.Errorf("schema = %v, want the submitted fields", form.Schema)
+			}
+			if !form.CreatedAt.Equal(form.UpdatedAt) {
+				t.Errorf("created_at %v and updated_at %v differ on insert", form.CreatedAt, form.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestGetForm(t *testing.T) {
+	env := newTestEnv(t)
+	created := env.createForm(t)
+
+	form, err := env.forms.GetForm(env.ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetForm: %v", err)
+	}
+	if form.ID != created.ID {
+		t.Errorf("ID = %s, want %s", form.ID, created.ID)
+	}
+
+	_, err = env.forms.GetForm(env.ctx, uuid.NewString())
+	assertError(t, err, KindNotFound, "form_not_found")
+
+	_, err = env.forms.GetForm(env.ctx, "not-a-uuid")
+	assertError(t, err, KindNotFound, "form_not_found")
+}
+
+func TestListForms(t *testing.T) {
+	env := newTestEnv(t)
+	first := env.createForm(t)
+	second := env.createForm(t)
+
+	_, err := env.forms.CreateForm(env.ctx, CreateFormRequest{Name: "Other", Schema: map[string]interface{}{}, OwnerID: uuid.NewString()})
+	if err != nil {
+		t.Fatalf("CreateForm: %v", err)
+	}
+
+	forms, err := env.forms.ListForms(env.ctx, env.owner)
+	if err != nil {
+		t.Fatalf("ListForms: %v", err)
+	}
+	if len(forms) != 2 {
+		t.Fatalf("got %d forms, want 2", len(forms))
+	}
+	if forms[0].ID != second.ID || forms[1].ID != first.ID {
+		t.Errorf("forms are not ordered newest first")
+	}
+
+	if _, err := env.forms.ListForms(env.ctx, "alice"); err == nil {
+		t.Error("expected an error for an invalid owner ID")
+	}
+}
+
+func TestUpdateForm(t *testing.T) {
+	env := newTestEnv(t)
+	created := env.createForm(t, models.Field{ID: "name", Type: "text"})
+
+	updated, err := env.forms.UpdateForm(env.ctx, created.ID, UpdateFormRequest{Description: ptr("Updated")})
+	if err != nil {
+		t.Fatalf("UpdateForm: %v", err)
+	}
+	if updated.Name != created.Name {
+		t.Errorf("name = %q, want unchanged %q", updated.Name, created.Name)
+	}
+	if updated.Description != "Updated" {
+		t.Errorf("description = %q, want %q", updated.Description, "Updated")
+	}
+	if len(updated.Schema["fields"].([]interface{})) != 1 {
+		t.Errorf("schema = %v, want unchanged", updated.Schema)
+	}
+	if !updated.UpdatedAt.After(created.UpdatedAt) || !updated.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("timestamps = %v/%v, want only updated_at bumped", updated.CreatedAt, updated.UpdatedAt)
+	}
+
+	_, err = env.forms.UpdateForm(env.ctx, uuid.NewString(), UpdateFormRequest{Name: ptr("Renamed")})
+	assertError(t, err, KindNotFound, "form_not_found")
+
+	invalid := schemaMap(t, models.FormSchema{Fields: []models.Field{
+		{ID: "name", Type: "text", Conditional: &models.Conditional{Expression: "{missing} == 'x'"}},
+	}})
+	_, err = env.forms.UpdateForm(env.ctx, created.ID, UpdateFormRequest{Schema: invalid})
+	assertError(t, err, KindValidation, "validation_failed")
+}
+
+func TestDeleteForm(t *testing.T) {
+	env := newTestEnv(t)
+
+	t.Run("unreferenced", func(t *testing.T) {
+		form := env.createForm(t)
+		if err := env.forms.DeleteForm(env.ctx, form.ID); err != nil {
+			t.Fatalf("DeleteForm: %v", err)
+		}
+
+		_, err := env.forms.GetForm(env.ctx, form.ID)
+		assertError(t, err, KindNotFound, "form_not_found")
+	})
+
+	t.Run("referenced by a workflow", func(t *testing.T) {
+		form := env.createForm(t)
+		env.createWorkflow(t, &form.ID)
+
+		err := env.forms.DeleteForm(env.ctx, form.ID)
+		assertIs(t, err, ErrFormInUse)
+
+		if _, err := env.forms.GetForm(env.ctx, form.ID); err != nil {
+			t.Errorf("form was deleted: %v", err)
+		}
+	})
+
+	t.Run("invalid ID", func(t *testing.T) {
+		err := env.forms.DeleteForm(env.ctx, "not-a-uuid")
+		assertError(t, err, KindNotFound, "form_not_found")
+	})
+}
@@ -0,0 +1,347 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hungaikev/rootd/backend/internal/models"
+)
+
+// ErrInvalidSubmission is returned when submission data does not satisfy the
+// linked form schema. The returned error holds one message per failing field ID.
+var ErrInvalidSubmission = Validation("invalid_submission", "submission data is invalid", nil)
+
+func invalidSubmission(fields map[string]string) error {
+	ids := make([]string, 0, len(fields))
+	for id := range fields {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = id + ": " + fields[id]
+	}
+
+	err := Validation(ErrInvalidSubmission.Code, ErrInvalidSubmission.Message, errors.New(strings.Join(parts, "; ")))
+	err.Fields = fields
+	return err
+}
+
+// FieldValidator checks a submitted value against a field of one type.
+// It is only called for values that are present; required checks happen before.
+type FieldValidator interface {
+	Validate(field models.Field, value interface{}) error
+}
+
+// FieldValidatorFunc adapts a function to the FieldValidator interface.
+type FieldValidatorFunc func(field models.Field, value interface{}) error
+
+func (f FieldValidatorFunc) Validate(field models.Field, value interface{}) error {
+	return f(field, value)
+}
+
+// fieldValidators maps a field type to its validator. Types without an entry
+// only get the generic length and pattern rules.
+var fieldValidators = map[string]FieldValidator{
+	"text":     FieldValidatorFunc(validateText),
+	"textarea": FieldValidatorFunc(validateText),
+	"email":    FieldValidatorFunc(validateEmail),
+	"url":      FieldValidatorFunc(validateURL),
+	"phone":    FieldValidatorFunc(validatePhone),
+	"number":   FieldValidatorFunc(validateNumber),
+	"slider":   FieldValidatorFunc(validateNumber),
+	"rating":   FieldValidatorFunc(validateRating),
+	"select":   FieldValidatorFunc(validateChoice),
+	"dropdown": FieldValidatorFunc(validateChoice),
+	"radio":    FieldValidatorFunc(validateChoice),
+	"checkbox": FieldValidatorFunc(validateCheckbox),
+	"rank":     FieldValidatorFunc(validateRank),
+	"date":     FieldValidatorFunc(validateDate),
+	"time":     FieldValidatorFunc(validateTime),
+	"datetime": FieldValidatorFunc(validateDateTime),
+}
+
+// displayFieldTypes never carry a submitted value.
+var displayFieldTypes = map[string]bool{
+	"heading":   true,
+	"paragraph": true,
+	"divider":   true,
+}
+
+// validateSubmissionData checks submitted data against every field of the schema.
+// Fields hidden by their conditional are skipped.
+func validateSubmissionData(schema *models.FormSchema, data map[string]interface{}) error {
+	errs := make(map[string]string)
+
+	for _, field := range schema.Fields {
+		if field.ID == "" || displayFieldTypes[field.Type] {
+			continue
+		}
+
+		if field.Conditional != nil {
+			visible, err := evaluateConditional(field.Conditional, data)
+			if err != nil || !visible {
+				continue
+			}
+		}
+
+		value, present := data[field.ID]
+		if !present || isEmptyValue(value) {
+			if field.Required {
+				errs[field.ID] = "This field is required"
+			}
+			continue
+		}
+
+		if validator, ok := fieldValidators[field.Type]; ok {
+			if err := validator.Validate(field, value); err != nil {
+				errs[field.ID] = err.Error()
+				continue
+			}
+		}
+
+		if err := validateRules(field, value); err != nil {
+			errs[field.ID] = err.Error()
+		}
+	}
+
+	if len(errs) > 0 {
+		return invalidSubmission(errs)
+	}
+	return nil
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+// validateRules applies the field's custom Validation rules to string values.
+func validateRules(field models.Field, value interface{}) error {
+	rules := field.Validation
+	if rules == nil {
+		return nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+
+	length := utf8.RuneCountInString(s)
+	if rules.MinLength > 0 && length < rules.MinLength {
+		return fmt.Errorf("must be at least %d characters", rules.MinLength)
+	}
+	if rules.MaxLength > 0 && length > rules.MaxLength {
+		return fmt.Errorf("must be at most %d characters", rules.MaxLength)
+	}
+
+	if rules.Pattern != "" {
+		re, err := regexp.Compile(rules.Pattern)
+		if err != nil {
+			return fmt.Errorf("has an invalid validation pattern")
+		}
+		if !re.MatchString(s) {
+			if rules.PatternErrorMessage != "" {
+				return fmt.Errorf("%s", rules.PatternErrorMessage)
+			}
+			return fmt.Errorf("does not match the required format")
+		}
+	}
+
+	return nil
+}
+
+func validateText(field models.Field, value interface{}) error {
+	if _, ok := value.(string); !ok {
+		return fmt.Errorf("must be a string")
+	}
+	return nil
+}
+
+func validateEmail(field models.Field, value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("must be a string")
+	}
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != strings.TrimSpace(s) {
+		return fmt.Errorf("must be a valid email address")
+	}
+	return nil
+}
+
+func validateURL(field models.Field, value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("must be a string")
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be a valid http or https URL")
+	}
+	return nil
+}
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ().-]{5,19}$`)
+
+func validatePhone(field models.Field, value interface{}) error {
+	s, ok := value.(string)
+	if !ok || !phonePattern.MatchString(strings.TrimSpace(s)) {
+		return fmt.Errorf("must be a valid phone number")
+	}
+	return nil
+}
+
+func validateNumber(field models.Field, value interface{}) error {
+	n, ok := value.(float64)
+	if !ok {
+		return fmt.Errorf("must be a number")
+	}
+	return checkRange(field, n)
+}
+
+// Ratings default to a 1-5 scale when the field does not set Min and Max.
+func validateRating(field models.Field, value interface{}) error {
+	n, ok := value.(float64)
+	if !ok || n != math.Trunc(n) {
+		return fmt.Errorf("must be a whole number")
+	}
+	if field.Min == nil {
+		one := 1.0
+		field.Min = &one
+	}
+	if field.Max == nil {
+		five := 5.0
+		field.Max = &five
+	}
+	return checkRange(field, n)
+}
+
+func checkRange(field models.Field, n float64) error {
+	if field.Min != nil && n < *field.Min {
+		return fmt.Errorf("must be at least %s", formatNumber(*field.Min))
+	}
+	if field.Max != nil && n > *field.Max {
+		return fmt.Errorf("must be at most %s", formatNumber(*field.Max))
+	}
+	if field.Step != nil && *field.Step > 0 {
+		base := 0.0
+		if field.Min != nil {
+			base = *field.Min
+		}
+		steps := (n - base) / *field.Step
+		if math.Abs(steps-math.Round(steps)) > 1e-9 {
+			return fmt.Errorf("must be a multiple of %s", formatNumber(*field.Step))
+		}
+	}
+	return nil
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func validateChoice(field models.Field, value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("must be a string")
+	}
+	if !hasOption(field, s) {
+		return fmt.Errorf("must be one of the listed options")
+	}
+	return nil
+}
+
+// Checkboxes with options hold the list of checked values; a lone checkbox is a boolean.
+func validateCheckbox(field models.Field, value interface{}) error {
+	if len(field.Options) == 0 {
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("must be true or false")
+		}
+		return nil
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("must be a list of options")
+	}
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok || !hasOption(field, s) {
+			return fmt.Errorf("must only contain the listed options")
+		}
+	}
+	return nil
+}
+
+// Rank values order every option exactly once.
+func validateRank(field models.Field, value interface{}) error {
+	items, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("must be a list of options")
+	}
+	if len(items) != len(field.Options) {
+		return fmt.Errorf("must rank every option")
+	}
+
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok || !hasOption(field, s) || seen[s] {
+			return fmt.Errorf("must rank every option exactly once")
+		}
+		seen[s] = true
+	}
+	return nil
+}
+
+func hasOption(field models.Field, value string) bool {
+	for _, option := range field.Options {
+		if option.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func validateDate(field models.Field, value interface{}) error {
+	return parseTimeValue(value, "2006-01-02", "must be a date in YYYY-MM-DD format")
+}
+
+func validateTime(field models.Field, value interface{}) error {
+	return parseTimeValue(value, "15:04", "must be a time in HH:MM format")
+}
+
+func validateDateTime(field models.Field, value interface{}) error {
+	return parseTimeValue(value, time.RFC3339, "must be an RFC 3339 timestamp")
+}
+
+func parseTimeValue(value interface{}, layout, message string) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("%s", message)
+	}
+	if _, err := time.Parse(layout, s); err != nil {
+		return fmt.Errorf("%s", message)
+	}
+	return nil
+}
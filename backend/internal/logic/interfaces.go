@@ -2,17 +2,23 @@ package logic
 
 import (
 	"context"
+	"io"
+	"time"
 
+	"github.com/hungaikev/rootd/backend/internal/export"
 	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/hungaikev/rootd/backend/internal/queue"
 )
 
 // WorkflowService defines the interface for workflow business logic
 type WorkflowService interface {
 	CreateWorkflow(ctx context.Context, req CreateWorkflowRequest) (*models.Workflow, error)
 	GetWorkflow(ctx context.Context, id string) (*models.Workflow, error)
+	GetWorkflowOwner(ctx context.Context, id string) (string, error)
 	ListWorkflows(ctx context.Context, ownerID string) ([]*models.Workflow, error)
 	UpdateWorkflow(ctx context.Context, id string, req UpdateWorkflowRequest) (*models.Workflow, error)
-	UpdateWorkflowStatus(ctx context.Context, id string, status models.WorkflowStatus) (*models.Workflow, error)
+	UpdateWorkflowStatus(ctx context.Context, id string, status models.WorkflowStatus, changedBy string) (*models.Workflow, error)
+	ListStatusTransitions(ctx context.Context, id string) ([]*models.WorkflowStatusTransition, error)
 	DeleteWorkflow(ctx context.Context, id string) error
 }
 
@@ -29,10 +35,56 @@ type FormService interface {
 type SubmissionService interface {
 	CreateSubmission(ctx context.Context, req CreateSubmissionRequest) (*models.Submission, error)
 	GetSubmission(ctx context.Context, id string) (*models.Submission, error)
-	ListSubmissions(ctx context.Context, workflowID string) ([]*models.Submission, error)
-	ListSubmissionsByOwner(ctx context.Context, ownerID string) ([]*models.Submission, error)
+	ListSubmissions(ctx context.Context, workflowID string, opts ListSubmissionsOptions) (*SubmissionPage, error)
+	ListSubmissionsByOwner(ctx context.Context, ownerID string, opts ListSubmissionsOptions) (*SubmissionPage, error)
 	UpdateSubmissionStatus(ctx context.Context, id string, status models.SubmissionStatus) (*models.Submission, error)
 	DeleteSubmission(ctx context.Context, id string) error
+	ListActionRuns(ctx context.Context, submissionID string) ([]*models.ActionRun, error)
+}
+
+// WebhookService defines the interface for ingesting payloads sent to workflow webhooks
+type WebhookService interface {
+	ReceiveWebhook(ctx context.Context, token string, req WebhookRequest) (*models.Submission, error)
+}
+
+// RunService defines the interface for running workflows on demand
+type RunService interface {
+	StartRun(ctx context.Context, workflowID string, req StartRunRequest) (*models.WorkflowRun, error)
+	GetRun(ctx context.Context, workflowID, runID string) (*models.WorkflowRun, error)
+	ListRuns(ctx context.Context, workflowID string) ([]*models.WorkflowRun, error)
+}
+
+// ExportService defines the interface for exporting workflow submissions
+type ExportService interface {
+	ExportSubmissions(ctx context.Context, workflowID string, format export.Format, w io.Writer) error
+}
+
+// AnalyticsService defines the interface for recording form session events
+type AnalyticsService interface {
+	RecordFormEvent(ctx context.Context, workflowID string, req FormEventRequest) error
+}
+
+// AuthService defines the interface for user registration and authentication
+type AuthService interface {
+	Register(ctx context.Context, req RegisterRequest) (*models.User, error)
+	Login(ctx context.Context, req LoginRequest) (*LoginResponse, error)
+	Authenticate(ctx context.Context, token string) (string, error)
+	GetUser(ctx context.Context, id string) (*models.User, error)
+}
+
+// ExecutionEngine defines the interface for running workflow actions against submissions
+type ExecutionEngine interface {
+	RegisterExecutor(executor ActionExecutor)
+	ProcessSubmission(ctx context.Context, id string) error
+	ProcessSubmissionJob(ctx context.Context, job *queue.Job) error
+	ProcessRunJob(ctx context.Context, job *queue.Job) error
+	PruneActionRuns(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Scheduler defines the interface for firing workflows that have a schedule trigger
+type Scheduler interface {
+	FireDue(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
 }
 
 // Request/Response DTOs
@@ -41,16 +93,18 @@ type CreateWorkflowRequest struct {
 	Description   string                 `json:"description"`
 	OwnerID       string                 `json:"owner_id" validate:"required"`
 	SchemaID      *string                `json:"schema_id"`
+	TriggerType   models.TriggerType     `json:"trigger_type"` // Defaults to manual.
 	TriggerConfig map[string]interface{} `json:"trigger_config"`
-	Actions       map[string]interface{} `json:"actions"`
+	Actions       []models.Action        `json:"actions"`
 }
 
 type UpdateWorkflowRequest struct {
 	Name          *string                `json:"name"`
 	Description   *string                `json:"description"`
 	SchemaID      *string                `json:"schema_id"`
+	TriggerType   *models.TriggerType    `json:"trigger_type"`
 	TriggerConfig map[string]interface{} `json:"trigger_config"`
-	Actions       map[string]interface{} `json:"actions"`
+	Actions       []models.Action        `json:"actions"`
 }
 
 type CreateFormRequest struct {
@@ -72,3 +126,60 @@ type CreateSubmissionRequest struct {
 	Data       map[string]interface{}     `json:"data" validate:"required"`
 	Metadata   *models.SubmissionMetadata `json:"metadata"`
 }
+
+// StartRunRequest starts a workflow run. Exactly one of Input and SubmissionID is set:
+// the actions run either against the input payload or against an existing submission.
+type StartRunRequest struct {
+	Input        map[string]interface{} `json:"input"`
+	SubmissionID *string                `json:"submission_id"`
+	TriggeredBy  string                 `json:"triggered_by" validate:"required"`
+}
+
+// WebhookRequest is one delivery to a workflow webhook. The signature covers the
+// timestamp and the raw payload, so Payload must be the body exactly as received.
+type WebhookRequest struct {
+	Payload   []byte
+	Timestamp string // Unix seconds from the WebhookTimestampHeader.
+	Signature string // Value of the WebhookSignatureHeader.
+	Metadata  *models.SubmissionMetadata
+}
+
+// ListSubmissionsOptions controls pagination, filtering and ordering of submission listings.
+// The zero value returns the first page of all submissions, newest first.
+type ListSubmissionsOptions struct {
+	Status      *models.SubmissionStatus
+	CreatedFrom *time.Time   // Inclusive lower bound on created_at.
+	CreatedTo   *time.Time   // Exclusive upper bound on created_at.
+	DataFilters []DataFilter // Conditions on values inside the submission data, all of which must match.
+	Cursor      string       // Opaque cursor from SubmissionPage.NextCursor.
+	Limit       int
+	Sort        SortDirection
+}
+
+type SubmissionPage struct {
+	Submissions []*models.Submission `json:"submissions"`
+	NextCursor  string               `json:"nextCursor,omitempty"`
+	TotalCount  int64                `json:"totalCount"`
+}
+
+type FormEventRequest struct {
+	Type      models.FormEventType `json:"type" validate:"required"`
+	SessionID string               `json:"sessionId" validate:"required"` // Client-generated UUID, also sent as metadata.sessionId on submit.
+}
+
+type RegisterRequest struct {
+	Email    string `json:"email" validate:"required"`
+	Name     string `json:"name"`
+	Password string `json:"password" validate:"required"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type LoginResponse struct {
+	Token     string       `json:"token"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+	User      *models.User `json:"user"`
+}
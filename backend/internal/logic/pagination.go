@@ -0,0 +1,64 @@
+package logic
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SortDirection orders listings by creation time.
+type SortDirection string
+
+const (
+	SortAscending  SortDirection = "asc"
+	SortDescending SortDirection = "desc"
+)
+
+// ErrInvalidListOptions is returned when listing options fail validation.
+var ErrInvalidListOptions = InvalidInput("invalid_list_options", "invalid list options", nil)
+
+func invalidListOptions(err error) error {
+	return InvalidInput(ErrInvalidListOptions.Code, ErrInvalidListOptions.Message, err)
+}
+
+const (
+	DefaultSubmissionPageSize = 50
+	MaxSubmissionPageSize     = 200
+)
+
+// submissionCursor is the keyset position of the last row of a page.
+type submissionCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// encodeSubmissionCursor serializes a cursor into an opaque, URL-safe token.
+func encodeSubmissionCursor(c submissionCursor) string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSubmissionCursor(token string) (submissionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return submissionCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return submissionCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	var c submissionCursor
+	if c.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return submissionCursor{}, fmt.Errorf("invalid cursor")
+	}
+	if c.ID, err = uuid.Parse(id); err != nil {
+		return submissionCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	return c, nil
+}
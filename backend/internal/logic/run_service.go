@@ -0,0 +1,179 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrWorkflowArchived is returned when starting a run of an archived workflow.
+var ErrWorkflowArchived = Conflict("workflow_archived", "archived workflows cannot be run", nil)
+
+type runService struct {
+	queries     db.Store
+	submissions *submissionService
+}
+
+// NewRunService creates a new run service. Runs are created as pending with a
+// run_workflow job, which the execution engine carries out.
+func NewRunService(queries db.Store) RunService {
+	return &runService{
+		queries:     queries,
+		submissions: &submissionService{queries: queries},
+	}
+}
+
+func (s *runService) StartRun(ctx context.Context, workflowID string, req StartRunRequest) (*models.WorkflowRun, error) {
+	// Validate business rules
+	if err := s.validateStartRun(req); err != nil {
+		return nil, validationFailed(err)
+	}
+
+	workflowUUID, err := uuid.Parse(workflowID)
+	if err != nil {
+		return nil, invalidID("workflow", err)
+	}
+
+	params := db.CreateWorkflowRunParams{
+		WorkflowID:  pgtype.UUID{Bytes: workflowUUID, Valid: true},
+		TriggeredBy: pgtype.UUID{Bytes: uuid.MustParse(req.TriggeredBy), Valid: true},
+	}
+	if req.SubmissionID != nil {
+		submissionID, err := uuid.Parse(*req.SubmissionID)
+		if err != nil {
+			return nil, invalidID("submission", err)
+		}
+		params.SubmissionID = pgtype.UUID{Bytes: submissionID, Valid: true}
+	} else {
+		params.Input, _ = json.Marshal(req.Input)
+	}
+
+	var run *db.WorkflowRun
+	err = inTx(ctx, s.queries, func(q db.Querier) error {
+		workflow, err := q.GetWorkflow(ctx, params.WorkflowID)
+		if err != nil {
+			return dbNotFound(err, "workflow", "failed to get workflow")
+		}
+
+		if workflow.Status == string(models.WorkflowStatusArchived) {
+			return ErrWorkflowArchived
+		}
+
+		if params.SubmissionID.Valid {
+			// Re-runs only apply to the workflow's own submissions
+			submission, err := q.GetSubmission(ctx, params.SubmissionID)
+			if err != nil {
+				return dbNotFound(err, "submission", "failed to get submission")
+			}
+			if submission.WorkflowID != workflow.ID {
+				return NotFound("submission_not_found", "submission not found", nil)
+			}
+		} else if workflow.SchemaID.Valid {
+			// The input stands in for a submission, so it must satisfy the form too
+			input, err := s.submissions.validateAgainstForm(ctx, q, workflow.SchemaID, req.Input)
+			if err != nil {
+				return err
+			}
+			params.Input, _ = json.Marshal(input)
+		}
+
+		if run, err = q.CreateWorkflowRun(ctx, &params); err != nil {
+			return dbError(err, "failed to create workflow run")
+		}
+		return enqueueRun(ctx, q, run.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.dbToModel(*run), nil
+}
+
+func (s *runService) GetRun(ctx context.Context, workflowID, runID string) (*models.WorkflowRun, error) {
+	workflowUUID, err := uuid.Parse(workflowID)
+	if err != nil {
+		return nil, invalidID("workflow", err)
+	}
+
+	runUUID, err := uuid.Parse(runID)
+	if err != nil {
+		return nil, invalidID("run", err)
+	}
+
+	run, err := s.queries.GetWorkflowRun(ctx, pgtype.UUID{Bytes: runUUID, Valid: true})
+	if err != nil {
+		return nil, dbNotFound(err, "run", "failed to get workflow run")
+	}
+
+	if run.WorkflowID.Bytes != workflowUUID {
+		return nil, NotFound("run_not_found", "run not found", nil)
+	}
+
+	return s.dbToModel(*run), nil
+}
+
+func (s *runService) ListRuns(ctx context.Context, workflowID string) ([]*models.WorkflowRun, error) {
+	workflowUUID, err := uuid.Parse(workflowID)
+	if err != nil {
+		return nil, invalidID("workflow", err)
+	}
+
+	runs, err := s.queries.ListWorkflowRuns(ctx, pgtype.UUID{Bytes: workflowUUID, Valid: true})
+	if err != nil {
+		return nil, dbError(err, "failed to list workflow runs")
+	}
+
+	result := make([]*models.WorkflowRun, len(runs))
+	for i, run := range runs {
+		result[i] = s.dbToModel(*run)
+	}
+
+	return result, nil
+}
+
+// Helper methods
+func (s *runService) validateStartRun(req StartRunRequest) error {
+	if req.TriggeredBy == "" {
+		return fmt.Errorf("triggered by is required")
+	}
+	if _, err := uuid.Parse(req.TriggeredBy); err != nil {
+		return fmt.Errorf("triggered by must be a UUID")
+	}
+	if (req.Input == nil) == (req.SubmissionID == nil) {
+		return fmt.Errorf("exactly one of input and submission ID is required")
+	}
+	return nil
+}
+
+func (s *runService) dbToModel(run db.WorkflowRun) *models.WorkflowRun {
+	result := &models.WorkflowRun{
+		ID:          uuid.UUID(run.ID.Bytes[:]).String(),
+		WorkflowID:  uuid.UUID(run.WorkflowID.Bytes[:]).String(),
+		Status:      models.WorkflowRunStatus(run.Status),
+		Error:       run.Error.String,
+		TriggeredBy: uuid.UUID(run.TriggeredBy.Bytes[:]).String(),
+		CreatedAt:   run.CreatedAt,
+	}
+
+	if run.SubmissionID.Valid {
+		result.SubmissionID = uuid.UUID(run.SubmissionID.Bytes[:]).String()
+	}
+	if len(run.Input) > 0 {
+		json.Unmarshal(run.Input, &result.Input)
+	}
+	if run.StartedAt.Valid {
+		t := run.StartedAt.Time
+		result.StartedAt = &t
+	}
+	if run.FinishedAt.Valid {
+		t := run.FinishedAt.Time
+		result.FinishedAt = &t
+	}
+
+	return result
+}
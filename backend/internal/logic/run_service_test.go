@@ -0,0 +1,253 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/hungaikev/rootd/backend/internal/queue"
+)
+
+// recordingExecutor records the action contexts it is called with.
+type recordingExecutor struct {
+	calls []*ActionContext
+	err   error
+}
+
+func (x *recordingExecutor) Type() models.ActionType {
+	return models.ActionTypeNotification
+}
+
+func (x *recordingExecutor) Execute(ctx context.Context, actx *ActionContext) (*ActionResult, error) {
+	x.calls = append(x.calls, actx)
+	return nil, x.err
+}
+
+func TestStartRun(t *testing.T) {
+	env := newTestEnv(t)
+	runs := NewRunService(env.queries)
+	form := env.createForm(t, models.Field{ID: "email", Type: "email", Label: "Email", Required: true})
+	workflow := env.activeWorkflow(t, &form.ID, withActions(models.Action{ID: "notify", Type: models.ActionTypeNotification}))
+	submission := env.submit(t, workflow.ID, map[string]interface{}{"email": "ada@example.com"})
+	other := env.submit(t, env.activeWorkflow(t, nil).ID, map[string]interface{}{})
+
+	archived := env.createWorkflow(t, nil)
+	if _, err := env.workflows.UpdateWorkflowStatus(env.ctx, archived.ID, models.WorkflowStatusArchived, env.owner); err != nil {
+		t.Fatalf("UpdateWorkflowStatus: %v", err)
+	}
+
+	input := map[string]interface{}{"email": "grace@example.com"}
+
+	tests := []struct {
+		name       string
+		workflowID string
+		req        StartRunRequest
+		kind       ErrorKind
+		code       string
+	}{
+		{"with input", workflow.ID, StartRunRequest{Input: input, TriggeredBy: env.owner}, "", ""},
+		{"with submission", workflow.ID, StartRunRequest{SubmissionID: &submission.ID, TriggeredBy: env.owner}, "", ""},
+		{"neither", workflow.ID, StartRunRequest{TriggeredBy: env.owner}, KindValidation, "validation_failed"},
+		{"both", workflow.ID, StartRunRequest{Input: input, SubmissionID: &submission.ID, TriggeredBy: env.owner}, KindValidation, "validation_failed"},
+		{"missing triggered by", workflow.ID, StartRunRequest{Input: input}, KindValidation, "validation_failed"},
+		{"invalid input", workflow.ID, StartRunRequest{Input: map[string]interface{}{}, TriggeredBy: env.owner}, KindValidation, "invalid_submission"},
+		{"unknown workflow", uuid.NewString(), StartRunRequest{Input: input, TriggeredBy: env.owner}, KindNotFound, "workflow_not_found"},
+		{"archived workflow", archived.ID, StartRunRequest{Input: input, TriggeredBy: env.owner}, KindConflict, "workflow_archived"},
+		{"submission not a UUID", workflow.ID, StartRunRequest{SubmissionID: ptr("first"), TriggeredBy: env.owner}, KindNotFound, "submission_not_found"},
+		{"unknown submission", workflow.ID, StartRunRequest{SubmissionID: ptr(uuid.NewString()), TriggeredBy: env.owner}, KindNotFound, "submission_not_found"},
+		{"other workflow's submission", workflow.ID, StartRunRequest{SubmissionID: &other.ID, TriggeredBy: env.owner}, KindNotFound, "submission_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, err := runs.StartRun(env.ctx, tt.workflowID, tt.req)
+			if tt.kind != "" {
+				assertError(t, err, tt.kind, tt.code)
+				return
+			}
+			if err != nil {
+				t.Fatalf("StartRun: %v", err)
+			}
+
+			if run.Status != models.WorkflowRunStatusPending || run.WorkflowID != workflow.ID || run.TriggeredBy != env.owner {
+				t.Errorf("run = %+v, want a pending run of workflow %s by %s", run, workflow.ID, env.owner)
+			}
+			if tt.req.SubmissionID != nil && (run.SubmissionID != *tt.req.SubmissionID || run.Input != nil) {
+				t.Errorf("run = %+v, want it against submission %s", run, *tt.req.SubmissionID)
+			}
+		})
+	}
+}
+
+func TestGetAndListRuns(t *testing.T) {
+	env := newTestEnv(t)
+	runs := NewRunService(env.queries)
+	workflow := env.activeWorkflow(t, nil, withActions(models.Action{ID: "notify", Type: models.ActionTypeNotification}))
+	other := env.activeWorkflow(t, nil, withActions(models.Action{ID: "notify", Type: models.ActionTypeNotification}))
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		run, err := runs.StartRun(env.ctx, workflow.ID, StartRunRequest{Input: map[string]interface{}{}, TriggeredBy: env.owner})
+		if err != nil {
+			t.Fatalf("StartRun: %v", err)
+		}
+		ids = append(ids, run.ID)
+	}
+
+	got, err := runs.GetRun(env.ctx, workflow.ID, ids[0])
+	if err != nil || got.ID != ids[0] {
+		t.Fatalf("GetRun = %+v, %v, want run %s", got, err, ids[0])
+	}
+
+	_, err = runs.GetRun(env.ctx, other.ID, ids[0])
+	assertError(t, err, KindNotFound, "run_not_found")
+	_, err = runs.GetRun(env.ctx, workflow.ID, "latest")
+	assertError(t, err, KindNotFound, "run_not_found")
+
+	list, err := runs.ListRuns(env.ctx, workflow.ID)
+	if err != nil {
+		t.Fatalf("ListRuns: %v", err)
+	}
+	var listed []string
+	for _, run := range list {
+		listed = append(listed, run.ID)
+	}
+	if !equalStrings(listed, reversed(ids)) {
+		t.Errorf("listed %v, want %v", listed, reversed(ids))
+	}
+}
+
+func TestProcessRunJob(t *testing.T) {
+	env := newTestEnv(t)
+	runs := NewRunService(env.queries)
+	recorder := &recordingExecutor{}
+	engine := NewExecutionEngine(env.queries, recorder)
+	pool := queue.NewPool(env.queries, queue.Config{MaxAttempts: 2, BaseBackoff: time.Nanosecond})
+	pool.Handle(JobProcessSubmission, engine.ProcessSubmissionJob)
+	pool.Handle(JobRunWorkflow, engine.ProcessRunJob)
+	work := func() {
+		t.Helper()
+		if claimed, err := pool.Work(env.ctx); err != nil || !claimed {
+			t.Fatalf("Work = %v, %v, want a job run", claimed, err)
+		}
+	}
+
+	workflow := env.activeWorkflow(t, nil, withActions(models.Action{ID: "notify", Type: models.ActionTypeNotification}))
+	submission := env.submit(t, workflow.ID, map[string]interface{}{"email": "ada@example.com"})
+	work()
+	recorder.calls = nil
+
+	rerun, err := runs.StartRun(env.ctx, workflow.ID, StartRunRequest{SubmissionID: &submission.ID, TriggeredBy: env.owner})
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	manual, err := runs.StartRun(env.ctx, workflow.ID, StartRunRequest{
+		Input:       map[string]interface{}{"email": "grace@example.com"},
+		TriggeredBy: env.owner,
+	})
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+
+	// Each run is carried out by the job enqueued with it
+	work()
+	work()
+	if len(recorder.calls) != 2 {
+		t.Fatalf("executor ran %d times, want 2", len(recorder.calls))
+	}
+	calls := map[string]*ActionContext{}
+	for _, actx := range recorder.calls {
+		calls[actx.Run.ID] = actx
+	}
+	if actx := calls[rerun.ID]; actx == nil || actx.Submission.ID != submission.ID {
+		t.Errorf("re-run ran against %+v, want submission %s", actx, submission.ID)
+	}
+	if actx := calls[manual.ID]; actx == nil || actx.Submission.ID != "" || actx.Submission.Data["email"] != "grace@example.com" {
+		t.Errorf("manual run ran against %+v, want its input", actx)
+	}
+
+	for _, id := range []string{rerun.ID, manual.ID} {
+		run, err := runs.GetRun(env.ctx, workflow.ID, id)
+		if err != nil {
+			t.Fatalf("GetRun: %v", err)
+		}
+		if run.Status != models.WorkflowRunStatusCompleted || run.StartedAt == nil || run.FinishedAt == nil {
+			t.Errorf("run = %+v, want completed with start and finish times", run)
+		}
+	}
+
+	// A re-run does not change the submission's own status
+	got, err := env.submissions.GetSubmission(env.ctx, submission.ID)
+	if err != nil || got.Status != models.SubmissionStatusCompleted {
+		t.Errorf("submission status = %v, %v, want completed", got, err)
+	}
+
+	// Failures are recorded on the run, which the job's retry takes over
+	recorder.err = errors.New("smtp unavailable")
+	failed, err := runs.StartRun(env.ctx, workflow.ID, StartRunRequest{SubmissionID: &submission.ID, TriggeredBy: env.owner})
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	work()
+	run, err := runs.GetRun(env.ctx, workflow.ID, failed.ID)
+	if err != nil {
+		t.Fatalf("GetRun: %v", err)
+	}
+	if run.Status != models.WorkflowRunStatusFailed || run.Error == "" {
+		t.Errorf("run = %+v, want failed with an error", run)
+	}
+
+	recorder.err = nil
+	work()
+	if run, err = runs.GetRun(env.ctx, workflow.ID, failed.ID); err != nil {
+		t.Fatalf("GetRun: %v", err)
+	}
+	if run.Status != models.WorkflowRunStatusCompleted || run.Error != "" {
+		t.Errorf("run = %+v, want completed by the retry", run)
+	}
+	if counts, _ := env.queries.CountJobsByStatus(env.ctx); len(counts) != 0 {
+		t.Errorf("jobs left: %+v", counts)
+	}
+}
+
+func TestProcessRunJobSkipsFinishedRuns(t *testing.T) {
+	env := newTestEnv(t)
+	recorder := &recordingExecutor{}
+	engine := NewExecutionEngine(env.queries, recorder)
+
+	workflow := env.activeWorkflow(t, nil, withActions(models.Action{ID: "notify", Type: models.ActionTypeNotification}))
+	run, err := NewRunService(env.queries).StartRun(env.ctx, workflow.ID, StartRunRequest{
+		Input:       map[string]interface{}{},
+		TriggeredBy: env.owner,
+	})
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	payload, _ := json.Marshal(runJob{RunID: run.ID})
+
+	tests := []struct {
+		name    string
+		job     queue.Job
+		wantErr bool
+		calls   int
+	}{
+		{"first attempt", queue.Job{Payload: payload, Attempt: 1}, false, 1},
+		{"already finished", queue.Job{Payload: payload, Attempt: 2}, false, 1},
+		{"deleted", queue.Job{Payload: json.RawMessage(`{"runId":"` + uuid.NewString() + `"}`), Attempt: 1}, false, 1},
+		{"invalid payload", queue.Job{Payload: json.RawMessage(`{"runId":"run-1"}`), Attempt: 1}, true, 1},
+	}
+
+	for _, tt := range tests {
+		err := engine.ProcessRunJob(env.ctx, &tt.job)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ProcessRunJob = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if len(recorder.calls) != tt.calls {
+			t.Errorf("%s: executor ran %d times, want %d", tt.name, len(recorder.calls), tt.calls)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hungaikev/rootd/backend/internal/cron"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// schedulerBatchSize is the number of due schedules claimed per transaction.
+const schedulerBatchSize = 50
+
+type scheduler struct {
+	queries db.Store
+	now     func() time.Time
+}
+
+// NewScheduler creates a scheduler that fires due workflows as workflow runs, which
+// the execution engine then carries out. Any number of replicas can run one: each
+// fire time is claimed by a single scheduler through row locks on the schedule.
+func NewScheduler(queries db.Store) Scheduler {
+	return &scheduler{
+		queries: queries,
+		now:     time.Now,
+	}
+}
+
+// FireDue starts a run for every active workflow whose schedule is due and moves each
+// due schedule to its next fire time. A schedule that was missed several times, e.g.
+// while no scheduler was running, fires once. Schedules of workflows that are not
+// active are moved on without firing.
+func (s *scheduler) FireDue(ctx context.Context) (int, error) {
+	var fired int
+	// Read committed is enough: the claimed rows stay locked until the commit, and a
+	// replica that skipped them sees their new fire time in its next statement
+	err := s.queries.ExecTx(ctx, db.TxOptions{}, func(q db.Querier) error {
+		fired = 0
+		now := s.now()
+
+		due, err := q.ClaimDueWorkflowSchedules(ctx, &db.ClaimDueWorkflowSchedulesParams{
+			Now:        now,
+			ClaimLimit: schedulerBatchSize,
+		})
+		if err != nil {
+			return dbError(err, "failed to claim due workflow schedules")
+		}
+
+		for _, schedule := range due {
+			ok, err := s.fire(ctx, q, schedule, now)
+			if err != nil {
+				return err
+			}
+			if ok {
+				fired++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return fired, nil
+}
+
+func (s *scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.FireDue(ctx); err != nil {
+			log.Printf("scheduler: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fire queues a run for one due schedule and records the fire. It reports whether a
+// run was queued.
+func (s *scheduler) fire(ctx context.Context, q db.Querier, schedule *db.WorkflowSchedule, now time.Time) (bool, error) {
+	workflow, err := q.GetWorkflow(ctx, schedule.WorkflowID)
+	if err != nil {
+		return false, dbError(err, "failed to get scheduled workflow")
+	}
+
+	expr, loc, err := parseSchedule(models.ScheduleTriggerConfig{Cron: schedule.CronExpression, TimeZone: schedule.TimeZone})
+	if err != nil {
+		// Configs are validated when saved, so this only happens if the time zone
+		// database changed. Drop the schedule rather than failing every tick.
+		log.Printf("scheduler: workflow %s: dropping schedule: %v", workflow.ID.String(), err)
+		if err := q.DeleteWorkflowSchedule(ctx, schedule.WorkflowID); err != nil {
+			return false, dbError(err, "failed to delete workflow schedule")
+		}
+		return false, nil
+	}
+
+	params := db.RecordWorkflowScheduleFireParams{
+		WorkflowID: schedule.WorkflowID,
+		LastFireAt: schedule.LastFireAt,
+		NextFireAt: expr.Next(now.In(loc)),
+	}
+	if params.NextFireAt.IsZero() {
+		log.Printf("scheduler: workflow %s: schedule %q does not fire again", workflow.ID.String(), schedule.CronExpression)
+		if err := q.DeleteWorkflowSchedule(ctx, schedule.WorkflowID); err != nil {
+			return false, dbError(err, "failed to delete workflow schedule")
+		}
+	}
+
+	active := workflow.Status == string(models.WorkflowStatusActive)
+	if active {
+		input := map[string]interface{}{"scheduledAt": schedule.NextFireAt}
+		if schedule.LastFireAt.Valid {
+			input["previousScheduledAt"] = schedule.LastFireAt.Time
+		}
+		raw, _ := json.Marshal(input)
+
+		run, err := q.CreateWorkflowRun(ctx, &db.CreateWorkflowRunParams{
+			WorkflowID:  workflow.ID,
+			Input:       raw,
+			TriggeredBy: workflow.OwnerID,
+		})
+		if err != nil {
+			return false, dbError(err, "failed to create scheduled workflow run")
+		}
+		if err := enqueueRun(ctx, q, run.ID); err != nil {
+			return false, err
+		}
+		params.LastFireAt = pgtype.Timestamptz{Time: schedule.NextFireAt, Valid: true}
+	}
+
+	if !params.NextFireAt.IsZero() {
+		if _, err := q.RecordWorkflowScheduleFire(ctx, &params); err != nil {
+			return false, dbError(err, "failed to record workflow schedule fire")
+		}
+	}
+
+	return active, nil
+}
+
+// Helper methods
+
+// parseScheduleTriggerConfig decodes and checks the config of a schedule trigger.
+func parseScheduleTriggerConfig(raw json.RawMessage) (models.ScheduleTriggerConfig, error) {
+	var config models.ScheduleTriggerConfig
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &config); err != nil {
+			return config, fmt.Errorf("schedule trigger config: %w", err)
+		}
+	}
+	if config.TimeZone == "" {
+		config.TimeZone = "UTC"
+	}
+
+	expr, loc, err := parseSchedule(config)
+	if err != nil {
+		return config, err
+	}
+	if expr.Next(time.Now().In(loc)).IsZero() {
+		return config, fmt.Errorf("schedule %q never fires", config.Cron)
+	}
+
+	return config, nil
+}
+
+// parseSchedule parses the cron expression and time zone of a schedule.
+func parseSchedule(config models.ScheduleTriggerConfig) (*cron.Schedule, *time.Location, error) {
+	if config.Cron == "" {
+		return nil, nil, fmt.Errorf("schedule trigger requires a cron expression")
+	}
+	expr, err := cron.Parse(config.Cron)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := time.LoadLocation(config.TimeZone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unknown time zone %q", config.TimeZone)
+	}
+	return expr, loc, nil
+}
+
+// syncWorkflowSchedule creates, updates or deletes the schedule of a workflow so that
+// only workflows with a schedule trigger have one. A schedule whose expression and
+// time zone are unchanged keeps its fire times. It returns the workflow's schedule, or
+// nil when it has none.
+func syncWorkflowSchedule(ctx context.Context, q db.Querier, workflowID pgtype.UUID, trigger models.Trigger) (*db.WorkflowSchedule, error) {
+	existing, err := q.GetWorkflowSchedule(ctx, workflowID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, dbError(err, "failed to get workflow schedule")
+	}
+	exists := err == nil
+
+	if trigger.Type != models.TriggerTypeSchedule {
+		if exists {
+			if err := q.DeleteWorkflowSchedule(ctx, workflowID); err != nil {
+				return nil, dbError(err, "failed to delete workflow schedule")
+			}
+		}
+		return nil, nil
+	}
+
+	config, err := parseScheduleTriggerConfig(trigger.Config)
+	if err != nil {
+		return nil, validationFailed(err)
+	}
+	if exists && existing.CronExpression == config.Cron && existing.TimeZone == config.TimeZone {
+		return existing, nil
+	}
+
+	expr, loc, err := parseSchedule(config)
+	if err != nil {
+		return nil, validationFailed(err)
+	}
+
+	schedule, err := q.UpsertWorkflowSchedule(ctx, &db.UpsertWorkflowScheduleParams{
+		WorkflowID:     workflowID,
+		CronExpression: config.Cron,
+		TimeZone:       config.TimeZone,
+		NextFireAt:     expr.Next(time.Now().In(loc)),
+	})
+	if err != nil {
+		return nil, dbError(err, "failed to save workflow schedule")
+	}
+	return schedule, nil
+}
+
+func scheduleToModel(schedule *db.WorkflowSchedule) *models.WorkflowSchedule {
+	if schedule == nil {
+		return nil
+	}
+	result := &models.WorkflowSchedule{
+		Cron:       schedule.CronExpression,
+		TimeZone:   schedule.TimeZone,
+		NextFireAt: schedule.NextFireAt,
+	}
+	if schedule.LastFireAt.Valid {
+		t := schedule.LastFireAt.Time
+		result.LastFireAt = &t
+	}
+	return result
+}
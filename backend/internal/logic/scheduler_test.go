@@ -0,0 +1,186 @@
+package logic
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/hungaikev/rootd/backend/internal/queue"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestScheduleTrigger(t *testing.T) {
+	env := newTestEnv(t)
+	before := time.Now()
+
+	workflow := env.createWorkflow(t, nil, withSchedule("0 9 * * MON"))
+	schedule := workflow.Schedule
+	if schedule == nil || schedule.Cron != "0 9 * * MON" || schedule.TimeZone != "Africa/Nairobi" || schedule.LastFireAt != nil {
+		t.Fatalf("schedule = %+v, want the configured cron and time zone, never fired", schedule)
+	}
+	next := schedule.NextFireAt.In(time.FixedZone("EAT", 3*60*60))
+	if !next.After(before) || next.Weekday() != time.Monday || next.Hour() != 9 || next.Minute() != 0 {
+		t.Errorf("next fire = %v, want the next Monday 09:00 in Nairobi", next)
+	}
+
+	got, err := env.workflows.GetWorkflow(env.ctx, workflow.ID)
+	if err != nil {
+		t.Fatalf("GetWorkflow: %v", err)
+	}
+	if got.Schedule == nil || !got.Schedule.NextFireAt.Equal(schedule.NextFireAt) {
+		t.Errorf("GetWorkflow schedule = %+v, want %+v", got.Schedule, schedule)
+	}
+
+	// Updating something else keeps the fire times
+	name := "Weekly digest"
+	updated, err := env.workflows.UpdateWorkflow(env.ctx, workflow.ID, UpdateWorkflowRequest{Name: &name})
+	if err != nil {
+		t.Fatalf("UpdateWorkflow: %v", err)
+	}
+	if updated.Schedule == nil || !updated.Schedule.NextFireAt.Equal(schedule.NextFireAt) {
+		t.Errorf("schedule after rename = %+v, want %+v", updated.Schedule, schedule)
+	}
+
+	daily := map[string]interface{}{"cron": "@daily"}
+	updated, err = env.workflows.UpdateWorkflow(env.ctx, workflow.ID, UpdateWorkflowRequest{TriggerConfig: daily})
+	if err != nil {
+		t.Fatalf("UpdateWorkflow: %v", err)
+	}
+	if updated.Schedule == nil || updated.Schedule.Cron != "@daily" || updated.Schedule.TimeZone != "UTC" {
+		t.Errorf("schedule = %+v, want @daily in UTC", updated.Schedule)
+	}
+
+	manual := models.TriggerTypeManual
+	updated, err = env.workflows.UpdateWorkflow(env.ctx, workflow.ID, UpdateWorkflowRequest{TriggerType: &manual})
+	if err != nil {
+		t.Fatalf("UpdateWorkflow: %v", err)
+	}
+	if updated.Schedule != nil {
+		t.Errorf("schedule = %+v, want none after switching to manual", updated.Schedule)
+	}
+	if _, err := env.queries.GetWorkflowSchedule(env.ctx, mustUUID(workflow.ID)); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("schedule survived the trigger change: %v", err)
+	}
+}
+
+func TestScheduleTriggerInvalidConfig(t *testing.T) {
+	env := newTestEnv(t)
+
+	configs := map[string]map[string]interface{}{
+		"missing cron":      {},
+		"invalid cron":      {"cron": "0 25 * * *"},
+		"unknown time zone": {"cron": "@daily", "timeZone": "Mars/Olympus_Mons"},
+		"never fires":       {"cron": "0 0 30 2 *"},
+	}
+
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			_, err := env.workflows.CreateWorkflow(env.ctx, CreateWorkflowRequest{
+				Name:          "Digest",
+				OwnerID:       env.owner,
+				TriggerType:   models.TriggerTypeSchedule,
+				TriggerConfig: config,
+			})
+			assertError(t, err, KindValidation, "validation_failed")
+		})
+	}
+}
+
+func TestFireDue(t *testing.T) {
+	env := newTestEnv(t)
+	runs := NewRunService(env.queries)
+	scheduler := NewScheduler(env.queries).(*scheduler)
+
+	active := env.activeWorkflow(t, nil, withSchedule("0 * * * *"))
+	draft := env.createWorkflow(t, nil, withSchedule("0 * * * *"))
+	due := active.Schedule.NextFireAt
+
+	// Nothing is due yet
+	scheduler.now = func() time.Time { return due.Add(-time.Second) }
+	if n, err := scheduler.FireDue(env.ctx); err != nil || n != 0 {
+		t.Fatalf("FireDue before the fire time = %d, %v, want 0", n, err)
+	}
+
+	// Three fire times were missed; the workflow fires once and moves past now
+	now := due.Add(2*time.Hour + 30*time.Minute)
+	scheduler.now = func() time.Time { return now }
+	if n, err := scheduler.FireDue(env.ctx); err != nil || n != 1 {
+		t.Fatalf("FireDue = %d, %v, want 1", n, err)
+	}
+	if n, err := scheduler.FireDue(env.ctx); err != nil || n != 0 {
+		t.Fatalf("second FireDue = %d, %v, want 0", n, err)
+	}
+
+	list, err := runs.ListRuns(env.ctx, active.ID)
+	if err != nil {
+		t.Fatalf("ListRuns: %v", err)
+	}
+	if len(list) != 1 || list[0].Status != models.WorkflowRunStatusPending || list[0].TriggeredBy != env.owner {
+		t.Fatalf("runs = %+v, want one pending run by the owner", list)
+	}
+	if scheduledAt, _ := list[0].Input["scheduledAt"].(string); scheduledAt != due.Format(time.RFC3339Nano) {
+		t.Errorf("input = %v, want scheduledAt %s", list[0].Input, due.Format(time.RFC3339Nano))
+	}
+	jobs, err := env.queries.ListJobsByStatus(env.ctx, queue.StatusQueued)
+	if err != nil || len(jobs) != 1 || jobs[0].Kind != JobRunWorkflow || !strings.Contains(string(jobs[0].Payload), list[0].ID) {
+		t.Errorf("queued jobs = %+v, %v, want the run's job", jobs, err)
+	}
+
+	got, err := env.workflows.GetWorkflow(env.ctx, active.ID)
+	if err != nil {
+		t.Fatalf("GetWorkflow: %v", err)
+	}
+	if got.Schedule.LastFireAt == nil || !got.Schedule.LastFireAt.Equal(due) {
+		t.Errorf("last fire = %v, want %v", got.Schedule.LastFireAt, due)
+	}
+	if want := now.Truncate(time.Hour).Add(time.Hour); !got.Schedule.NextFireAt.Equal(want) {
+		t.Errorf("next fire = %v, want %v", got.Schedule.NextFireAt, want)
+	}
+
+	// The draft workflow is moved on without a run
+	got, err = env.workflows.GetWorkflow(env.ctx, draft.ID)
+	if err != nil {
+		t.Fatalf("GetWorkflow: %v", err)
+	}
+	if got.Schedule.LastFireAt != nil || !got.Schedule.NextFireAt.After(now) {
+		t.Errorf("draft schedule = %+v, want it moved past %v without firing", got.Schedule, now)
+	}
+	if list, _ := runs.ListRuns(env.ctx, draft.ID); len(list) != 0 {
+		t.Errorf("draft workflow has runs %+v", list)
+	}
+}
+
+func TestFireDueConcurrentSchedulers(t *testing.T) {
+	env := newTestEnv(t)
+	workflow := env.activeWorkflow(t, nil, withSchedule("*/5 * * * *"))
+	now := workflow.Schedule.NextFireAt.Add(time.Minute)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	for i := 0; i < 4; i++ {
+		s := NewScheduler(env.queries).(*scheduler)
+		s.now = func() time.Time { return now }
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := s.FireDue(env.ctx)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			total += n
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if total != 1 {
+		t.Errorf("workflow fired %d times, want once", total)
+	}
+}
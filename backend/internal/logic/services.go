@@ -1,6 +1,7 @@
 package logic
 
 import (
+	"github.com/hungaikev/rootd/backend/internal/auth"
 	"github.com/hungaikev/rootd/backend/internal/db"
 )
 
@@ -9,13 +10,23 @@ type Services struct {
 	Workflow   WorkflowService
 	Form       FormService
 	Submission SubmissionService
+	Webhook    WebhookService
+	Run        RunService
+	Export     ExportService
+	Analytics  AnalyticsService
+	Auth       AuthService
 }
 
 // NewServices creates a new services container
-func NewServices(queries *db.Queries) *Services {
+func NewServices(store db.Store, tokens *auth.TokenManager) *Services {
 	return &Services{
-		Workflow:   NewWorkflowService(queries),
-		Form:       NewFormService(queries),
-		Submission: NewSubmissionService(queries),
+		Workflow:   NewWorkflowService(store),
+		Form:       NewFormService(store),
+		Submission: NewSubmissionService(store),
+		Webhook:    NewWebhookService(store),
+		Run:        NewRunService(store),
+		Export:     NewExportService(store),
+		Analytics:  NewAnalyticsService(store),
+		Auth:       NewAuthService(store, tokens),
 	}
 }
@@ -0,0 +1,188 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/db/memdb"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// testEnv wires the services to an in-memory database.
+type testEnv struct {
+	ctx         context.Context
+	queries     *memdb.Queries
+	workflows   WorkflowService
+	forms       FormService
+	submissions SubmissionService
+	analytics   AnalyticsService
+	webhooks    WebhookService
+	owner       string
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	queries := memdb.New()
+	return &testEnv{
+		ctx:         context.Background(),
+		queries:     queries,
+		workflows:   NewWorkflowService(queries),
+		forms:       NewFormService(queries),
+		submissions: NewSubmissionService(queries),
+		analytics:   NewAnalyticsService(queries),
+		webhooks:    NewWebhookService(queries),
+		owner:       uuid.NewString(),
+	}
+}
+
+// fakeClock is a settable clock for memdb.Queries.SetClock.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func (e *testEnv) setClock(start time.Time) *fakeClock {
+	clock := &fakeClock{now: start}
+	e.queries.SetClock(clock.Now)
+	return clock
+}
+
+func (e *testEnv) createForm(t *testing.T, fields ...models.Field) *models.Form {
+	t.Helper()
+
+	form, err := e.forms.CreateForm(e.ctx, CreateFormRequest{
+		Name:    "Contact",
+		Schema:  schemaMap(t, models.FormSchema{Fields: fields}),
+		OwnerID: e.owner,
+	})
+	if err != nil {
+		t.Fatalf("CreateForm: %v", err)
+	}
+	return form
+}
+
+// workflowOption sets up the request of createWorkflow and activeWorkflow.
+type workflowOption func(req *CreateWorkflowRequest)
+
+// withActions gives the workflow actions.
+func withActions(actions ...models.Action) workflowOption {
+	return func(req *CreateWorkflowRequest) {
+		req.Actions = actions
+	}
+}
+
+// withTrigger gives the workflow a trigger of the given type.
+func withTrigger(triggerType models.TriggerType, config map[string]interface{}) workflowOption {
+	return func(req *CreateWorkflowRequest) {
+		req.TriggerType = triggerType
+		req.TriggerConfig = config
+	}
+}
+
+// withSchedule gives the workflow a schedule trigger with cronExpr in Nairobi time.
+func withSchedule(cronExpr string) workflowOption {
+	return withTrigger(models.TriggerTypeSchedule, map[string]interface{}{"cron": cronExpr, "timeZone": "Africa/Nairobi"})
+}
+
+func (e *testEnv) createWorkflow(t *testing.T, schemaID *string, opts ...workflowOption) *models.Workflow {
+	t.Helper()
+
+	req := CreateWorkflowRequest{
+		Name:     "Intake",
+		OwnerID:  e.owner,
+		SchemaID: schemaID,
+	}
+	for _, opt := range opts {
+		opt(&req)
+	}
+	workflow, err := e.workflows.CreateWorkflow(e.ctx, req)
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	return workflow
+}
+
+func (e *testEnv) activeWorkflow(t *testing.T, schemaID *string, opts ...workflowOption) *models.Workflow {
+	t.Helper()
+
+	created := e.createWorkflow(t, schemaID, opts...)
+	workflow, err := e.workflows.UpdateWorkflowStatus(e.ctx, created.ID, models.WorkflowStatusActive, e.owner)
+	if err != nil {
+		t.Fatalf("UpdateWorkflowStatus: %v", err)
+	}
+	// Only CreateWorkflow returns these
+	workflow.Webhook, workflow.Schedule, workflow.SigningSecret = created.Webhook, created.Schedule, created.SigningSecret
+	return workflow
+}
+
+func (e *testEnv) submit(t *testing.T, workflowID string, data map[string]interface{}) *models.Submission {
+	t.Helper()
+
+	submission, err := e.submissions.CreateSubmission(e.ctx, CreateSubmissionRequest{
+		WorkflowID: workflowID,
+		Data:       data,
+	})
+	if err != nil {
+		t.Fatalf("CreateSubmission: %v", err)
+	}
+	return submission
+}
+
+func schemaMap(t *testing.T, schema models.FormSchema) map[string]interface{} {
+	t.Helper()
+
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// assertError fails unless err is a domain error of the given kind and code.
+func assertError(t *testing.T, err error, kind ErrorKind, code string) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatalf("got no error, want %s %s", kind, code)
+	}
+	e := AsError(err)
+	if e == nil {
+		t.Fatalf("got untyped error %v, want %s %s", err, kind, code)
+	}
+	if e.Kind != kind || e.Code != code {
+		t.Fatalf("got %s %s (%v), want %s %s", e.Kind, e.Code, err, kind, code)
+	}
+}
+
+func assertIs(t *testing.T, err, target error) {
+	t.Helper()
+
+	if !errors.Is(err, target) {
+		t.Fatalf("got %v, want %v", err, target)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func mustUUID(id string) pgtype.UUID {
+	return pgtype.UUID{Bytes: uuid.MustParse(id), Valid: true}
+}
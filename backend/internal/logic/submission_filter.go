@@ -0,0 +1,199 @@
+package logic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FilterOperator compares a value inside submission data with a filter value.
+type FilterOperator string
+
+const (
+	FilterEq       FilterOperator = "eq"
+	FilterNe       FilterOperator = "ne"
+	FilterGt       FilterOperator = "gt"
+	FilterGte      FilterOperator = "gte"
+	FilterLt       FilterOperator = "lt"
+	FilterLte      FilterOperator = "lte"
+	FilterContains FilterOperator = "contains"
+	FilterIn       FilterOperator = "in"
+)
+
+// DataFilter matches submissions by a value inside their JSONB data,
+// e.g. `data.email eq "x@y.com"` or `data.score gte 8`.
+type DataFilter struct {
+	Path     []string       // Field ID, followed by keys for nested objects.
+	Operator FilterOperator // How the stored value is compared with Value.
+	Value    interface{}    // A JSON scalar, or an array of scalars for "in".
+}
+
+// filterPathSegment restricts path segments so they can be quoted into a jsonpath safely.
+var filterPathSegment = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ParseDataFilter parses a filter of the form `data.<fieldId> <operator> <json value>`.
+func ParseDataFilter(expr string) (DataFilter, error) {
+	path, rest, _ := strings.Cut(strings.TrimSpace(expr), " ")
+	op, value, _ := strings.Cut(strings.TrimSpace(rest), " ")
+
+	field, ok := strings.CutPrefix(path, "data.")
+	if !ok || field == "" {
+		return DataFilter{}, fmt.Errorf("filter %q must start with data.<fieldId>", expr)
+	}
+
+	filter := DataFilter{
+		Path:     strings.Split(field, "."),
+		Operator: FilterOperator(op),
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return DataFilter{}, fmt.Errorf("filter %q is missing a value", expr)
+	}
+
+	dec := json.NewDecoder(strings.NewReader(value))
+	dec.UseNumber()
+	if err := dec.Decode(&filter.Value); err != nil || dec.More() {
+		return DataFilter{}, fmt.Errorf("filter %q has an invalid value; strings must be double-quoted", expr)
+	}
+
+	if err := filter.validate(); err != nil {
+		return DataFilter{}, fmt.Errorf("filter %q: %w", expr, err)
+	}
+
+	return filter, nil
+}
+
+func (f DataFilter) validate() error {
+	for _, segment := range f.Path {
+		if !filterPathSegment.MatchString(segment) {
+			return fmt.Errorf("field path may only contain letters, digits, '_' and '-'")
+		}
+	}
+
+	switch f.Operator {
+	case FilterEq, FilterNe:
+		if !isScalar(f.Value) {
+			return fmt.Errorf("%s needs a string, number, boolean or null", f.Operator)
+		}
+	case FilterGt, FilterGte, FilterLt, FilterLte:
+		switch f.Value.(type) {
+		case string, json.Number:
+		default:
+			return fmt.Errorf("%s needs a string or number", f.Operator)
+		}
+	case FilterContains:
+		if !isScalar(f.Value) || f.Value == nil {
+			return fmt.Errorf("contains needs a string, number or boolean")
+		}
+	case FilterIn:
+		values, ok := f.Value.([]interface{})
+		if !ok || len(values) == 0 {
+			return fmt.Errorf("in needs a non-empty array")
+		}
+		for _, v := range values {
+			if !isScalar(v) {
+				return fmt.Errorf("in values must be strings, numbers, booleans or null")
+			}
+		}
+	default:
+		return fmt.Errorf("unknown operator %q", f.Operator)
+	}
+
+	values, ok := f.Value.([]interface{})
+	if !ok {
+		values = []interface{}{f.Value}
+	}
+	for _, v := range values {
+		if n, ok := v.(json.Number); ok {
+			if _, err := n.Float64(); err != nil {
+				return fmt.Errorf("number %s is out of range", n)
+			}
+		}
+	}
+
+	return nil
+}
+
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case nil, string, json.Number, bool:
+		return true
+	default:
+		return false
+	}
+}
+
+// compileDataFilters translates filters into a single jsonpath predicate for the
+// `data @@ $n::jsonpath` condition. The result is always sent as a bound parameter,
+// never spliced into SQL. An empty string means no filtering.
+func compileDataFilters(filters []DataFilter) (string, error) {
+	parts := make([]string, 0, len(filters))
+
+	for _, f := range filters {
+		if err := f.validate(); err != nil {
+			return "", err
+		}
+
+		path := "$"
+		for _, segment := range f.Path {
+			path += "." + jsonPathLiteral(segment)
+		}
+
+		var part string
+		switch f.Operator {
+		case FilterEq:
+			part = path + " == " + jsonPathLiteral(f.Value)
+		case FilterNe:
+			part = path + " != " + jsonPathLiteral(f.Value)
+		case FilterGt:
+			part = path + " > " + jsonPathLiteral(f.Value)
+		case FilterGte:
+			part = path + " >= " + jsonPathLiteral(f.Value)
+		case FilterLt:
+			part = path + " < " + jsonPathLiteral(f.Value)
+		case FilterLte:
+			part = path + " <= " + jsonPathLiteral(f.Value)
+		case FilterContains:
+			// Substring match for strings; lax mode also applies it to each array element
+			if s, ok := f.Value.(string); ok {
+				part = path + " like_regex " + jsonPathLiteral(regexp.QuoteMeta(s))
+			} else {
+				part = path + "[*] == " + jsonPathLiteral(f.Value)
+			}
+		case FilterIn:
+			values := f.Value.([]interface{})
+			alternatives := make([]string, len(values))
+			for i, v := range values {
+				alternatives[i] = path + " == " + jsonPathLiteral(v)
+			}
+			part = strings.Join(alternatives, " || ")
+		}
+
+		parts = append(parts, "("+part+")")
+	}
+
+	return strings.Join(parts, " && "), nil
+}
+
+// jsonPathLiteral renders a JSON scalar as a jsonpath literal.
+func jsonPathLiteral(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(value)
+	case json.Number:
+		// Written as given, so large integers keep their precision
+		return value.String()
+	default:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.Encode(fmt.Sprint(value))
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+}
@@ -3,7 +3,9 @@ package logic
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 
 	"github.com/google/uuid"
 	"github.com/hungaikev/rootd/backend/internal/db"
@@ -12,57 +14,91 @@ import (
 )
 
 type submissionService struct {
-	queries *db.Queries
+	queries db.Store
 }
 
 // NewSubmissionService creates a new submission service
-func NewSubmissionService(queries *db.Queries) SubmissionService {
+func NewSubmissionService(queries db.Store) SubmissionService {
 	return &submissionService{
 		queries: queries,
 	}
 }
 
 func (s *submissionService) CreateSubmission(ctx context.Context, req CreateSubmissionRequest) (*models.Submission, error) {
+	return s.createSubmission(ctx, req, true)
+}
+
+// createSubmission stores a submission and queues it for the engine. fromForm is set for
+// the public form endpoint, which only feeds workflows that acceptsForms.
+func (s *submissionService) createSubmission(ctx context.Context, req CreateSubmissionRequest, fromForm bool) (*models.Submission, error) {
 	// Validate business rules
 	if err := s.validateCreateSubmission(req); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return nil, validationFailed(err)
 	}
 
-	// Check if workflow is active
 	workflowID, err := uuid.Parse(req.WorkflowID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid workflow ID: %w", err)
-	}
-
-	workflow, err := s.queries.GetWorkflow(ctx, pgtype.UUID{Bytes: workflowID, Valid: true})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get workflow: %w", err)
-	}
-
-	if workflow.Status != string(models.WorkflowStatusActive) {
-		return nil, fmt.Errorf("workflow is not active and cannot accept submissions")
+		return nil, invalidID("workflow", err)
 	}
 
 	// Convert request to database params
-	data, _ := json.Marshal(req.Data)
 	metadata, _ := json.Marshal(req.Metadata)
 
-	params := db.CreateSubmissionParams{
-		WorkflowID: pgtype.UUID{Bytes: workflowID, Valid: true},
-		Data:       data,
-		Metadata:   metadata,
-		Status:     string(models.SubmissionStatusPending),
-	}
-
+	var requestSchemaID pgtype.UUID
 	if req.SchemaID != nil {
-		schemaID := uuid.MustParse(*req.SchemaID)
-		params.SchemaID = pgtype.UUID{Bytes: schemaID, Valid: true}
+		schemaID, err := uuid.Parse(*req.SchemaID)
+		if err != nil {
+			return nil, validationFailed(fmt.Errorf("schema ID must be a UUID"))
+		}
+		requestSchemaID = pgtype.UUID{Bytes: schemaID, Valid: true}
 	}
 
-	// Create submission in database
-	submission, err := s.queries.CreateSubmission(ctx, &params)
+	// The workflow must still be active, with the same form, when the submission is stored
+	var submission *db.Submission
+	err = inTx(ctx, s.queries, func(q db.Querier) error {
+		workflow, err := q.GetWorkflow(ctx, pgtype.UUID{Bytes: workflowID, Valid: true})
+		if err != nil {
+			return dbNotFound(err, "workflow", "failed to get workflow")
+		}
+
+		if workflow.Status != string(models.WorkflowStatusActive) {
+			return Conflict(ErrWorkflowNotActive.Code, "workflow is not active and cannot accept submissions", nil)
+		}
+		if fromForm && !acceptsForms(workflow.Trigger) {
+			return ErrTriggerNotForm
+		}
+
+		// Validate the data against the workflow's form schema, which also computes its calculations
+		values := req.Data
+		if workflow.SchemaID.Valid {
+			if values, err = s.validateAgainstForm(ctx, q, workflow.SchemaID, req.Data); err != nil {
+				return err
+			}
+		}
+		data, _ := json.Marshal(values)
+
+		params := db.CreateSubmissionParams{
+			WorkflowID: pgtype.UUID{Bytes: workflowID, Valid: true},
+			SchemaID:   workflow.SchemaID,
+			Data:       data,
+			Metadata:   metadata,
+			Status:     string(models.SubmissionStatusPending),
+		}
+
+		if !params.SchemaID.Valid {
+			params.SchemaID = requestSchemaID
+		}
+
+		// Create submission in database
+		if submission, err = q.CreateSubmission(ctx, &params); err != nil {
+			return dbError(err, "failed to create submission")
+		}
+
+		// The engine picks the submission up from the queue
+		return enqueueSubmission(ctx, q, submission.ID)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create submission: %w", err)
+		return nil, err
 	}
 
 	// Convert database model to business model
@@ -72,50 +108,130 @@ func (s *submissionService) CreateSubmission(ctx context.Context, req CreateSubm
 func (s *submissionService) GetSubmission(ctx context.Context, id string) (*models.Submission, error) {
 	submissionID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid submission ID: %w", err)
+		return nil, invalidID("submission", err)
 	}
 
 	submission, err := s.queries.GetSubmission(ctx, pgtype.UUID{Bytes: submissionID, Valid: true})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get submission: %w", err)
+		return nil, dbNotFound(err, "submission", "failed to get submission")
 	}
 
-	return s.dbToModel(*submission), nil
+	runs, err := s.queries.ListActionRunsBySubmission(ctx, submission.ID)
+	if err != nil {
+		return nil, dbError(err, "failed to list action runs")
+	}
+
+	result := s.dbToModel(*submission)
+	result.Runs = summarizeActionRuns(runs)
+	return result, nil
 }
 
-func (s *submissionService) ListSubmissions(ctx context.Context, workflowID string) ([]*models.Submission, error) {
+func (s *submissionService) ListSubmissions(ctx context.Context, workflowID string, opts ListSubmissionsOptions) (*SubmissionPage, error) {
 	workflowUUID, err := uuid.Parse(workflowID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid workflow ID: %w", err)
+		return nil, invalidID("workflow", err)
 	}
 
-	submissions, err := s.queries.ListSubmissions(ctx, pgtype.UUID{Bytes: workflowUUID, Valid: true})
+	filter := submissionFilter{workflowID: pgtype.UUID{Bytes: workflowUUID, Valid: true}}
+	return s.listSubmissions(ctx, filter, opts)
+}
+
+func (s *submissionService) ListSubmissionsByOwner(ctx context.Context, ownerID string, opts ListSubmissionsOptions) (*SubmissionPage, error) {
+	ownerUUID, err := uuid.Parse(ownerID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list submissions: %w", err)
+		return nil, fmt.Errorf("invalid owner ID: %w", err)
 	}
 
-	result := make([]*models.Submission, len(submissions))
-	for i, submission := range submissions {
-		result[i] = s.dbToModel(*submission)
-	}
+	filter := submissionFilter{ownerID: pgtype.UUID{Bytes: ownerUUID, Valid: true}}
+	return s.listSubmissions(ctx, filter, opts)
+}
 
-	return result, nil
+// submissionFilter scopes a listing to a workflow or to every workflow of an owner.
+type submissionFilter struct {
+	workflowID pgtype.UUID
+	ownerID    pgtype.UUID
 }
 
-func (s *submissionService) ListSubmissionsByOwner(ctx context.Context, ownerID string) ([]*models.Submission, error) {
-	ownerUUID, err := uuid.Parse(ownerID)
+// listSubmissions returns one page of submissions in (created_at, id) order, along with
+// the cursor of the next page and the total number of rows matching the filters.
+func (s *submissionService) listSubmissions(ctx context.Context, filter submissionFilter, opts ListSubmissionsOptions) (*SubmissionPage, error) {
+	if err := s.validateListOptions(&opts); err != nil {
+		return nil, invalidListOptions(err)
+	}
+
+	count := db.CountSubmissionsParams{
+		WorkflowID: filter.workflowID,
+		OwnerID:    filter.ownerID,
+	}
+	if opts.Status != nil {
+		count.Status = pgtype.Text{String: string(*opts.Status), Valid: true}
+	}
+	if opts.CreatedFrom != nil {
+		count.CreatedFrom = pgtype.Timestamptz{Time: *opts.CreatedFrom, Valid: true}
+	}
+	if opts.CreatedTo != nil {
+		count.CreatedTo = pgtype.Timestamptz{Time: *opts.CreatedTo, Valid: true}
+	}
+	if len(opts.DataFilters) > 0 {
+		dataFilter, err := compileDataFilters(opts.DataFilters)
+		if err != nil {
+			return nil, invalidListOptions(err)
+		}
+		count.DataFilter = pgtype.Text{String: dataFilter, Valid: true}
+	}
+
+	page := db.ListSubmissionsPageDescParams{
+		WorkflowID:  count.WorkflowID,
+		OwnerID:     count.OwnerID,
+		Status:      count.Status,
+		CreatedFrom: count.CreatedFrom,
+		CreatedTo:   count.CreatedTo,
+		DataFilter:  count.DataFilter,
+		// Fetch one extra row to learn whether another page follows
+		PageLimit: int32(opts.Limit + 1),
+	}
+	if opts.Cursor != "" {
+		cursor, err := decodeSubmissionCursor(opts.Cursor)
+		if err != nil {
+			return nil, invalidListOptions(err)
+		}
+		page.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.CreatedAt, Valid: true}
+		page.CursorID = pgtype.UUID{Bytes: cursor.ID, Valid: true}
+	}
+
+	var submissions []*db.Submission
+	var err error
+	if opts.Sort == SortAscending {
+		asc := db.ListSubmissionsPageAscParams(page)
+		submissions, err = s.queries.ListSubmissionsPageAsc(ctx, &asc)
+	} else {
+		submissions, err = s.queries.ListSubmissionsPageDesc(ctx, &page)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("invalid owner ID: %w", err)
+		return nil, dbError(err, "failed to list submissions")
 	}
 
-	submissions, err := s.queries.ListSubmissionsByOwner(ctx, pgtype.UUID{Bytes: ownerUUID, Valid: true})
+	total, err := s.queries.CountSubmissions(ctx, &count)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list submissions by owner: %w", err)
+		return nil, dbError(err, "failed to count submissions")
+	}
+
+	result := &SubmissionPage{
+		Submissions: make([]*models.Submission, 0, len(submissions)),
+		TotalCount:  total,
 	}
 
-	result := make([]*models.Submission, len(submissions))
-	for i, submission := range submissions {
-		result[i] = s.dbToModel(*submission)
+	if len(submissions) > opts.Limit {
+		submissions = submissions[:opts.Limit]
+		last := submissions[len(submissions)-1]
+		result.NextCursor = encodeSubmissionCursor(submissionCursor{
+			CreatedAt: last.CreatedAt,
+			ID:        last.ID.Bytes,
+		})
+	}
+
+	for _, submission := range submissions {
+		result.Submissions = append(result.Submissions, s.dbToModel(*submission))
 	}
 
 	return result, nil
@@ -124,12 +240,12 @@ func (s *submissionService) ListSubmissionsByOwner(ctx context.Context, ownerID
 func (s *submissionService) UpdateSubmissionStatus(ctx context.Context, id string, status models.SubmissionStatus) (*models.Submission, error) {
 	submissionID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid submission ID: %w", err)
+		return nil, invalidID("submission", err)
 	}
 
 	// Validate status
 	if err := s.validateSubmissionStatus(status); err != nil {
-		return nil, fmt.Errorf("invalid status: %w", err)
+		return nil, Validation("invalid_status", "invalid status", err)
 	}
 
 	params := db.UpdateSubmissionStatusParams{
@@ -139,7 +255,7 @@ func (s *submissionService) UpdateSubmissionStatus(ctx context.Context, id strin
 
 	submission, err := s.queries.UpdateSubmissionStatus(ctx, &params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update submission status: %w", err)
+		return nil, dbNotFound(err, "submission", "failed to update submission status")
 	}
 
 	return s.dbToModel(*submission), nil
@@ -148,10 +264,38 @@ func (s *submissionService) UpdateSubmissionStatus(ctx context.Context, id strin
 func (s *submissionService) DeleteSubmission(ctx context.Context, id string) error {
 	submissionID, err := uuid.Parse(id)
 	if err != nil {
-		return fmt.Errorf("invalid submission ID: %w", err)
+		return invalidID("submission", err)
 	}
 
-	return s.queries.DeleteSubmission(ctx, pgtype.UUID{Bytes: submissionID, Valid: true})
+	if err := s.queries.DeleteSubmission(ctx, pgtype.UUID{Bytes: submissionID, Valid: true}); err != nil {
+		return dbError(err, "failed to delete submission")
+	}
+
+	return nil
+}
+
+func (s *submissionService) ListActionRuns(ctx context.Context, submissionID string) ([]*models.ActionRun, error) {
+	submissionUUID, err := uuid.Parse(submissionID)
+	if err != nil {
+		return nil, invalidID("submission", err)
+	}
+
+	id := pgtype.UUID{Bytes: submissionUUID, Valid: true}
+	if _, err := s.queries.GetSubmission(ctx, id); err != nil {
+		return nil, dbNotFound(err, "submission", "failed to get submission")
+	}
+
+	runs, err := s.queries.ListActionRunsBySubmission(ctx, id)
+	if err != nil {
+		return nil, dbError(err, "failed to list action runs")
+	}
+
+	result := make([]*models.ActionRun, len(runs))
+	for i, run := range runs {
+		result[i] = actionRunToModel(*run)
+	}
+
+	return result, nil
 }
 
 // Helper methods
@@ -165,6 +309,80 @@ func (s *submissionService) validateCreateSubmission(req CreateSubmissionRequest
 	return nil
 }
 
+// acceptsForms reports whether a workflow with trigger takes submissions from the
+// public form endpoint. Webhook workflows only take signed deliveries and scheduled
+// workflows take none; a workflow without a trigger is manual.
+func acceptsForms(trigger []byte) bool {
+	var t models.Trigger
+	json.Unmarshal(trigger, &t)
+	switch t.Type {
+	case "", models.TriggerTypeManual, models.TriggerTypeFormSubmission:
+		return true
+	}
+	return false
+}
+
+// validateAgainstForm loads the linked form, computes its calculation fields and checks
+// the submitted data against its fields. It returns the data to store.
+func (s *submissionService) validateAgainstForm(ctx context.Context, q db.Querier, formID pgtype.UUID, data map[string]interface{}) (map[string]interface{}, error) {
+	form, err := q.GetForm(ctx, formID)
+	if err != nil {
+		return nil, dbError(err, "failed to get form")
+	}
+
+	var schema models.FormSchema
+	if err := json.Unmarshal(form.Schema, &schema); err != nil {
+		log.Printf("submissions: form %s: %v", formID.String(), err)
+		return nil, ErrInvalidFormSchema
+	}
+
+	// Errors in the submitted fields explain a calculation that failed, so they are reported first
+	data, broken, calcErr := applyCalculations(&schema, data)
+	for id, err := range broken {
+		log.Printf("submissions: form %s: calculation %s left empty: %v", formID.String(), id, err)
+	}
+	if calcErr != nil && !errors.Is(calcErr, ErrInvalidSubmission) {
+		return nil, calcErr
+	}
+	if err := validateSubmissionData(&schema, data); err != nil {
+		return nil, err
+	}
+	if calcErr != nil {
+		return nil, calcErr
+	}
+	return data, nil
+}
+
+// validateListOptions checks the listing options and fills in defaults.
+func (s *submissionService) validateListOptions(opts *ListSubmissionsOptions) error {
+	switch {
+	case opts.Limit == 0:
+		opts.Limit = DefaultSubmissionPageSize
+	case opts.Limit < 0 || opts.Limit > MaxSubmissionPageSize:
+		return fmt.Errorf("limit must be between 1 and %d", MaxSubmissionPageSize)
+	}
+
+	switch opts.Sort {
+	case "":
+		opts.Sort = SortDescending
+	case SortAscending, SortDescending:
+	default:
+		return fmt.Errorf("sort must be %q or %q", SortAscending, SortDescending)
+	}
+
+	if opts.Status != nil {
+		if err := s.validateSubmissionStatus(*opts.Status); err != nil {
+			return err
+		}
+	}
+
+	if opts.CreatedFrom != nil && opts.CreatedTo != nil && !opts.CreatedFrom.Before(*opts.CreatedTo) {
+		return fmt.Errorf("from must be before to")
+	}
+
+	return nil
+}
+
 func (s *submissionService) validateSubmissionStatus(status models.SubmissionStatus) error {
 	validStatuses := []models.SubmissionStatus{
 		models.SubmissionStatusPending,
@@ -184,17 +402,10 @@ func (s *submissionService) validateSubmissionStatus(status models.SubmissionSta
 
 func (s *submissionService) dbToModel(submission db.Submission) *models.Submission {
 	var data map[string]interface{}
-	var metadataData map[string]interface{}
+	var metadata models.SubmissionMetadata
 
 	json.Unmarshal(submission.Data, &data)
-	json.Unmarshal(submission.Metadata, &metadataData)
-
-	// Convert metadata to proper structure
-	metadata := models.SubmissionMetadata{
-		IPAddress: metadataData["ipAddress"].(string),
-		UserAgent: metadataData["userAgent"].(string),
-		Referrer:  metadataData["referrer"].(string),
-	}
+	json.Unmarshal(submission.Metadata, &metadata)
 
 	return &models.Submission{
 		ID:         submission.ID.String(),
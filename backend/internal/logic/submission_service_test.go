@@ -0,0 +1,802 @@
+package logic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/export"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/hungaikev/rootd/backend/internal/queue"
+)
+
+func TestCreateSubmission(t *testing.T) {
+	env := newTestEnv(t)
+	form := env.createForm(t,
+		models.Field{ID: "email", Type: "email", Label: "Email", Required: true},
+		models.Field{ID: "score", Type: "rating", Label: "Score"},
+	)
+	active := env.activeWorkflow(t, &form.ID)
+	unlinked := env.activeWorkflow(t, nil)
+	draft := env.createWorkflow(t, nil)
+
+	paused := env.activeWorkflow(t, nil)
+	if _, err := env.workflows.UpdateWorkflowStatus(env.ctx, paused.ID, models.WorkflowStatusPaused, env.owner); err != nil {
+		t.Fatalf("UpdateWorkflowStatus: %v", err)
+	}
+
+	hooked := env.activeWorkflow(t, nil, withTrigger(models.TriggerTypeWebhook, nil))
+	scheduled := env.activeWorkflow(t, nil, withSchedule("0 9 * * *"))
+
+	valid := map[string]interface{}{"email": "ada@example.com", "score": 5.0}
+
+	tests := []struct {
+		name string
+		req  CreateSubmissionRequest
+		kind ErrorKind
+		code string
+	}{
+		{"valid", CreateSubmissionRequest{WorkflowID: active.ID, Data: valid}, "", ""},
+		{"missing workflow", CreateSubmissionRequest{Data: valid}, KindValidation, "validation_failed"},
+		{"missing data", CreateSubmissionRequest{WorkflowID: active.ID}, KindValidation, "validation_failed"},
+		{"workflow not a UUID", CreateSubmissionRequest{WorkflowID: "intake", Data: valid}, KindNotFound, "workflow_not_found"},
+		{"unknown workflow", CreateSubmissionRequest{WorkflowID: uuid.NewString(), Data: valid}, KindNotFound, "workflow_not_found"},
+		{"draft workflow", CreateSubmissionRequest{WorkflowID: draft.ID, Data: valid}, KindConflict, "workflow_not_active"},
+		{"paused workflow", CreateSubmissionRequest{WorkflowID: paused.ID, Data: valid}, KindConflict, "workflow_not_active"},
+		{"webhook trigger", CreateSubmissionRequest{WorkflowID: hooked.ID, Data: valid}, KindConflict, "trigger_not_form"},
+		{"schedule trigger", CreateSubmissionRequest{WorkflowID: scheduled.ID, Data: valid}, KindConflict, "trigger_not_form"},
+		{"invalid data", CreateSubmissionRequest{WorkflowID: active.ID, Data: map[string]interface{}{"score": 9.0}}, KindValidation, "invalid_submission"},
+		{"schema from request", CreateSubmissionRequest{WorkflowID: unlinked.ID, SchemaID: &form.ID, Data: map[string]interface{}{}}, "", ""},
+		{"schema not a UUID", CreateSubmissionRequest{WorkflowID: unlinked.ID, SchemaID: ptr("contact"), Data: valid}, KindValidation, "validation_failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submission, err := env.submissions.CreateSubmission(env.ctx, tt.req)
+			if tt.kind != "" {
+				assertError(t, err, tt.kind, tt.code)
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateSubmission: %v", err)
+			}
+
+			if submission.Status != models.SubmissionStatusPending {
+				t.Errorf("status = %s, want pending", submission.Status)
+			}
+			if submission.WorkflowID != tt.req.WorkflowID {
+				t.Errorf("workflow ID = %s, want %s", submission.WorkflowID, tt.req.WorkflowID)
+			}
+			if submission.SchemaID != form.ID {
+				t.Errorf("schema ID = %s, want %s", submission.SchemaID, form.ID)
+			}
+		})
+	}
+}
+
+func TestCreateSubmissionFieldErrors(t *testing.T) {
+	env := newTestEnv(t)
+	form := env.createForm(t,
+		models.Field{ID: "email", Type: "email", Required: true},
+		models.Field{ID: "website", Type: "url"},
+	)
+	workflow := env.activeWorkflow(t, &form.ID)
+
+	_, err := env.submissions.CreateSubmission(env.ctx, CreateSubmissionRequest{
+		WorkflowID: workflow.ID,
+		Data:       map[string]interface{}{"website": "ftp://example.com"},
+	})
+	assertIs(t, err, ErrInvalidSubmission)
+
+	fields := AsError(err).Fields
+	if len(fields) != 2 || fields["email"] == "" || fields["website"] == "" {
+		t.Errorf("fields = %v, want errors for email and website", fields)
+	}
+}
+
+func TestCreateSubmissionCalculatesFields(t *testing.T) {
+	env := newTestEnv(t)
+	form := env.createForm(t,
+		// The total comes first but depends on the subtotal after it
+		models.Field{ID: "total", Type: "calculation", Formula: "round({subtotal} * 1.16, 2)", Prefix: "KES"},
+		models.Field{ID: "qty", Type: "number", Required: true},
+		models.Field{ID: "price", Type: "number", Required: true},
+		models.Field{ID: "subtotal", Type: "calculation", Formula: "{qty} * {price}"},
+		models.Field{ID: "big", Type: "text", Conditional: &models.Conditional{Expression: "{total} > 100"}, Required: true},
+		models.Field{ID: "ratio", Type: "calculation", Formula: "{price} / {qty}"},
+	)
+	workflow := env.activeWorkflow(t, &form.ID)
+
+	t.Run("client values are replaced", func(t *testing.T) {
+		submission := env.submit(t, workflow.ID, map[string]interface{}{
+			"qty": 2.0, "price": 25.0, "subtotal": 1.0, "total": 1.0,
+		})
+		want := map[string]interface{}{"qty": 2.0, "price": 25.0, "subtotal": 50.0, "total": 58.0, "ratio": 12.5}
+		if !reflect.DeepEqual(submission.Data, want) {
+			t.Errorf("data = %v, want %v", submission.Data, want)
+		}
+
+		stored, err := env.submissions.GetSubmission(env.ctx, submission.ID)
+		if err != nil {
+			t.Fatalf("GetSubmission: %v", err)
+		}
+		if !reflect.DeepEqual(stored.Data, want) {
+			t.Errorf("stored data = %v, want %v", stored.Data, want)
+		}
+	})
+
+	t.Run("conditionals see calculated values", func(t *testing.T) {
+		_, err := env.submissions.CreateSubmission(env.ctx, CreateSubmissionRequest{
+			WorkflowID: workflow.ID,
+			Data:       map[string]interface{}{"qty": 10.0, "price": 25.0, "total": 1.0},
+		})
+		assertIs(t, err, ErrInvalidSubmission)
+		if fields := AsError(err).Fields; fields["big"] == "" {
+			t.Errorf("fields = %v, want an error for big", fields)
+		}
+	})
+
+	t.Run("formula errors", func(t *testing.T) {
+		_, err := env.submissions.CreateSubmission(env.ctx, CreateSubmissionRequest{
+			WorkflowID: workflow.ID,
+			Data:       map[string]interface{}{"qty": 0.0, "price": 25.0},
+		})
+		assertIs(t, err, ErrInvalidSubmission)
+		if fields := AsError(err).Fields; len(fields) != 1 || !strings.Contains(fields["ratio"], "division by zero") {
+			t.Errorf("fields = %v, want a division by zero for ratio", fields)
+		}
+	})
+
+	t.Run("field errors come first", func(t *testing.T) {
+		_, err := env.submissions.CreateSubmission(env.ctx, CreateSubmissionRequest{
+			WorkflowID: workflow.ID,
+			Data:       map[string]interface{}{"qty": "two", "price": 25.0},
+		})
+		assertIs(t, err, ErrInvalidSubmission)
+		if fields := AsError(err).Fields; fields["qty"] == "" {
+			t.Errorf("fields = %v, want an error for qty", fields)
+		}
+	})
+}
+
+// Forms saved before formulas were checked may have broken calculations, or a schema
+// that does not decode at all.
+func TestCreateSubmissionWithBrokenSchema(t *testing.T) {
+	env := newTestEnv(t)
+	storedForm := func(schema string) *string {
+		form, err := env.queries.CreateForm(env.ctx, &db.CreateFormParams{
+			Name:    "Legacy",
+			Schema:  []byte(schema),
+			OwnerID: mustUUID(env.owner),
+		})
+		if err != nil {
+			t.Fatalf("CreateForm: %v", err)
+		}
+		id := form.ID.String()
+		return &id
+	}
+
+	t.Run("broken calculations are left empty", func(t *testing.T) {
+		workflow := env.activeWorkflow(t, storedForm(`{"fields": [
+			{"id": "qty", "type": "number"},
+			{"id": "a", "type": "calculation", "formula": "{b} + 1"},
+			{"id": "b", "type": "calculation", "formula": "{a} + 1"},
+			{"id": "typo", "type": "calculation", "formula": "{qty} *"},
+			{"id": "empty", "type": "calculation"},
+			{"id": "double", "type": "calculation", "formula": "{qty} * 2"}
+		]}`))
+		submission := env.submit(t, workflow.ID, map[string]interface{}{"qty": 2.0, "a": 5.0, "typo": "x"})
+		want := map[string]interface{}{"qty": 2.0, "double": 4.0}
+		if !reflect.DeepEqual(submission.Data, want) {
+			t.Errorf("data = %v, want %v", submission.Data, want)
+		}
+	})
+
+	t.Run("undecodable schema", func(t *testing.T) {
+		workflow := env.activeWorkflow(t, storedForm(`{"fields": "qty"}`))
+		_, err := env.submissions.CreateSubmission(env.ctx, CreateSubmissionRequest{
+			WorkflowID: workflow.ID,
+			Data:       map[string]interface{}{},
+		})
+		assertIs(t, err, ErrInvalidFormSchema)
+	})
+}
+
+func TestGetSubmission(t *testing.T) {
+	env := newTestEnv(t)
+	workflow := env.activeWorkflow(t, nil)
+	created := env.submit(t, workflow.ID, map[string]interface{}{"name": "Ada"})
+
+	submission, err := env.submissions.GetSubmission(env.ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetSubmission: %v", err)
+	}
+	if submission.ID != created.ID || submission.Data["name"] != "Ada" {
+		t.Errorf("got %+v, want %+v", submission, created)
+	}
+
+	_, err = env.submissions.GetSubmission(env.ctx, uuid.NewString())
+	assertError(t, err, KindNotFound, "submission_not_found")
+
+	_, err = env.submissions.GetSubmission(env.ctx, "not-a-uuid")
+	assertError(t, err, KindNotFound, "submission_not_found")
+}
+
+func TestUpdateSubmissionStatus(t *testing.T) {
+	env := newTestEnv(t)
+	workflow := env.activeWorkflow(t, nil)
+	created := env.submit(t, workflow.ID, map[string]interface{}{})
+
+	for _, status := range []models.SubmissionStatus{
+		models.SubmissionStatusProcessing,
+		models.SubmissionStatusCompleted,
+		models.SubmissionStatusFailed,
+		models.SubmissionStatusPending,
+	} {
+		updated, err := env.submissions.UpdateSubmissionStatus(env.ctx, created.ID, status)
+		if err != nil {
+			t.Fatalf("UpdateSubmissionStatus(%s): %v", status, err)
+		}
+		if updated.Status != status {
+			t.Errorf("status = %s, want %s", updated.Status, status)
+		}
+		if !updated.UpdatedAt.After(created.UpdatedAt) {
+			t.Errorf("updated_at was not bumped")
+		}
+	}
+
+	_, err := env.submissions.UpdateSubmissionStatus(env.ctx, created.ID, "archived")
+	assertError(t, err, KindValidation, "invalid_status")
+
+	_, err = env.submissions.UpdateSubmissionStatus(env.ctx, uuid.NewString(), models.SubmissionStatusCompleted)
+	assertError(t, err, KindNotFound, "submission_not_found")
+}
+
+func TestDeleteSubmission(t *testing.T) {
+	env := newTestEnv(t)
+	workflow := env.activeWorkflow(t, nil)
+	created := env.submit(t, workflow.ID, map[string]interface{}{})
+
+	if err := env.submissions.DeleteSubmission(env.ctx, created.ID); err != nil {
+		t.Fatalf("DeleteSubmission: %v", err)
+	}
+
+	_, err := env.submissions.GetSubmission(env.ctx, created.ID)
+	assertError(t, err, KindNotFound, "submission_not_found")
+
+	// Without submissions the workflow can be deleted again
+	if err := env.workflows.DeleteWorkflow(env.ctx, workflow.ID); err != nil {
+		t.Errorf("DeleteWorkflow: %v", err)
+	}
+
+	err = env.submissions.DeleteSubmission(env.ctx, "not-a-uuid")
+	assertError(t, err, KindNotFound, "submission_not_found")
+}
+
+func TestCreateSubmissionEnqueuesJob(t *testing.T) {
+	env := newTestEnv(t)
+	active := env.activeWorkflow(t, nil)
+	draft := env.createWorkflow(t, nil)
+
+	submission := env.submit(t, active.ID, map[string]interface{}{})
+	_, err := env.submissions.CreateSubmission(env.ctx, CreateSubmissionRequest{WorkflowID: draft.ID, Data: map[string]interface{}{}})
+	assertError(t, err, KindConflict, "workflow_not_active")
+
+	jobs, err := env.queries.ListJobsByStatus(env.ctx, queue.StatusQueued)
+	if err != nil {
+		t.Fatalf("ListJobsByStatus: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("%d jobs queued, want one for the stored submission", len(jobs))
+	}
+
+	var payload submissionJob
+	if err := json.Unmarshal(jobs[0].Payload, &payload); err != nil {
+		t.Fatalf("job payload: %v", err)
+	}
+	if jobs[0].Kind != JobProcessSubmission || payload.SubmissionID != submission.ID {
+		t.Errorf("job is %s for submission %s, want %s for %s", jobs[0].Kind, payload.SubmissionID, JobProcessSubmission, submission.ID)
+	}
+}
+
+func TestProcessSubmissionJob(t *testing.T) {
+	env := newTestEnv(t)
+	recorder := &recordingExecutor{}
+	engine := NewExecutionEngine(env.queries, recorder)
+	pool := queue.NewPool(env.queries, queue.Config{MaxAttempts: 2, BaseBackoff: time.Nanosecond})
+	pool.Handle(JobProcessSubmission, engine.ProcessSubmissionJob)
+	workflow := env.activeWorkflow(t, nil, withActions(models.Action{ID: "notify", Type: models.ActionTypeNotification}))
+
+	work := func() {
+		t.Helper()
+		if claimed, err := pool.Work(env.ctx); err != nil || !claimed {
+			t.Fatalf("Work = %v, %v, want a job run", claimed, err)
+		}
+	}
+	status := func(id string) models.SubmissionStatus {
+		t.Helper()
+		submission, err := env.submissions.GetSubmission(env.ctx, id)
+		if err != nil {
+			t.Fatalf("GetSubmission: %v", err)
+		}
+		return submission.Status
+	}
+	deadJobs := func() int {
+		t.Helper()
+		jobs, err := env.queries.ListJobsByStatus(env.ctx, queue.StatusDead)
+		if err != nil {
+			t.Fatalf("ListJobsByStatus: %v", err)
+		}
+		return len(jobs)
+	}
+
+	// A failed attempt leaves the submission failed until the retry succeeds
+	recovered := env.submit(t, workflow.ID, map[string]interface{}{})
+	recorder.err = errors.New("smtp unavailable")
+	work()
+	if got := status(recovered.ID); got != models.SubmissionStatusFailed {
+		t.Errorf("status after the failed attempt = %s, want failed", got)
+	}
+	recorder.err = nil
+	work()
+	if got := status(recovered.ID); got != models.SubmissionStatusCompleted {
+		t.Errorf("status after the retry = %s, want completed", got)
+	}
+
+	// Out of attempts, the job is dead-lettered
+	failed := env.submit(t, workflow.ID, map[string]interface{}{})
+	recorder.err = errors.New("smtp unavailable")
+	work()
+	work()
+	if got := status(failed.ID); got != models.SubmissionStatusFailed || deadJobs() != 1 {
+		t.Errorf("submission is %s with %d dead jobs, want failed with one", got, deadJobs())
+	}
+	recorder.err = nil
+
+	// Jobs for deleted or already processed submissions have nothing left to do
+	deleted := env.submit(t, workflow.ID, map[string]interface{}{})
+	if err := env.submissions.DeleteSubmission(env.ctx, deleted.ID); err != nil {
+		t.Fatalf("DeleteSubmission: %v", err)
+	}
+	processed := env.submit(t, workflow.ID, map[string]interface{}{})
+	if err := engine.ProcessSubmission(env.ctx, processed.ID); err != nil {
+		t.Fatalf("ProcessSubmission: %v", err)
+	}
+	recorder.calls = nil
+	work()
+	work()
+	if len(recorder.calls) != 0 || deadJobs() != 1 {
+		t.Errorf("executor ran %d times with %d dead jobs, want no runs and no new dead jobs", len(recorder.calls), deadJobs())
+	}
+	if claimed, _ := pool.Work(env.ctx); claimed {
+		t.Error("jobs are left in the queue")
+	}
+}
+
+func TestListSubmissionsPagination(t *testing.T) {
+	env := newTestEnv(t)
+	workflow := env.activeWorkflow(t, nil)
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		ids = append(ids, env.submit(t, workflow.ID, map[string]interface{}{"n": float64(i)}).ID)
+	}
+
+	tests := []struct {
+		name string
+		sort SortDirection
+		want []string
+	}{
+		{"newest first by default", "", reversed(ids)},
+		{"ascending", SortAscending, ids},
+		{"descending", SortDescending, reversed(ids)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			opts := ListSubmissionsOptions{Limit: 2, Sort: tt.sort}
+			for pages := 0; ; pages++ {
+				if pages > len(ids) {
+					t.Fatal("pagination did not terminate")
+				}
+
+				page, err := env.submissions.ListSubmissions(env.ctx, workflow.ID, opts)
+				if err != nil {
+					t.Fatalf("ListSubmissions: %v", err)
+				}
+				if page.TotalCount != int64(len(ids)) {
+					t.Errorf("total count = %d, want %d", page.TotalCount, len(ids))
+				}
+				for _, submission := range page.Submissions {
+					got = append(got, submission.ID)
+				}
+
+				if page.NextCursor == "" {
+					break
+				}
+				opts.Cursor = page.NextCursor
+			}
+
+			if !equalStrings(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("default limit", func(t *testing.T) {
+		page, err := env.submissions.ListSubmissions(env.ctx, workflow.ID, ListSubmissionsOptions{})
+		if err != nil {
+			t.Fatalf("ListSubmissions: %v", err)
+		}
+		if len(page.Submissions) != len(ids) || page.NextCursor != "" {
+			t.Errorf("got %d submissions and cursor %q, want all of them on one page", len(page.Submissions), page.NextCursor)
+		}
+	})
+
+	t.Run("exact page", func(t *testing.T) {
+		page, err := env.submissions.ListSubmissions(env.ctx, workflow.ID, ListSubmissionsOptions{Limit: len(ids)})
+		if err != nil {
+			t.Fatalf("ListSubmissions: %v", err)
+		}
+		if page.NextCursor != "" {
+			t.Errorf("cursor = %q on the last page", page.NextCursor)
+		}
+	})
+}
+
+func TestListSubmissionsFilters(t *testing.T) {
+	env := newTestEnv(t)
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	clock := env.setClock(start)
+	workflow := env.activeWorkflow(t, nil)
+
+	// One submission per day; the second one is completed
+	var ids []string
+	for i := 0; i < 3; i++ {
+		clock.Advance(24 * time.Hour)
+		ids = append(ids, env.submit(t, workflow.ID, map[string]interface{}{}).ID)
+	}
+	if _, err := env.submissions.UpdateSubmissionStatus(env.ctx, ids[1], models.SubmissionStatusCompleted); err != nil {
+		t.Fatalf("UpdateSubmissionStatus: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts ListSubmissionsOptions
+		want []string
+	}{
+		{"status", ListSubmissionsOptions{Status: ptr(models.SubmissionStatusCompleted)}, []string{ids[1]}},
+		{"created from is inclusive", ListSubmissionsOptions{CreatedFrom: ptr(start.Add(48 * time.Hour)), Sort: SortAscending}, ids[1:]},
+		{"created to is exclusive", ListSubmissionsOptions{CreatedTo: ptr(start.Add(48 * time.Hour)), Sort: SortAscending}, ids[:1]},
+		{"range and status", ListSubmissionsOptions{CreatedFrom: ptr(start), CreatedTo: ptr(start.Add(72 * time.Hour)), Status: ptr(models.SubmissionStatusPending)}, ids[:1]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := env.submissions.ListSubmissions(env.ctx, workflow.ID, tt.opts)
+			if err != nil {
+				t.Fatalf("ListSubmissions: %v", err)
+			}
+
+			var got []string
+			for _, submission := range page.Submissions {
+				got = append(got, submission.ID)
+			}
+			if !equalStrings(got, tt.want) || page.TotalCount != int64(len(tt.want)) {
+				t.Errorf("got %v (total %d), want %v", got, page.TotalCount, tt.want)
+			}
+		})
+	}
+}
+
+func TestListSubmissionsByOwner(t *testing.T) {
+	env := newTestEnv(t)
+	first := env.activeWorkflow(t, nil)
+	second := env.activeWorkflow(t, nil)
+	a := env.submit(t, first.ID, map[string]interface{}{})
+	b := env.submit(t, second.ID, map[string]interface{}{})
+
+	// Another owner's submissions are not listed
+	other := *env
+	other.owner = uuid.NewString()
+	other.submit(t, other.activeWorkflow(t, nil).ID, map[string]interface{}{})
+
+	page, err := env.submissions.ListSubmissionsByOwner(env.ctx, env.owner, ListSubmissionsOptions{})
+	if err != nil {
+		t.Fatalf("ListSubmissionsByOwner: %v", err)
+	}
+
+	var got []string
+	for _, submission := range page.Submissions {
+		got = append(got, submission.ID)
+	}
+	if want := []string{b.ID, a.ID}; !equalStrings(got, want) || page.TotalCount != 2 {
+		t.Errorf("got %v (total %d), want %v", got, page.TotalCount, want)
+	}
+
+	if _, err := env.submissions.ListSubmissionsByOwner(env.ctx, "alice", ListSubmissionsOptions{}); err == nil {
+		t.Error("expected an error for an invalid owner ID")
+	}
+}
+
+func TestListSubmissionsInvalidOptions(t *testing.T) {
+	env := newTestEnv(t)
+	workflow := env.activeWorkflow(t, nil)
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		opts ListSubmissionsOptions
+	}{
+		{"negative limit", ListSubmissionsOptions{Limit: -1}},
+		{"limit too large", ListSubmissionsOptions{Limit: MaxSubmissionPageSize + 1}},
+		{"unknown sort", ListSubmissionsOptions{Sort: "sideways"}},
+		{"unknown status", ListSubmissionsOptions{Status: ptr(models.SubmissionStatus("archived"))}},
+		{"empty range", ListSubmissionsOptions{CreatedFrom: &now, CreatedTo: &now}},
+		{"malformed cursor", ListSubmissionsOptions{Cursor: "not a cursor"}},
+		{"cursor without ID", ListSubmissionsOptions{Cursor: "MjAyNi0wMS0wMVQwMDowMDowMFo"}},
+		{"invalid data filter", ListSubmissionsOptions{DataFilters: []DataFilter{{Path: []string{"a b"}, Operator: FilterEq, Value: "x"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.submissions.ListSubmissions(env.ctx, workflow.ID, tt.opts)
+			assertIs(t, err, ErrInvalidListOptions)
+		})
+	}
+
+	_, err := env.submissions.ListSubmissions(env.ctx, "not-a-uuid", ListSubmissionsOptions{})
+	assertError(t, err, KindNotFound, "workflow_not_found")
+}
+
+func TestSubmissionCursorRoundTrip(t *testing.T) {
+	want := submissionCursor{
+		CreatedAt: time.Date(2026, 5, 4, 3, 2, 1, 123456000, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	got, err := decodeSubmissionCursor(encodeSubmissionCursor(want))
+	if err != nil {
+		t.Fatalf("decodeSubmissionCursor: %v", err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseDataFilter(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{expr: `data.email eq "x@y.com"`, want: `($."email" == "x@y.com")`},
+		{expr: `data.score gte 8`, want: `($."score" >= 8)`},
+		{expr: `data.id eq 9007199254740993`, want: `($."id" == 9007199254740993)`},
+		{expr: `data.score lt -1.5e3`, want: `($."score" < -1.5e3)`},
+		{expr: `data.address.city ne "Nairobi"`, want: `($."address"."city" != "Nairobi")`},
+		{expr: `data.tags contains "vip"`, want: `($."tags" like_regex "vip")`},
+		{expr: `data.tags contains 3`, want: `($."tags"[*] == 3)`},
+		{expr: `data.plan in ["pro", "team"]`, want: `($."plan" == "pro" || $."plan" == "team")`},
+		{expr: `email eq "x"`, wantErr: true},
+		{expr: `data.email eq`, wantErr: true},
+		{expr: `data.email eq x`, wantErr: true},
+		{expr: `data.email like "x"`, wantErr: true},
+		{expr: `data.score gt true`, wantErr: true},
+		{expr: `data.plan in []`, wantErr: true},
+		{expr: `data.a$b eq 1`, wantErr: true},
+		{expr: `data.score gte 1e400`, wantErr: true},
+		{expr: `data.score in [1, -1e400]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			filter, err := ParseDataFilter(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got filter %+v, want an error", filter)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDataFilter: %v", err)
+			}
+
+			got, err := compileDataFilters([]DataFilter{filter})
+			if err != nil {
+				t.Fatalf("compileDataFilters: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSubmissionData(t *testing.T) {
+	one, five, half := 1.0, 5.0, 0.5
+	options := []models.Option{{Value: "a"}, {Value: "b"}}
+
+	tests := []struct {
+		name  string
+		field models.Field
+		value interface{}
+		valid bool
+	}{
+		{"required missing", models.Field{Type: "text", Required: true}, nil, false},
+		{"required blank", models.Field{Type: "text", Required: true}, "  ", false},
+		{"optional missing", models.Field{Type: "text"}, nil, true},
+		{"text", models.Field{Type: "text"}, "hi", true},
+		{"text not a string", models.Field{Type: "text"}, 1.0, false},
+		{"min length", models.Field{Type: "text", Validation: &models.Validation{MinLength: 3}}, "hi", false},
+		{"max length", models.Field{Type: "textarea", Validation: &models.Validation{MaxLength: 2}}, "hey", false},
+		{"pattern", models.Field{Type: "text", Validation: &models.Validation{Pattern: `^\d+$`}}, "12", true},
+		{"pattern mismatch", models.Field{Type: "text", Validation: &models.Validation{Pattern: `^\d+$`}}, "1a", false},
+		{"email", models.Field{Type: "email"}, "ada@example.com", true},
+		{"email invalid", models.Field{Type: "email"}, "Ada <ada@example.com>", false},
+		{"url", models.Field{Type: "url"}, "https://example.com", true},
+		{"url without host", models.Field{Type: "url"}, "https://", false},
+		{"phone", models.Field{Type: "phone"}, "+254 700 000000", true},
+		{"phone invalid", models.Field{Type: "phone"}, "call me", false},
+		{"number", models.Field{Type: "number", Min: &one, Max: &five}, 3.0, true},
+		{"number below min", models.Field{Type: "number", Min: &one}, 0.0, false},
+		{"number above max", models.Field{Type: "slider", Max: &five}, 6.0, false},
+		{"number step", models.Field{Type: "number", Step: &half}, 1.5, true},
+		{"number off step", models.Field{Type: "number", Step: &half}, 1.2, false},
+		{"number not a number", models.Field{Type: "number"}, "3", false},
+		{"rating", models.Field{Type: "rating"}, 5.0, true},
+		{"rating out of default scale", models.Field{Type: "rating"}, 6.0, false},
+		{"rating fraction", models.Field{Type: "rating"}, 2.5, false},
+		{"select", models.Field{Type: "select", Options: options}, "a", true},
+		{"select unknown option", models.Field{Type: "radio", Options: options}, "c", false},
+		{"checkbox list", models.Field{Type: "checkbox", Options: options}, []interface{}{"a", "b"}, true},
+		{"checkbox unknown option", models.Field{Type: "checkbox", Options: options}, []interface{}{"c"}, false},
+		{"lone checkbox", models.Field{Type: "checkbox"}, true, true},
+		{"lone checkbox not a bool", models.Field{Type: "checkbox"}, "yes", false},
+		{"rank", models.Field{Type: "rank", Options: options}, []interface{}{"b", "a"}, true},
+		{"rank duplicate", models.Field{Type: "rank", Options: options}, []interface{}{"a", "a"}, false},
+		{"rank incomplete", models.Field{Type: "rank", Options: options}, []interface{}{"a"}, false},
+		{"date", models.Field{Type: "date"}, "2026-02-28", true},
+		{"date invalid", models.Field{Type: "date"}, "2026-02-30", false},
+		{"time", models.Field{Type: "time"}, "13:45", true},
+		{"time invalid", models.Field{Type: "time"}, "1pm", false},
+		{"datetime", models.Field{Type: "datetime"}, "2026-02-28T13:45:00Z", true},
+		{"datetime invalid", models.Field{Type: "datetime"}, "2026-02-28 13:45", false},
+		{"display field ignored", models.Field{Type: "heading", Required: true}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.field.ID = "f"
+			data := map[string]interface{}{}
+			if tt.value != nil {
+				data["f"] = tt.value
+			}
+
+			err := validateSubmissionData(&models.FormSchema{Fields: []models.Field{tt.field}}, data)
+			if tt.valid && err != nil {
+				t.Errorf("got %v, want valid", err)
+			}
+			if !tt.valid {
+				assertIs(t, err, ErrInvalidSubmission)
+			}
+		})
+	}
+}
+
+func TestValidateSubmissionDataConditional(t *testing.T) {
+	schema := &models.FormSchema{Fields: []models.Field{
+		{ID: "contact", Type: "radio", Options: []models.Option{{Value: "email"}, {Value: "none"}}},
+		{ID: "email", Type: "email", Required: true, Conditional: &models.Conditional{FieldID: "contact", Operator: "==", Value: "email"}},
+	}}
+
+	if err := validateSubmissionData(schema, map[string]interface{}{"contact": "none"}); err != nil {
+		t.Errorf("hidden required field was validated: %v", err)
+	}
+
+	err := validateSubmissionData(schema, map[string]interface{}{"contact": "email"})
+	assertIs(t, err, ErrInvalidSubmission)
+}
+
+func TestValidateSubmissionDataConditionalExpression(t *testing.T) {
+	schema := &models.FormSchema{Fields: []models.Field{
+		{ID: "age", Type: "number"},
+		{ID: "country", Type: "text"},
+		{ID: "consent", Type: "text", Required: true, Conditional: &models.Conditional{Expression: "{age} < 18 && lower({country}) == 'ke'"}},
+	}}
+
+	tests := []struct {
+		name  string
+		data  map[string]interface{}
+		valid bool
+	}{
+		{"hidden for adults", map[string]interface{}{"age": 30.0, "country": "KE"}, true},
+		{"hidden elsewhere", map[string]interface{}{"age": 12.0, "country": "UG"}, true},
+		{"hidden without age", map[string]interface{}{"country": "KE"}, true},
+		{"shown", map[string]interface{}{"age": "12", "country": "ke"}, false},
+		{"shown and answered", map[string]interface{}{"age": 12.0, "country": "KE", "consent": "yes"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSubmissionData(schema, tt.data)
+			if tt.valid && err != nil {
+				t.Errorf("got %v, want valid", err)
+			}
+			if !tt.valid {
+				assertIs(t, err, ErrInvalidSubmission)
+			}
+		})
+	}
+}
+
+func TestSubmissionMetadataRoundTrip(t *testing.T) {
+	env := newTestEnv(t)
+	workflow := env.activeWorkflow(t, nil)
+
+	metadata := &models.SubmissionMetadata{IPAddress: "10.0.0.1", UserAgent: "test", Referrer: "https://example.com", SessionID: uuid.NewString()}
+	created, err := env.submissions.CreateSubmission(env.ctx, CreateSubmissionRequest{
+		WorkflowID: workflow.ID,
+		Data:       map[string]interface{}{"nested": map[string]interface{}{"a": 1.0}},
+		Metadata:   metadata,
+	})
+	if err != nil {
+		t.Fatalf("CreateSubmission: %v", err)
+	}
+
+	got, err := env.submissions.GetSubmission(env.ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetSubmission: %v", err)
+	}
+	if got.Metadata != *metadata {
+		t.Errorf("metadata = %+v, want %+v", got.Metadata, *metadata)
+	}
+	if raw, _ := json.Marshal(got.Data); string(raw) != `{"nested":{"a":1}}` {
+		t.Errorf("data = %s", raw)
+	}
+}
+
+func reversed(s []string) []string {
+	r := make([]string, len(s))
+	for i, v := range s {
+		r[len(s)-1-i] = v
+	}
+	return r
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestExportSubmissionsPagesWithoutStreamer(t *testing.T) {
+	env := newTestEnv(t)
+	workflow := env.activeWorkflow(t, nil)
+
+	// More than one page, so the keyset fallback has to follow its cursor
+	for i := 0; i < exportPageSize+1; i++ {
+		env.submit(t, workflow.ID, map[string]interface{}{})
+	}
+
+	var buf bytes.Buffer
+	if err := NewExportService(env.queries).ExportSubmissions(env.ctx, workflow.ID, export.FormatNDJSON, &buf); err != nil {
+		t.Fatalf("ExportSubmissions: %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != exportPageSize+1 {
+		t.Errorf("exported %d rows, want %d", lines, exportPageSize+1)
+	}
+}
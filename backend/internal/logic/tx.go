@@ -0,0 +1,30 @@
+package logic
+
+import (
+	"context"
+	"log"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// serializableTx is used by operations that check a business rule and then write.
+// Postgres aborts one of two transactions that would otherwise break the rule
+// together, and ExecTx retries it against the committed state.
+var serializableTx = db.TxOptions{IsoLevel: pgx.Serializable}
+
+// ErrConcurrentUpdate is returned when a transaction keeps conflicting with
+// concurrent requests after every retry.
+var ErrConcurrentUpdate = Conflict("concurrent_update", "the resource was changed by a concurrent request, please retry", nil)
+
+// inTx runs fn as one serializable unit of work on store. A conflict that outlasts the
+// retries is reported as ErrConcurrentUpdate; the database error is only logged, as
+// its text would reach clients otherwise.
+func inTx(ctx context.Context, store db.Store, fn func(q db.Querier) error) error {
+	err := store.ExecTx(ctx, serializableTx, fn)
+	if db.IsRetryable(err) {
+		log.Printf("tx: giving up on a conflicting transaction: %v", err)
+		return ErrConcurrentUpdate
+	}
+	return err
+}
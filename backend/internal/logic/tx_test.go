@@ -0,0 +1,117 @@
+package logic
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/db/memdb"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// conflictingStore fails every transaction like Postgres does after ExecTx ran out of retries.
+type conflictingStore struct {
+	*memdb.Queries
+}
+
+func (s *conflictingStore) ExecTx(ctx context.Context, opts db.TxOptions, fn func(db.Querier) error) error {
+	return &pgconn.PgError{Code: "40001", Message: "could not serialize access due to read/write dependencies among transactions"}
+}
+
+func TestInTxReportsPersistentConflicts(t *testing.T) {
+	env := newTestEnv(t)
+	workflow := env.createWorkflow(t, nil)
+
+	workflows := NewWorkflowService(&conflictingStore{Queries: env.queries})
+	err := workflows.DeleteWorkflow(env.ctx, workflow.ID)
+	assertIs(t, err, ErrConcurrentUpdate)
+	if err.Error() != ErrConcurrentUpdate.Message {
+		t.Errorf("error = %q, want only the message, without the database error", err)
+	}
+}
+
+func TestInTxRollsBackOnError(t *testing.T) {
+	env := newTestEnv(t)
+	workflow := env.createWorkflow(t, nil)
+
+	err := inTx(env.ctx, env.queries, func(q db.Querier) error {
+		if err := q.DeleteWorkflow(env.ctx, mustUUID(workflow.ID)); err != nil {
+			return err
+		}
+		return ErrWorkflowHasSubmissions
+	})
+	assertIs(t, err, ErrWorkflowHasSubmissions)
+
+	if _, err := env.workflows.GetWorkflow(env.ctx, workflow.ID); err != nil {
+		t.Errorf("the delete was not rolled back: %v", err)
+	}
+}
+
+// Deleting a workflow while submissions arrive must never leave a deleted workflow
+// that accepted a submission, nor refuse the delete without a stored submission.
+func TestDeleteWorkflowRacesCreateSubmission(t *testing.T) {
+	env := newTestEnv(t)
+
+	for i := 0; i < 20; i++ {
+		workflow := env.activeWorkflow(t, nil)
+
+		var wg sync.WaitGroup
+		var deleteErr, submitErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			deleteErr = env.workflows.DeleteWorkflow(env.ctx, workflow.ID)
+		}()
+		go func() {
+			defer wg.Done()
+			_, submitErr = env.submissions.CreateSubmission(env.ctx, CreateSubmissionRequest{
+				WorkflowID: workflow.ID,
+				Data:       map[string]interface{}{},
+			})
+		}()
+		wg.Wait()
+
+		switch {
+		case deleteErr == nil && submitErr == nil:
+			t.Fatal("the workflow was deleted although a submission was accepted")
+		case deleteErr != nil && submitErr != nil:
+			t.Fatalf("both failed: %v, %v", deleteErr, submitErr)
+		case deleteErr != nil:
+			assertIs(t, deleteErr, ErrWorkflowHasSubmissions)
+		default:
+			assertError(t, submitErr, KindNotFound, "workflow_not_found")
+		}
+	}
+}
+
+// Pausing a workflow while it is edited must leave either the edit or the pause
+// undone, never an edited workflow that is not in draft.
+func TestUpdateWorkflowRacesStatusChange(t *testing.T) {
+	env := newTestEnv(t)
+
+	for i := 0; i < 20; i++ {
+		workflow := env.createWorkflow(t, nil)
+
+		var wg sync.WaitGroup
+		var updateErr, statusErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, updateErr = env.workflows.UpdateWorkflow(env.ctx, workflow.ID, UpdateWorkflowRequest{Name: ptr("Renamed")})
+		}()
+		go func() {
+			defer wg.Done()
+			_, statusErr = env.workflows.UpdateWorkflowStatus(env.ctx, workflow.ID, models.WorkflowStatusActive, env.owner)
+		}()
+		wg.Wait()
+
+		if statusErr != nil {
+			t.Fatalf("UpdateWorkflowStatus: %v", statusErr)
+		}
+		if updateErr != nil {
+			assertIs(t, updateErr, ErrWorkflowNotDraft)
+		}
+	}
+}
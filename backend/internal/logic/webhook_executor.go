@@ -0,0 +1,468 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	texttemplate "text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// defaultWebhookTimeout bounds each request of a call_webhook action that sets no timeout.
+	defaultWebhookTimeout = 10 * time.Second
+	// maxWebhookTimeout is the longest timeout an action may set.
+	maxWebhookTimeout = time.Minute
+	// maxWebhookAttempts is the most requests an action's retry policy may make.
+	maxWebhookAttempts = 5
+	// defaultWebhookBackoff is the wait before the first retry; it doubles for each retry after it.
+	defaultWebhookBackoff = time.Second
+	// maxWebhookBackoff is the longest first wait a retry policy may set.
+	maxWebhookBackoff = 30 * time.Second
+	// maxWebhookDuration bounds how long an action may take when every request times out
+	// and every retry waits in full. It stays well under the default 5m queue lease, which
+	// the whole workflow has to fit in.
+	maxWebhookDuration = 2 * time.Minute
+	// maxWebhookResponse is the length in bytes beyond which response bodies are cut in
+	// the action's output.
+	maxWebhookResponse = 4096
+)
+
+// Event types of the envelope a call_webhook action sends.
+const (
+	webhookEventSubmission = "submission.created" // A new submission was processed.
+	webhookEventRun        = "workflow.run"       // An on-demand run executed the action.
+)
+
+// webhookMethods are the HTTP methods a call_webhook action may use.
+var webhookMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodGet}
+
+// webhookReservedHeaders are set by the executor and cannot be configured.
+var webhookReservedHeaders = []string{"Host", "Content-Length", WebhookTimestampHeader, WebhookSignatureHeader}
+
+// webhookActionConfig is the Action.Config for call_webhook actions:
+//
+//	{"url": "https://example.com/hooks", "headers": {"Authorization": "Bearer ..."},
+//	 "timeout": "5s", "retry": {"maxAttempts": 3, "backoff": "2s"}}
+//
+// Without a body template the request carries a webhookEnvelope as JSON. Body is
+// rendered with text/template against webhookTemplateData, with the email template
+// functions and json, which encodes a value as JSON.
+type webhookActionConfig struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`  // Defaults to POST.
+	Headers map[string]string `json:"headers"` // Sent with every request. Values are redacted in the execution log.
+	Body    string            `json:"body"`
+	Timeout string            `json:"timeout"` // Bounds each request, e.g. "5s". Defaults to 10s.
+	Retry   struct {
+		MaxAttempts int    `json:"maxAttempts"` // Requests made in total, including the first. Defaults to 1.
+		Backoff     string `json:"backoff"`     // Wait before the first retry, e.g. "2s". Defaults to 1s.
+	} `json:"retry"`
+	// ContinueOnError completes the action when the final response is not a 2xx, so a
+	// later action can branch on its status instead of the workflow failing.
+	ContinueOnError bool `json:"continueOnError"`
+
+	timeout time.Duration
+	backoff time.Duration
+}
+
+// webhookEnvelope is the default body of a call_webhook request.
+type webhookEnvelope struct {
+	Event        string                    `json:"event"`
+	WorkflowID   string                    `json:"workflowId"`
+	SubmissionID string                    `json:"submissionId,omitempty"` // Empty for runs on an input payload.
+	RunID        string                    `json:"runId,omitempty"`
+	ActionID     string                    `json:"actionId"`
+	Data         map[string]interface{}    `json:"data"`
+	Metadata     models.SubmissionMetadata `json:"metadata"`
+}
+
+// webhookTemplateData is what the body template of a call_webhook action is rendered against.
+type webhookTemplateData struct {
+	Envelope   *webhookEnvelope
+	Data       map[string]interface{} // The submission data, keyed by field ID.
+	Submission *models.Submission
+	Workflow   *models.Workflow
+	Results    map[string]*ActionResult // Results of the actions that already ran, keyed by action ID.
+}
+
+// webhookFuncs are the functions available to body templates.
+func webhookFuncs(data map[string]interface{}) map[string]interface{} {
+	funcs := emailFuncs(data)
+	funcs["json"] = func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+	return funcs
+}
+
+// parseWebhookActionConfig decodes a call_webhook configuration, applies its defaults
+// and checks that its body template parses.
+func parseWebhookActionConfig(raw json.RawMessage) (*webhookActionConfig, error) {
+	var cfg webhookActionConfig
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid call_webhook config: %w", err)
+		}
+	}
+
+	target, err := url.Parse(cfg.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("call_webhook needs an absolute http or https url")
+	}
+
+	cfg.Method = strings.ToUpper(cfg.Method)
+	if cfg.Method == "" {
+		cfg.Method = http.MethodPost
+	}
+	if !slices.Contains(webhookMethods, cfg.Method) {
+		return nil, fmt.Errorf("unsupported call_webhook method %q", cfg.Method)
+	}
+
+	for name := range cfg.Headers {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return nil, fmt.Errorf("invalid call_webhook header name %q", name)
+		}
+		if slices.Contains(webhookReservedHeaders, http.CanonicalHeaderKey(name)) {
+			return nil, fmt.Errorf("call_webhook header %s is set by the server", name)
+		}
+		if strings.ContainsAny(cfg.Headers[name], "\r\n") {
+			return nil, fmt.Errorf("invalid call_webhook header value for %s", name)
+		}
+	}
+
+	if cfg.timeout, err = parseWebhookDuration("timeout", cfg.Timeout, defaultWebhookTimeout, maxWebhookTimeout); err != nil {
+		return nil, err
+	}
+	if cfg.backoff, err = parseWebhookDuration("retry backoff", cfg.Retry.Backoff, defaultWebhookBackoff, maxWebhookBackoff); err != nil {
+		return nil, err
+	}
+	if cfg.Retry.MaxAttempts == 0 {
+		cfg.Retry.MaxAttempts = 1
+	}
+	if cfg.Retry.MaxAttempts < 1 || cfg.Retry.MaxAttempts > maxWebhookAttempts {
+		return nil, fmt.Errorf("call_webhook retry maxAttempts must be between 1 and %d", maxWebhookAttempts)
+	}
+	if worst := cfg.worstCase(); worst > maxWebhookDuration {
+		return nil, fmt.Errorf("call_webhook timeout and retries may take %s, more than the %s allowed", worst, maxWebhookDuration)
+	}
+
+	if _, err := texttemplate.New("body").Funcs(webhookFuncs(nil)).Parse(cfg.Body); err != nil {
+		return nil, fmt.Errorf("invalid call_webhook template: %w", err)
+	}
+	return &cfg, nil
+}
+
+// worstCase is how long the action takes when every request times out.
+func (cfg *webhookActionConfig) worstCase() time.Duration {
+	total := time.Duration(cfg.Retry.MaxAttempts) * cfg.timeout
+	for retry := 0; retry < cfg.Retry.MaxAttempts-1; retry++ {
+		total += cfg.backoff << retry
+	}
+	return total
+}
+
+// parseWebhookDuration parses a duration of a call_webhook configuration.
+func parseWebhookDuration(name, value string, def, limit time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 || d > limit {
+		return 0, fmt.Errorf("call_webhook %s must be a duration between 0s and %s", name, limit)
+	}
+	return d, nil
+}
+
+// render builds the request body for one execution of the action.
+func (cfg *webhookActionConfig) render(actx *ActionContext) ([]byte, error) {
+	envelope := &webhookEnvelope{
+		Event:        webhookEventSubmission,
+		WorkflowID:   actx.Workflow.ID,
+		SubmissionID: actx.Submission.ID,
+		ActionID:     actx.Action.ID,
+		Data:         actx.Submission.Data,
+		Metadata:     actx.Submission.Metadata,
+	}
+	if actx.Run != nil {
+		envelope.Event = webhookEventRun
+		envelope.RunID = actx.Run.ID
+	}
+
+	if cfg.Body == "" {
+		return json.Marshal(envelope)
+	}
+
+	tmpl, err := texttemplate.New("body").Funcs(webhookFuncs(actx.Submission.Data)).Parse(cfg.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, webhookTemplateData{
+		Envelope:   envelope,
+		Data:       actx.Submission.Data,
+		Submission: actx.Submission,
+		Workflow:   actx.Workflow,
+		Results:    actx.Results,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// webhookResponse is what is kept of a call_webhook response.
+type webhookResponse struct {
+	status int
+	body   string // Cut to maxWebhookResponse bytes.
+}
+
+// retryable reports whether a request that ended with resp or err is worth repeating.
+func (r *webhookResponse) retryable(err error) bool {
+	if err != nil {
+		return !errors.Is(err, errNonPublicAddress)
+	}
+	return r.status == http.StatusRequestTimeout || r.status == http.StatusTooManyRequests || r.status >= 500
+}
+
+type webhookExecutor struct {
+	queries db.Querier
+	client  *http.Client
+	now     func() time.Time
+}
+
+// NewWebhookExecutor creates an executor that sends call_webhook requests with client,
+// signed with the workflow secrets in queries. A nil client uses one that only connects
+// to public addresses, see publicTransport. Redirects are not followed, so a request is
+// never resent without its body.
+func NewWebhookExecutor(queries db.Querier, client *http.Client) ActionExecutor {
+	c := &http.Client{Transport: publicTransport()}
+	if client != nil {
+		*c = *client
+	}
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &webhookExecutor{queries: queries, client: c, now: time.Now}
+}
+
+// errNonPublicAddress is returned for webhook connections to addresses that are not public.
+var errNonPublicAddress = errors.New("webhook address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range, which some clouds serve metadata from.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicTransport returns a transport that refuses to connect to loopback, link-local,
+// private, unspecified and multicast addresses, so owners cannot reach the server's own
+// network through a webhook and read the reply. The check runs on the resolved address of
+// each connection, which covers DNS names that resolve, or later re-resolve, to such an
+// address. Proxies are not used, since the check would only see the proxy.
+func publicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddr(ip) {
+				return fmt.Errorf("%w: %s", errNonPublicAddress, ip)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// isPublicAddr reports whether ip is a globally routable unicast address.
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+func (x *webhookExecutor) Type() models.ActionType {
+	return models.ActionTypeCallWebhook
+}
+
+func (x *webhookExecutor) Execute(ctx context.Context, actx *ActionContext) (*ActionResult, error) {
+	cfg, err := parseWebhookActionConfig(actx.Action.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := x.signingSecret(ctx, actx.Workflow.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := cfg.render(actx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *webhookResponse
+	attempts := 0
+	for {
+		attempts++
+		resp, err = x.send(ctx, cfg, secret, body)
+		if !resp.retryable(err) || attempts == cfg.Retry.MaxAttempts {
+			break
+		}
+
+		wait := cfg.backoff << (attempts - 1)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("webhook retry cancelled after %d attempts: %w", attempts, ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to call webhook after %d attempts: %w", attempts, err)
+	}
+
+	if (resp.status < 200 || resp.status > 299) && !cfg.ContinueOnError {
+		return nil, fmt.Errorf("webhook responded with status %d after %d attempts: %s", resp.status, attempts, truncate(resp.body, 512))
+	}
+
+	return &ActionResult{Output: map[string]interface{}{
+		"status":   resp.status,
+		"body":     resp.body,
+		"attempts": attempts,
+	}}, nil
+}
+
+// send makes one signed request. Reaching the endpoint is success: the response
+// status is left to the caller.
+func (x *webhookExecutor) send(ctx context.Context, cfg *webhookActionConfig, secret string, body []byte) (*webhookResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, cfg.Method, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return &webhookResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Rootd-Webhook/1.0")
+	for name, value := range cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	now := x.now()
+	req.Header.Set(WebhookTimestampHeader, fmt.Sprint(now.Unix()))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(secret, now, body))
+
+	res, err := x.client.Do(req)
+	if err != nil {
+		return &webhookResponse{}, err
+	}
+	defer res.Body.Close()
+
+	// Read a little past the limit so truncate can cut on a rune boundary
+	raw, err := io.ReadAll(io.LimitReader(res.Body, maxWebhookResponse+utf8.UTFMax))
+	if err != nil {
+		return &webhookResponse{}, fmt.Errorf("failed to read the response: %w", err)
+	}
+	return &webhookResponse{status: res.StatusCode, body: truncate(string(raw), maxWebhookResponse)}, nil
+}
+
+// signingSecret returns the secret the workflow's requests are signed with.
+func (x *webhookExecutor) signingSecret(ctx context.Context, workflowID string) (string, error) {
+	id, err := uuid.Parse(workflowID)
+	if err != nil {
+		return "", fmt.Errorf("invalid workflow ID: %w", err)
+	}
+	secret, err := x.queries.GetWorkflowSigningSecret(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("workflow has no signing secret")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get signing secret: %w", err)
+	}
+	return secret.Secret, nil
+}
+
+// syncSigningSecret gives a workflow a signing secret once it has a call_webhook
+// action. The secret is kept when those actions are removed, so receivers that
+// verify with it keep working if they are added back. It returns the workflow's
+// secret, or nil when it has none.
+func syncSigningSecret(ctx context.Context, q db.Querier, workflowID pgtype.UUID, actions []models.Action) (*db.WorkflowSigningSecret, error) {
+	secret, err := q.GetWorkflowSigningSecret(ctx, workflowID)
+	if err == nil {
+		return secret, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, dbError(err, "failed to get workflow signing secret")
+	}
+
+	if !slices.ContainsFunc(actions, func(action models.Action) bool {
+		return action.Type == models.ActionTypeCallWebhook
+	}) {
+		return nil, nil
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing secret: %w", err)
+	}
+	secret, err = q.CreateWorkflowSigningSecret(ctx, &db.CreateWorkflowSigningSecretParams{
+		WorkflowID: workflowID,
+		Secret:     "whsec_" + token,
+	})
+	if err != nil {
+		return nil, dbError(err, "failed to create workflow signing secret")
+	}
+	return secret, nil
+}
+
+func signingSecretValue(secret *db.WorkflowSigningSecret) string {
+	if secret == nil {
+		return ""
+	}
+	return secret.Secret
+}
+
+// redactWebhookConfig replaces the header values of a call_webhook configuration,
+// which often carry credentials, before it is written to the execution log.
+func redactWebhookConfig(raw json.RawMessage) json.RawMessage {
+	var config map[string]interface{}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return raw
+	}
+	headers, ok := config["headers"].(map[string]interface{})
+	if !ok || len(headers) == 0 {
+		return raw
+	}
+	for name := range headers {
+		headers[name] = "[redacted]"
+	}
+	redacted, err := json.Marshal(config)
+	if err != nil {
+		return nil
+	}
+	return redacted
+}
@@ -0,0 +1,321 @@
+package logic
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hungaikev/rootd/backend/internal/models"
+)
+
+// webhookAction returns a call_webhook action with config, whose "url" is set to url.
+func webhookAction(id, url, config string) models.Action {
+	var cfg map[string]interface{}
+	json.Unmarshal([]byte(config), &cfg)
+	cfg["url"] = url
+	raw, _ := json.Marshal(cfg)
+	return models.Action{ID: id, Type: models.ActionTypeCallWebhook, Config: raw}
+}
+
+func TestWebhookExecutor(t *testing.T) {
+	env := newTestEnv(t)
+
+	var req *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, `{"id":"c-1"}`)
+	}))
+	defer server.Close()
+
+	workflow := env.activeWorkflow(t, nil, withActions(webhookAction("crm", server.URL+"/contacts", `{"method": "put", "headers": {"Authorization": "Bearer s3cret"}}`)))
+	if !strings.HasPrefix(workflow.SigningSecret, "whsec_") {
+		t.Fatalf("signing secret = %q, want one for the call_webhook action", workflow.SigningSecret)
+	}
+	submission := env.submit(t, workflow.ID, map[string]interface{}{"email": "ada@example.com"})
+
+	engine := NewExecutionEngine(env.queries, NewWebhookExecutor(env.queries, server.Client()))
+	if err := engine.ProcessSubmission(env.ctx, submission.ID); err != nil {
+		t.Fatalf("ProcessSubmission: %v", err)
+	}
+
+	if req == nil {
+		t.Fatal("the webhook was not called")
+	}
+	if req.Method != http.MethodPut || req.URL.Path != "/contacts" || req.Header.Get("Authorization") != "Bearer s3cret" {
+		t.Errorf("request = %s %s %v, want the configured method, url and headers", req.Method, req.URL, req.Header)
+	}
+
+	timestamp, err := strconv.ParseInt(req.Header.Get(WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp header = %q", req.Header.Get(WebhookTimestampHeader))
+	}
+	if want := SignWebhook(workflow.SigningSecret, time.Unix(timestamp, 0), body); req.Header.Get(WebhookSignatureHeader) != want {
+		t.Errorf("signature = %q, want %q", req.Header.Get(WebhookSignatureHeader), want)
+	}
+
+	var envelope webhookEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		t.Fatalf("body %s: %v", body, err)
+	}
+	if envelope.Event != "submission.created" || envelope.WorkflowID != workflow.ID || envelope.SubmissionID != submission.ID ||
+		envelope.ActionID != "crm" || envelope.Data["email"] != "ada@example.com" {
+		t.Errorf("envelope = %+v, want the submission", envelope)
+	}
+
+	runs, err := env.submissions.ListActionRuns(env.ctx, submission.ID)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("ListActionRuns = %v, %v, want one entry", runs, err)
+	}
+	if output := runs[0].Output; output["status"] != float64(http.StatusAccepted) || output["body"] != `{"id":"c-1"}` || output["attempts"] != float64(1) {
+		t.Errorf("logged output = %v, want the response", output)
+	}
+	if config := string(runs[0].Config); strings.Contains(config, "s3cret") || !strings.Contains(config, "[redacted]") {
+		t.Errorf("logged config = %s, want the header values redacted", config)
+	}
+}
+
+func TestWebhookExecutorBodyTemplate(t *testing.T) {
+	env := newTestEnv(t)
+
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	workflow := env.activeWorkflow(t, nil, withActions(webhookAction("slack", server.URL, `{"body": "{\"text\": {{json (printf \"New order from %s\" (field \"name\"))}}, \"event\": {{json .Envelope.Event}}}"}`)))
+	submission := env.submit(t, workflow.ID, map[string]interface{}{"name": `Ada "Countess" Lovelace`})
+
+	engine := NewExecutionEngine(env.queries, NewWebhookExecutor(env.queries, server.Client()))
+	if err := engine.ProcessSubmission(env.ctx, submission.ID); err != nil {
+		t.Fatalf("ProcessSubmission: %v", err)
+	}
+
+	if want := `{"text": "New order from Ada \"Countess\" Lovelace", "event": "submission.created"}`; string(body) != want {
+		t.Errorf("body = %s, want %s", body, want)
+	}
+}
+
+func TestWebhookExecutorRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		responses []int
+		calls     int32
+		want      string // Error of the submission, if it fails.
+		status    int    // Status recorded for later actions, if it completes.
+	}{
+		{"succeeds first time", `{}`, []int{200}, 1, "", 200},
+		{"retried until it succeeds", `{"retry": {"maxAttempts": 3, "backoff": "1ms"}}`, []int{503, 429, 200}, 3, "", 200},
+		{"retries exhausted", `{"retry": {"maxAttempts": 2, "backoff": "1ms"}}`, []int{500, 502}, 2, "status 502 after 2 attempts", 0},
+		{"client errors are not retried", `{"retry": {"maxAttempts": 3, "backoff": "1ms"}}`, []int{400}, 1, "status 400 after 1 attempts", 0},
+		{"redirects are not followed", `{}`, []int{302}, 1, "status 302", 0},
+		{"continue on error", `{"continueOnError": true}`, []int{404}, 1, "", 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				if status := tt.responses[min(int(n), len(tt.responses))-1]; status == http.StatusFound {
+					http.Redirect(w, r, "/elsewhere", status)
+				} else {
+					w.WriteHeader(status)
+				}
+			}))
+			defer server.Close()
+
+			// The notification runs only when the webhook answered with the expected status
+			recorder := &recordingExecutor{}
+			workflow := env.activeWorkflow(t, nil, withActions(
+				webhookAction("hook", server.URL, tt.config),
+				models.Action{ID: "notify", Type: models.ActionTypeNotification,
+					Conditional: &models.Conditional{FieldID: "hook.status", Operator: "==", Value: tt.status}},
+			))
+			submission := env.submit(t, workflow.ID, map[string]interface{}{})
+
+			engine := NewExecutionEngine(env.queries, NewWebhookExecutor(env.queries, server.Client()), recorder)
+			err := engine.ProcessSubmission(env.ctx, submission.ID)
+			if tt.want != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.want) {
+					t.Errorf("ProcessSubmission = %v, want an error containing %q", err, tt.want)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("ProcessSubmission: %v", err)
+				}
+				if len(recorder.calls) != 1 {
+					t.Errorf("later action ran %d times, want it to branch on status %d", len(recorder.calls), tt.status)
+				}
+			}
+			if n := calls.Load(); n != tt.calls {
+				t.Errorf("webhook called %d times, want %d", n, tt.calls)
+			}
+		})
+	}
+}
+
+func TestWebhookExecutorTimeout(t *testing.T) {
+	env := newTestEnv(t)
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	workflow := env.activeWorkflow(t, nil, withActions(webhookAction("slow", server.URL, `{"timeout": "50ms"}`)))
+	submission := env.submit(t, workflow.ID, map[string]interface{}{})
+	engine := NewExecutionEngine(env.queries, NewWebhookExecutor(env.queries, server.Client()))
+
+	start := time.Now()
+	err := engine.ProcessSubmission(env.ctx, submission.ID)
+	if err == nil || !strings.Contains(err.Error(), "failed to call webhook after 1 attempts") {
+		t.Errorf("ProcessSubmission = %v, want the request to time out", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("ProcessSubmission returned after %s, want it bounded by the timeout", elapsed)
+	}
+}
+
+func TestWebhookExecutorRefusesPrivateAddresses(t *testing.T) {
+	env := newTestEnv(t)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	workflow := env.activeWorkflow(t, nil, withActions(webhookAction("local", server.URL, `{"retry": {"maxAttempts": 3, "backoff": "1ms"}}`)))
+	submission := env.submit(t, workflow.ID, map[string]interface{}{})
+
+	// The default client, as the worker uses it
+	engine := NewExecutionEngine(env.queries, NewWebhookExecutor(env.queries, nil))
+	err := engine.ProcessSubmission(env.ctx, submission.ID)
+	if err == nil || !strings.Contains(err.Error(), "failed to call webhook after 1 attempts") || !strings.Contains(err.Error(), "not public") {
+		t.Errorf("ProcessSubmission = %v, want the loopback address refused without retries", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("server received %d requests, want none", calls.Load())
+	}
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"100.100.100.200", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		if got := isPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestParseWebhookActionConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"minimal", `{"url": "https://example.com/hooks"}`, ""},
+		{"full", `{"url": "http://example.com", "method": "patch", "headers": {"X-Api-Key": "k"}, "body": "{{json .Data}}",
+			"timeout": "15s", "retry": {"maxAttempts": 5, "backoff": "2s"}, "continueOnError": true}`, ""},
+		{"not an object", `"https://example.com"`, "invalid call_webhook config"},
+		{"no url", `{}`, "absolute http or https url"},
+		{"relative url", `{"url": "/hooks"}`, "absolute http or https url"},
+		{"other scheme", `{"url": "ftp://example.com"}`, "absolute http or https url"},
+		{"unsupported method", `{"url": "https://example.com", "method": "TRACE"}`, `unsupported call_webhook method "TRACE"`},
+		{"reserved header", `{"url": "https://example.com", "headers": {"x-rootd-signature": "forged"}}`, "set by the server"},
+		{"invalid header name", `{"url": "https://example.com", "headers": {"Bad Name": "v"}}`, "invalid call_webhook header name"},
+		{"header injection", `{"url": "https://example.com", "headers": {"X-A": "v\r\nX-B: w"}}`, "invalid call_webhook header value"},
+		{"timeout too long", `{"url": "https://example.com", "timeout": "5m"}`, "timeout must be a duration"},
+		{"timeout not a duration", `{"url": "https://example.com", "timeout": "soon"}`, "timeout must be a duration"},
+		{"too slow", `{"url": "https://example.com", "timeout": "30s", "retry": {"maxAttempts": 5, "backoff": "10s"}}`,
+			"timeout and retries may take 5m0s, more than the 2m0s allowed"},
+		{"too many attempts", `{"url": "https://example.com", "retry": {"maxAttempts": 6}}`, "maxAttempts must be between 1 and 5"},
+		{"invalid template", `{"url": "https://example.com", "body": "{{json .Data"}`, "invalid call_webhook template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseWebhookActionConfig(json.RawMessage(tt.config))
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("parseWebhookActionConfig = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseWebhookActionConfig = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowSigningSecret(t *testing.T) {
+	env := newTestEnv(t)
+	hook := webhookAction("hook", "https://example.com", `{}`)
+
+	workflow := env.createWorkflow(t, nil)
+	if workflow.SigningSecret != "" {
+		t.Errorf("signing secret = %q, want none without a call_webhook action", workflow.SigningSecret)
+	}
+
+	updated, err := env.workflows.UpdateWorkflow(env.ctx, workflow.ID, UpdateWorkflowRequest{Actions: []models.Action{hook}})
+	if err != nil {
+		t.Fatalf("UpdateWorkflow: %v", err)
+	}
+	secret := updated.SigningSecret
+	if secret == "" {
+		t.Fatal("no signing secret after adding a call_webhook action")
+	}
+
+	// Removing the action keeps the secret
+	if updated, err = env.workflows.UpdateWorkflow(env.ctx, workflow.ID, UpdateWorkflowRequest{Actions: []models.Action{}}); err != nil {
+		t.Fatalf("UpdateWorkflow: %v", err)
+	}
+	fetched, err := env.workflows.GetWorkflow(env.ctx, workflow.ID)
+	if err != nil {
+		t.Fatalf("GetWorkflow: %v", err)
+	}
+	if updated.SigningSecret != secret || fetched.SigningSecret != secret {
+		t.Errorf("signing secret = %q, then %q, want %q kept", updated.SigningSecret, fetched.SigningSecret, secret)
+	}
+
+	invalid := []models.Action{{ID: "hook", Type: models.ActionTypeCallWebhook, Config: json.RawMessage(`{}`)}}
+	_, err = env.workflows.UpdateWorkflow(env.ctx, workflow.ID, UpdateWorkflowRequest{Actions: invalid})
+	assertError(t, err, KindValidation, "validation_failed")
+}
@@ -0,0 +1,258 @@
+package logic
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// WebhookTimestampHeader carries the time a webhook payload was signed, in Unix seconds.
+	WebhookTimestampHeader = "X-Rootd-Timestamp"
+	// WebhookSignatureHeader carries the signature made with SignWebhook.
+	WebhookSignatureHeader = "X-Rootd-Signature"
+	// WebhookTolerance is how far a webhook timestamp may be from the server clock.
+	// Older deliveries are rejected so a captured request cannot be replayed later.
+	WebhookTolerance = 5 * time.Minute
+)
+
+var (
+	// ErrInvalidWebhookSignature is returned for webhook deliveries that are not
+	// signed with the workflow's secret or whose timestamp is out of range.
+	ErrInvalidWebhookSignature = Unauthenticated("invalid_webhook_signature", "invalid webhook signature", nil)
+	// ErrInvalidWebhookPayload is returned when a webhook body is not a JSON object.
+	ErrInvalidWebhookPayload = InvalidInput("invalid_webhook_payload", "webhook payload must be a JSON object", nil)
+)
+
+type webhookService struct {
+	queries     db.Store
+	submissions *submissionService
+	now         func() time.Time
+}
+
+// NewWebhookService creates a new webhook service. Accepted payloads are stored as
+// submissions, so they are validated like any other submission.
+func NewWebhookService(queries db.Store) WebhookService {
+	return &webhookService{
+		queries:     queries,
+		submissions: &submissionService{queries: queries},
+		now:         time.Now,
+	}
+}
+
+// SignWebhook returns the signature header value for a payload: "sha256=" followed by
+// the hex HMAC-SHA256, keyed with the webhook secret, of the Unix timestamp, a dot
+// and the payload.
+func SignWebhook(secret string, timestamp time.Time, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *webhookService) ReceiveWebhook(ctx context.Context, token string, req WebhookRequest) (*models.Submission, error) {
+	webhook, err := s.queries.GetWorkflowWebhookByToken(ctx, token)
+	if err != nil {
+		return nil, dbNotFound(err, "webhook", "failed to get webhook")
+	}
+
+	// Nothing from the payload is looked at before it is known to be authentic
+	if err := s.verifySignature(webhook.Secret, req); err != nil {
+		return nil, err
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(req.Payload, &payload); err != nil || payload == nil {
+		return nil, InvalidInput(ErrInvalidWebhookPayload.Code, ErrInvalidWebhookPayload.Message, err)
+	}
+
+	workflow, err := s.queries.GetWorkflow(ctx, webhook.WorkflowID)
+	if err != nil {
+		return nil, dbNotFound(err, "webhook", "failed to get workflow")
+	}
+
+	var trigger models.Trigger
+	json.Unmarshal(workflow.Trigger, &trigger)
+	config, err := parseWebhookTriggerConfig(trigger.Config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook trigger config: %w", err)
+	}
+
+	// The submission service checks that the workflow is active and validates the
+	// mapped data against its form; the engine then runs the actions. The delivery is
+	// signed, so it may feed a workflow the public form endpoint cannot.
+	return s.submissions.createSubmission(ctx, CreateSubmissionRequest{
+		WorkflowID: uuid.UUID(workflow.ID.Bytes).String(),
+		Data:       mapWebhookPayload(payload, config.FieldMapping),
+		Metadata:   req.Metadata,
+	}, false)
+}
+
+// Helper methods
+
+// verifySignature checks the timestamp and signature of a delivery against secret.
+func (s *webhookService) verifySignature(secret string, req WebhookRequest) error {
+	seconds, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return Unauthenticated(ErrInvalidWebhookSignature.Code, "missing or malformed webhook timestamp", err)
+	}
+
+	timestamp := time.Unix(seconds, 0)
+	if skew := s.now().Sub(timestamp); skew > WebhookTolerance || skew < -WebhookTolerance {
+		return Unauthenticated(ErrInvalidWebhookSignature.Code, "webhook timestamp is too far from the current time", nil)
+	}
+
+	expected := SignWebhook(secret, timestamp, req.Payload)
+	if !hmac.Equal([]byte(expected), []byte(req.Signature)) {
+		return ErrInvalidWebhookSignature
+	}
+
+	return nil
+}
+
+// parseWebhookTriggerConfig decodes and checks the config of a webhook trigger.
+// A missing config is valid and maps the payload as is.
+func parseWebhookTriggerConfig(raw json.RawMessage) (models.WebhookTriggerConfig, error) {
+	var config models.WebhookTriggerConfig
+	if len(raw) == 0 || string(raw) == "null" {
+		return config, nil
+	}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return config, fmt.Errorf("webhook trigger config: %w", err)
+	}
+
+	for fieldID, path := range config.FieldMapping {
+		if fieldID == "" {
+			return config, fmt.Errorf("webhook field mapping has an empty field ID")
+		}
+		for _, key := range strings.Split(path, ".") {
+			if key == "" {
+				return config, fmt.Errorf("webhook field mapping for %q has an invalid path %q", fieldID, path)
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// mapWebhookPayload builds submission data from a payload. Each field in mapping takes
+// the value at its path; paths that are missing or null leave the field unset so the
+// form's required rules apply. Without a mapping the payload is used unchanged.
+func mapWebhookPayload(payload map[string]interface{}, mapping map[string]string) map[string]interface{} {
+	if len(mapping) == 0 {
+		return payload
+	}
+
+	data := make(map[string]interface{}, len(mapping))
+	for fieldID, path := range mapping {
+		if value, ok := lookupPath(payload, path); ok && value != nil {
+			data[fieldID] = value
+		}
+	}
+	return data
+}
+
+// lookupPath follows a dot-separated path through JSON objects and arrays.
+// Array elements are addressed by index, e.g. "items.0.name".
+func lookupPath(value interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// createWorkflowWebhook gives a workflow a new inbound URL token and signing secret.
+func createWorkflowWebhook(ctx context.Context, q db.Querier, workflowID pgtype.UUID) (*db.WorkflowWebhook, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook token: %w", err)
+	}
+	secret, err := randomToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	webhook, err := q.CreateWorkflowWebhook(ctx, &db.CreateWorkflowWebhookParams{
+		WorkflowID: workflowID,
+		Token:      token,
+		Secret:     "whsec_" + secret,
+	})
+	if err != nil {
+		return nil, dbError(err, "failed to create workflow webhook")
+	}
+	return webhook, nil
+}
+
+// syncWorkflowWebhook creates or deletes the webhook of a workflow so that only
+// workflows with a webhook trigger have one. An existing webhook keeps its URL and
+// secret. It returns the workflow's webhook, or nil when it has none.
+func syncWorkflowWebhook(ctx context.Context, q db.Querier, workflowID pgtype.UUID, triggerType models.TriggerType) (*db.WorkflowWebhook, error) {
+	webhook, err := q.GetWorkflowWebhook(ctx, workflowID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, dbError(err, "failed to get workflow webhook")
+	}
+	exists := err == nil
+
+	switch {
+	case triggerType == models.TriggerTypeWebhook && !exists:
+		return createWorkflowWebhook(ctx, q, workflowID)
+	case triggerType != models.TriggerTypeWebhook && exists:
+		if err := q.DeleteWorkflowWebhook(ctx, workflowID); err != nil {
+			return nil, dbError(err, "failed to delete workflow webhook")
+		}
+		return nil, nil
+	case exists:
+		return webhook, nil
+	}
+	return nil, nil
+}
+
+// randomToken returns 32 random bytes encoded for use in a URL.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func webhookToModel(webhook *db.WorkflowWebhook) *models.WorkflowWebhook {
+	if webhook == nil {
+		return nil
+	}
+	return &models.WorkflowWebhook{
+		URL:    "/hooks/" + webhook.Token,
+		Secret: webhook.Secret,
+	}
+}
@@ -0,0 +1,208 @@
+package logic
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// signedRequest signs payload the way a webhook sender does.
+func signedRequest(secret string, at time.Time, payload string) WebhookRequest {
+	return WebhookRequest{
+		Payload:   []byte(payload),
+		Timestamp: strconv.FormatInt(at.Unix(), 10),
+		Signature: SignWebhook(secret, at, []byte(payload)),
+	}
+}
+
+func webhookToken(t *testing.T, workflow *models.Workflow) string {
+	t.Helper()
+
+	if workflow.Webhook == nil {
+		t.Fatalf("workflow %s has no webhook", workflow.ID)
+	}
+	token, ok := strings.CutPrefix(workflow.Webhook.URL, "/hooks/")
+	if !ok || token == "" {
+		t.Fatalf("webhook URL = %q, want /hooks/{token}", workflow.Webhook.URL)
+	}
+	return token
+}
+
+func TestCreateWorkflowTriggerType(t *testing.T) {
+	env := newTestEnv(t)
+
+	workflow, err := env.workflows.CreateWorkflow(env.ctx, CreateWorkflowRequest{
+		Name:        "Intake",
+		OwnerID:     env.owner,
+		TriggerType: models.TriggerTypeFormSubmission,
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	if workflow.Trigger.Type != models.TriggerTypeFormSubmission {
+		t.Errorf("trigger type = %s, want form_submission", workflow.Trigger.Type)
+	}
+	if workflow.Webhook != nil {
+		t.Errorf("webhook = %+v, want none for a form submission trigger", workflow.Webhook)
+	}
+
+	first := env.activeWorkflow(t, nil, withTrigger(models.TriggerTypeWebhook, nil))
+	second := env.activeWorkflow(t, nil, withTrigger(models.TriggerTypeWebhook, nil))
+	if first.Trigger.Type != models.TriggerTypeWebhook {
+		t.Errorf("trigger type = %s, want webhook", first.Trigger.Type)
+	}
+	if webhookToken(t, first) == webhookToken(t, second) || first.Webhook.Secret == second.Webhook.Secret {
+		t.Errorf("webhooks %+v and %+v share a token or secret", first.Webhook, second.Webhook)
+	}
+
+	got, err := env.workflows.GetWorkflow(env.ctx, first.ID)
+	if err != nil {
+		t.Fatalf("GetWorkflow: %v", err)
+	}
+	if got.Webhook == nil || *got.Webhook != *first.Webhook {
+		t.Errorf("GetWorkflow webhook = %+v, want %+v", got.Webhook, first.Webhook)
+	}
+}
+
+func TestUpdateWorkflowTriggerType(t *testing.T) {
+	env := newTestEnv(t)
+	workflow := env.createWorkflow(t, nil)
+	webhook := models.TriggerTypeWebhook
+	manual := models.TriggerTypeManual
+
+	updated, err := env.workflows.UpdateWorkflow(env.ctx, workflow.ID, UpdateWorkflowRequest{TriggerType: &webhook})
+	if err != nil {
+		t.Fatalf("UpdateWorkflow: %v", err)
+	}
+	if updated.Trigger.Type != models.TriggerTypeWebhook || updated.Webhook == nil {
+		t.Fatalf("trigger = %+v, webhook = %+v, want a webhook trigger with a webhook", updated.Trigger, updated.Webhook)
+	}
+
+	// Changing only the config keeps the type and the existing URL and secret
+	config := map[string]interface{}{"fieldMapping": map[string]interface{}{"email": "customer.email"}}
+	again, err := env.workflows.UpdateWorkflow(env.ctx, workflow.ID, UpdateWorkflowRequest{TriggerConfig: config})
+	if err != nil {
+		t.Fatalf("UpdateWorkflow: %v", err)
+	}
+	if again.Trigger.Type != models.TriggerTypeWebhook || again.Webhook == nil || *again.Webhook != *updated.Webhook {
+		t.Errorf("trigger = %+v, webhook = %+v, want the webhook %+v to be kept", again.Trigger, again.Webhook, updated.Webhook)
+	}
+
+	reverted, err := env.workflows.UpdateWorkflow(env.ctx, workflow.ID, UpdateWorkflowRequest{TriggerType: &manual})
+	if err != nil {
+		t.Fatalf("UpdateWorkflow: %v", err)
+	}
+	if reverted.Webhook != nil {
+		t.Errorf("webhook = %+v, want none after switching to manual", reverted.Webhook)
+	}
+	if _, err := env.queries.GetWorkflowWebhook(env.ctx, mustUUID(workflow.ID)); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("webhook survived the trigger change: %v", err)
+	}
+
+	unknown := models.TriggerType("cron")
+	_, err = env.workflows.UpdateWorkflow(env.ctx, workflow.ID, UpdateWorkflowRequest{TriggerType: &unknown})
+	assertError(t, err, KindValidation, "validation_failed")
+}
+
+func TestReceiveWebhook(t *testing.T) {
+	env := newTestEnv(t)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	env.webhooks.(*webhookService).now = func() time.Time { return now }
+
+	form := env.createForm(t,
+		models.Field{ID: "email", Type: "email", Label: "Email", Required: true},
+		models.Field{ID: "item", Type: "text", Label: "Item"},
+	)
+	workflow := env.activeWorkflow(t, &form.ID, withTrigger(models.TriggerTypeWebhook, map[string]interface{}{
+		"fieldMapping": map[string]interface{}{"email": "customer.email", "item": "items.0.name"},
+	}))
+	token := webhookToken(t, workflow)
+	secret := workflow.Webhook.Secret
+
+	paused := env.activeWorkflow(t, nil, withTrigger(models.TriggerTypeWebhook, nil))
+	if _, err := env.workflows.UpdateWorkflowStatus(env.ctx, paused.ID, models.WorkflowStatusPaused, env.owner); err != nil {
+		t.Fatalf("UpdateWorkflowStatus: %v", err)
+	}
+
+	payload := `{"customer": {"email": "ada@example.com"}, "items": [{"name": "Lamp"}]}`
+	tampered := signedRequest(secret, now, payload)
+	tampered.Payload = []byte(strings.Replace(payload, "ada", "eve", 1))
+
+	tests := []struct {
+		name  string
+		token string
+		req   WebhookRequest
+		kind  ErrorKind
+		code  string
+	}{
+		{"valid", token, signedRequest(secret, now, payload), "", ""},
+		{"within tolerance", token, signedRequest(secret, now.Add(-4*time.Minute), payload), "", ""},
+		{"unknown token", "nope", signedRequest(secret, now, payload), KindNotFound, "webhook_not_found"},
+		{"wrong secret", token, signedRequest("whsec_other", now, payload), KindUnauthenticated, "invalid_webhook_signature"},
+		{"tampered payload", token, tampered, KindUnauthenticated, "invalid_webhook_signature"},
+		{"missing signature", token, WebhookRequest{Payload: []byte(payload), Timestamp: strconv.FormatInt(now.Unix(), 10)}, KindUnauthenticated, "invalid_webhook_signature"},
+		{"missing timestamp", token, WebhookRequest{Payload: []byte(payload), Signature: SignWebhook(secret, now, []byte(payload))}, KindUnauthenticated, "invalid_webhook_signature"},
+		{"stale timestamp", token, signedRequest(secret, now.Add(-6*time.Minute), payload), KindUnauthenticated, "invalid_webhook_signature"},
+		{"not an object", token, signedRequest(secret, now, `[1, 2]`), KindInvalidInput, "invalid_webhook_payload"},
+		{"mapped data invalid", token, signedRequest(secret, now, `{"customer": {}}`), KindValidation, "invalid_submission"},
+		{"paused workflow", webhookToken(t, paused), signedRequest(paused.Webhook.Secret, now, payload), KindConflict, "workflow_not_active"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submission, err := env.webhooks.ReceiveWebhook(env.ctx, tt.token, tt.req)
+			if tt.kind != "" {
+				assertError(t, err, tt.kind, tt.code)
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReceiveWebhook: %v", err)
+			}
+
+			if submission.WorkflowID != workflow.ID || submission.Status != models.SubmissionStatusPending {
+				t.Errorf("submission = %+v, want a pending submission of workflow %s", submission, workflow.ID)
+			}
+			want := map[string]interface{}{"email": "ada@example.com", "item": "Lamp"}
+			if !reflect.DeepEqual(submission.Data, want) {
+				t.Errorf("data = %v, want %v", submission.Data, want)
+			}
+		})
+	}
+}
+
+func TestMapWebhookPayload(t *testing.T) {
+	payload := map[string]interface{}{
+		"id":       "ord_1",
+		"customer": map[string]interface{}{"email": "ada@example.com", "phone": nil},
+		"items":    []interface{}{map[string]interface{}{"name": "Lamp"}},
+	}
+
+	tests := []struct {
+		name    string
+		mapping map[string]string
+		want    map[string]interface{}
+	}{
+		{"no mapping", nil, payload},
+		{"nested object", map[string]string{"email": "customer.email"}, map[string]interface{}{"email": "ada@example.com"}},
+		{"array index", map[string]string{"item": "items.0.name"}, map[string]interface{}{"item": "Lamp"}},
+		{"index out of range", map[string]string{"item": "items.1.name"}, map[string]interface{}{}},
+		{"index into object", map[string]string{"item": "customer.0"}, map[string]interface{}{}},
+		{"missing key", map[string]string{"email": "email"}, map[string]interface{}{}},
+		{"null value", map[string]string{"phone": "customer.phone"}, map[string]interface{}{}},
+		{"through a scalar", map[string]string{"id": "id.value"}, map[string]interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mapWebhookPayload(payload, tt.mapping); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,20 +3,48 @@ package logic
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 
 	"github.com/google/uuid"
 	"github.com/hungaikev/rootd/backend/internal/db"
 	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+var (
+	// ErrInvalidStatusTransition is returned when a workflow cannot move from its
+	// current status to the requested one.
+	ErrInvalidStatusTransition = Conflict("invalid_status_transition", "invalid workflow status transition", nil)
+	// ErrWorkflowNotDraft is returned when editing a workflow that has left draft.
+	ErrWorkflowNotDraft = Conflict("workflow_not_draft", "workflow can only be updated in draft status", nil)
+	// ErrWorkflowHasSubmissions is returned when deleting a workflow that has submissions.
+	ErrWorkflowHasSubmissions = Conflict("workflow_has_submissions", "cannot delete workflow with existing submissions", nil)
+	// ErrWorkflowNotActive is returned when a workflow that is not active receives form traffic.
+	ErrWorkflowNotActive = Conflict("workflow_not_active", "workflow is not active", nil)
+	// ErrTriggerNotForm is returned when the public form endpoint receives a submission
+	// for a workflow with a webhook or schedule trigger.
+	ErrTriggerNotForm = Conflict("trigger_not_form", "workflow does not accept form submissions", nil)
+)
+
+// workflowTransitions lists the statuses each workflow status may move to.
+// Archived is terminal.
+var workflowTransitions = map[models.WorkflowStatus][]models.WorkflowStatus{
+	models.WorkflowStatusDraft:    {models.WorkflowStatusActive, models.WorkflowStatusArchived},
+	models.WorkflowStatusActive:   {models.WorkflowStatusPaused, models.WorkflowStatusStopped, models.WorkflowStatusArchived},
+	models.WorkflowStatusPaused:   {models.WorkflowStatusActive, models.WorkflowStatusStopped, models.WorkflowStatusArchived},
+	models.WorkflowStatusStopped:  {models.WorkflowStatusArchived},
+	models.WorkflowStatusArchived: {},
+}
+
 type workflowService struct {
-	queries *db.Queries
+	queries db.Store
 }
 
 // NewWorkflowService creates a new workflow service
-func NewWorkflowService(queries *db.Queries) WorkflowService {
+func NewWorkflowService(queries db.Store) WorkflowService {
 	return &workflowService{
 		queries: queries,
 	}
@@ -25,14 +53,24 @@ func NewWorkflowService(queries *db.Queries) WorkflowService {
 func (s *workflowService) CreateWorkflow(ctx context.Context, req CreateWorkflowRequest) (*models.Workflow, error) {
 	// Validate business rules
 	if err := s.validateCreateWorkflow(req); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return nil, validationFailed(err)
 	}
 
 	// Convert request to database params
-	trigger, _ := json.Marshal(map[string]interface{}{
-		"type":   "manual", // Default trigger type
-		"config": req.TriggerConfig,
-	})
+	triggerType := req.TriggerType
+	if triggerType == "" {
+		triggerType = models.TriggerTypeManual
+	}
+	trigger, err := buildTrigger(triggerType, req.TriggerConfig)
+	if err != nil {
+		return nil, validationFailed(err)
+	}
+	if req.Actions == nil {
+		req.Actions = []models.Action{}
+	}
+	if err := validateActions(req.Actions); err != nil {
+		return nil, validationFailed(err)
+	}
 	actions, _ := json.Marshal(req.Actions)
 
 	ownerUUID := uuid.MustParse(req.OwnerID)
@@ -50,28 +88,105 @@ func (s *workflowService) CreateWorkflow(ctx context.Context, req CreateWorkflow
 		params.SchemaID = pgtype.UUID{Bytes: schemaID, Valid: true}
 	}
 
-	// Create workflow in database
-	workflow, err := s.queries.CreateWorkflow(ctx, &params)
+	// Create workflow in database. The insert is serializable so that a concurrent
+	// DeleteForm either sees the reference to its form or makes this insert retry.
+	// Webhook and schedule triggers get their URL or fire times in the same transaction.
+	var workflow *db.Workflow
+	var webhook *db.WorkflowWebhook
+	var schedule *db.WorkflowSchedule
+	var secret *db.WorkflowSigningSecret
+	err = inTx(ctx, s.queries, func(q db.Querier) error {
+		if params.SchemaID.Valid {
+			if err := checkFormOwner(ctx, q, params.SchemaID, params.OwnerID); err != nil {
+				return err
+			}
+		}
+		var err error
+		if workflow, err = q.CreateWorkflow(ctx, &params); err != nil {
+			return dbError(err, "failed to create workflow")
+		}
+		if webhook, schedule, err = s.syncTrigger(ctx, q, workflow); err != nil {
+			return err
+		}
+		secret, err = syncSigningSecret(ctx, q, workflow.ID, req.Actions)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create workflow: %w", err)
+		return nil, err
 	}
 
 	// Convert database model to business model
-	return s.dbToModel(*workflow), nil
+	result := s.dbToModel(*workflow)
+	result.Webhook = webhookToModel(webhook)
+	result.Schedule = scheduleToModel(schedule)
+	result.SigningSecret = signingSecretValue(secret)
+	return result, nil
 }
 
 func (s *workflowService) GetWorkflow(ctx context.Context, id string) (*models.Workflow, error) {
 	workflowID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid workflow ID: %w", err)
+		return nil, invalidID("workflow", err)
 	}
 
 	workflow, err := s.queries.GetWorkflow(ctx, pgtype.UUID{Bytes: workflowID, Valid: true})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get workflow: %w", err)
+		return nil, dbNotFound(err, "workflow", "failed to get workflow")
 	}
 
-	return s.dbToModel(*workflow), nil
+	summary, err := s.queries.GetWorkflowSubmissionSummary(ctx, workflow.ID)
+	if err != nil {
+		return nil, dbError(err, "failed to get workflow submission summary")
+	}
+
+	result := s.dbToModel(*workflow)
+	result.SubmissionSummary = summaryToModel(summary.TotalVisits, summary.CompletedVisits,
+		summary.TotalSubmissions, summary.AverageTimeToComplete, summary.LastSubmissionAt)
+
+	switch result.Trigger.Type {
+	case models.TriggerTypeWebhook:
+		webhook, err := s.queries.GetWorkflowWebhook(ctx, workflow.ID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, dbError(err, "failed to get workflow webhook")
+		}
+		if err == nil {
+			result.Webhook = webhookToModel(webhook)
+		}
+	case models.TriggerTypeSchedule:
+		schedule, err := s.queries.GetWorkflowSchedule(ctx, workflow.ID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, dbError(err, "failed to get workflow schedule")
+		}
+		if err == nil {
+			result.Schedule = scheduleToModel(schedule)
+		}
+	}
+
+	secret, err := s.queries.GetWorkflowSigningSecret(ctx, workflow.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, dbError(err, "failed to get workflow signing secret")
+	}
+	if err == nil {
+		result.SigningSecret = signingSecretValue(secret)
+	}
+
+	return result, nil
+}
+
+// GetWorkflowOwner returns the ID of the workflow's owner without loading its
+// submission summary, webhook, schedule or signing secret.
+func (s *workflowService) GetWorkflowOwner(ctx context.Context, id string) (string, error) {
+	workflowID, err := uuid.Parse(id)
+	if err != nil {
+		return "", invalidID("workflow", err)
+	}
+
+	workflow, err := s.queries.GetWorkflow(ctx, pgtype.UUID{Bytes: workflowID, Valid: true})
+	if err != nil {
+		return "", dbNotFound(err, "workflow", "failed to get workflow")
+	}
+
+	return uuid.UUID(workflow.OwnerID.Bytes[:]).String(), nil
 }
 
 func (s *workflowService) ListWorkflows(ctx context.Context, ownerID string) ([]*models.Workflow, error) {
@@ -82,12 +197,26 @@ func (s *workflowService) ListWorkflows(ctx context.Context, ownerID string) ([]
 
 	workflows, err := s.queries.ListWorkflows(ctx, pgtype.UUID{Bytes: ownerUUID, Valid: true})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list workflows: %w", err)
+		return nil, dbError(err, "failed to list workflows")
+	}
+
+	summaries, err := s.queries.ListWorkflowSubmissionSummaries(ctx, pgtype.UUID{Bytes: ownerUUID, Valid: true})
+	if err != nil {
+		return nil, dbError(err, "failed to list workflow submission summaries")
+	}
+
+	summaryByWorkflow := make(map[[16]byte]*db.ListWorkflowSubmissionSummariesRow, len(summaries))
+	for _, summary := range summaries {
+		summaryByWorkflow[summary.WorkflowID.Bytes] = summary
 	}
 
 	result := make([]*models.Workflow, len(workflows))
 	for i, workflow := range workflows {
 		result[i] = s.dbToModel(*workflow)
+		if summary, ok := summaryByWorkflow[workflow.ID.Bytes]; ok {
+			result[i].SubmissionSummary = summaryToModel(summary.TotalVisits, summary.CompletedVisits,
+				summary.TotalSubmissions, summary.AverageTimeToComplete, summary.LastSubmissionAt)
+		}
 	}
 
 	return result, nil
@@ -96,111 +225,214 @@ func (s *workflowService) ListWorkflows(ctx context.Context, ownerID string) ([]
 func (s *workflowService) UpdateWorkflow(ctx context.Context, id string, req UpdateWorkflowRequest) (*models.Workflow, error) {
 	workflowID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid workflow ID: %w", err)
+		return nil, invalidID("workflow", err)
 	}
 
-	// Get existing workflow to check if it's in draft status
-	existing, err := s.queries.GetWorkflow(ctx, pgtype.UUID{Bytes: workflowID, Valid: true})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get existing workflow: %w", err)
-	}
+	var schemaID *pgtype.UUID
+	if req.SchemaID != nil {
+		id, err := uuid.Parse(*req.SchemaID)
+		if err != nil {
+			return nil, validationFailed(fmt.Errorf("schema ID must be a UUID"))
+		}
+		schemaID = &pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	// Read and update in one transaction so the workflow cannot leave draft in between
+	var workflow *db.Workflow
+	var webhook *db.WorkflowWebhook
+	var schedule *db.WorkflowSchedule
+	var secret *db.WorkflowSigningSecret
+	err = inTx(ctx, s.queries, func(q db.Querier) error {
+		existing, err := q.GetWorkflow(ctx, pgtype.UUID{Bytes: workflowID, Valid: true})
+		if err != nil {
+			return dbNotFound(err, "workflow", "failed to get existing workflow")
+		}
 
-	// Business rule: Only allow updates if workflow is in draft status
-	if existing.Status != string(models.WorkflowStatusDraft) {
-		return nil, fmt.Errorf("workflow can only be updated in draft status")
-	}
+		// Business rule: Only allow updates if workflow is in draft status
+		if existing.Status != string(models.WorkflowStatusDraft) {
+			return ErrWorkflowNotDraft
+		}
 
-	// Prepare update params
-	params := db.UpdateWorkflowParams{
-		ID: pgtype.UUID{Bytes: workflowID, Valid: true},
-	}
+		// Prepare update params
+		params := db.UpdateWorkflowParams{
+			ID: pgtype.UUID{Bytes: workflowID, Valid: true},
+		}
 
-	if req.Name != nil {
-		params.Name = *req.Name
-	} else {
-		params.Name = existing.Name
-	}
+		if req.Name != nil {
+			params.Name = *req.Name
+		} else {
+			params.Name = existing.Name
+		}
 
-	if req.Description != nil {
-		params.Description = pgtype.Text{String: *req.Description, Valid: true}
-	} else {
-		params.Description = existing.Description
-	}
+		if req.Description != nil {
+			params.Description = pgtype.Text{String: *req.Description, Valid: true}
+		} else {
+			params.Description = existing.Description
+		}
 
-	if req.SchemaID != nil {
-		schemaID := uuid.MustParse(*req.SchemaID)
-		params.SchemaID = pgtype.UUID{Bytes: schemaID, Valid: true}
-	} else {
-		params.SchemaID = existing.SchemaID
-	}
+		if schemaID != nil {
+			if err := checkFormOwner(ctx, q, *schemaID, existing.OwnerID); err != nil {
+				return err
+			}
+			params.SchemaID = *schemaID
+		} else {
+			params.SchemaID = existing.SchemaID
+		}
 
-	if req.TriggerConfig != nil {
-		trigger, _ := json.Marshal(map[string]interface{}{
-			"type":   "manual", // Default trigger type
-			"config": req.TriggerConfig,
-		})
-		params.Trigger = trigger
-	} else {
-		params.Trigger = existing.Trigger
-	}
+		// The trigger type and config can be changed independently of each other
+		var trigger models.Trigger
+		json.Unmarshal(existing.Trigger, &trigger)
+		if trigger.Type == "" {
+			trigger.Type = models.TriggerTypeManual
+		}
+		if req.TriggerType != nil || req.TriggerConfig != nil {
+			if req.TriggerType != nil {
+				trigger.Type = *req.TriggerType
+			}
+			config := req.TriggerConfig
+			if config == nil {
+				json.Unmarshal(trigger.Config, &config)
+			}
+			if params.Trigger, err = buildTrigger(trigger.Type, config); err != nil {
+				return validationFailed(err)
+			}
+		} else {
+			params.Trigger = existing.Trigger
+		}
 
-	if req.Actions != nil {
-		actions, _ := json.Marshal(req.Actions)
-		params.Actions = actions
-	} else {
-		params.Actions = existing.Actions
-	}
+		if req.Actions != nil {
+			if err := validateActions(req.Actions); err != nil {
+				return validationFailed(err)
+			}
+			actions, _ := json.Marshal(req.Actions)
+			params.Actions = actions
+		} else {
+			params.Actions = existing.Actions
+		}
+
+		// Update workflow in database
+		if workflow, err = q.UpdateWorkflow(ctx, &params); err != nil {
+			return dbError(err, "failed to update workflow")
+		}
 
-	// Update workflow in database
-	workflow, err := s.queries.UpdateWorkflow(ctx, &params)
+		if webhook, schedule, err = s.syncTrigger(ctx, q, workflow); err != nil {
+			return err
+		}
+		var actions []models.Action
+		json.Unmarshal(workflow.Actions, &actions)
+		secret, err = syncSigningSecret(ctx, q, workflow.ID, actions)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update workflow: %w", err)
+		return nil, err
 	}
 
-	return s.dbToModel(*workflow), nil
+	result := s.dbToModel(*workflow)
+	result.Webhook = webhookToModel(webhook)
+	result.Schedule = scheduleToModel(schedule)
+	result.SigningSecret = signingSecretValue(secret)
+	return result, nil
 }
 
-func (s *workflowService) UpdateWorkflowStatus(ctx context.Context, id string, status models.WorkflowStatus) (*models.Workflow, error) {
+func (s *workflowService) UpdateWorkflowStatus(ctx context.Context, id string, status models.WorkflowStatus, changedBy string) (*models.Workflow, error) {
 	workflowID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid workflow ID: %w", err)
+		return nil, invalidID("workflow", err)
 	}
 
-	// Validate status transition
-	if err := s.validateStatusTransition(status); err != nil {
-		return nil, fmt.Errorf("invalid status transition: %w", err)
+	changedByUUID, err := uuid.Parse(changedBy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
 	}
 
-	params := db.UpdateWorkflowStatusParams{
-		ID:     pgtype.UUID{Bytes: workflowID, Valid: true},
-		Status: string(status),
-	}
+	var workflow *db.Workflow
+	err = inTx(ctx, s.queries, func(q db.Querier) error {
+		existing, err := q.GetWorkflow(ctx, pgtype.UUID{Bytes: workflowID, Valid: true})
+		if err != nil {
+			return dbNotFound(err, "workflow", "failed to get existing workflow")
+		}
+
+		// Validate status transition
+		from := models.WorkflowStatus(existing.Status)
+		if err := s.validateStatusTransition(from, status); err != nil {
+			return err
+		}
+
+		params := db.TransitionWorkflowStatusParams{
+			ID:             existing.ID,
+			Status:         string(status),
+			ExpectedStatus: string(from),
+			ChangedBy:      pgtype.UUID{Bytes: changedByUUID, Valid: true},
+		}
 
-	workflow, err := s.queries.UpdateWorkflowStatus(ctx, &params)
+		// The update only applies if nobody changed the status since we read it
+		workflow, err = q.TransitionWorkflowStatus(ctx, &params)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Conflict(ErrInvalidStatusTransition.Code, fmt.Sprintf("workflow status changed concurrently, expected %s", from), nil)
+		}
+		if err != nil {
+			return dbError(err, "failed to update workflow status")
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update workflow status: %w", err)
+		return nil, err
 	}
 
 	return s.dbToModel(*workflow), nil
 }
 
-func (s *workflowService) DeleteWorkflow(ctx context.Context, id string) error {
+func (s *workflowService) ListStatusTransitions(ctx context.Context, id string) ([]*models.WorkflowStatusTransition, error) {
 	workflowID, err := uuid.Parse(id)
 	if err != nil {
-		return fmt.Errorf("invalid workflow ID: %w", err)
+		return nil, invalidID("workflow", err)
 	}
 
-	// Check if workflow has submissions
-	submissions, err := s.queries.ListSubmissions(ctx, pgtype.UUID{Bytes: workflowID, Valid: true})
+	transitions, err := s.queries.ListWorkflowStatusTransitions(ctx, pgtype.UUID{Bytes: workflowID, Valid: true})
 	if err != nil {
-		return fmt.Errorf("failed to check workflow submissions: %w", err)
+		return nil, dbError(err, "failed to list workflow status transitions")
+	}
+
+	result := make([]*models.WorkflowStatusTransition, len(transitions))
+	for i, transition := range transitions {
+		result[i] = &models.WorkflowStatusTransition{
+			ID:         uuid.UUID(transition.ID.Bytes[:]).String(),
+			WorkflowID: uuid.UUID(transition.WorkflowID.Bytes[:]).String(),
+			From:       models.WorkflowStatus(transition.FromStatus),
+			To:         models.WorkflowStatus(transition.ToStatus),
+			ChangedBy:  uuid.UUID(transition.ChangedBy.Bytes[:]).String(),
+			ChangedAt:  transition.ChangedAt,
+		}
 	}
 
-	if len(submissions) > 0 {
-		return fmt.Errorf("cannot delete workflow with existing submissions")
+	return result, nil
+}
+
+func (s *workflowService) DeleteWorkflow(ctx context.Context, id string) error {
+	workflowID, err := uuid.Parse(id)
+	if err != nil {
+		return invalidID("workflow", err)
 	}
 
-	return s.queries.DeleteWorkflow(ctx, pgtype.UUID{Bytes: workflowID, Valid: true})
+	// Count and delete in one transaction so no submission can arrive in between
+	return inTx(ctx, s.queries, func(q db.Querier) error {
+		count, err := q.CountSubmissions(ctx, &db.CountSubmissionsParams{
+			WorkflowID: pgtype.UUID{Bytes: workflowID, Valid: true},
+		})
+		if err != nil {
+			return dbError(err, "failed to check workflow submissions")
+		}
+
+		if count > 0 {
+			return ErrWorkflowHasSubmissions
+		}
+
+		if err := q.DeleteWorkflow(ctx, pgtype.UUID{Bytes: workflowID, Valid: true}); err != nil {
+			return dbError(err, "failed to delete workflow")
+		}
+
+		return nil
+	})
 }
 
 // Helper methods
@@ -211,52 +443,148 @@ func (s *workflowService) validateCreateWorkflow(req CreateWorkflowRequest) erro
 	if req.OwnerID == "" {
 		return fmt.Errorf("owner ID is required")
 	}
+	if _, err := uuid.Parse(req.OwnerID); err != nil {
+		return fmt.Errorf("owner ID must be a UUID")
+	}
+	if req.SchemaID != nil {
+		if _, err := uuid.Parse(*req.SchemaID); err != nil {
+			return fmt.Errorf("schema ID must be a UUID")
+		}
+	}
 	return nil
 }
 
-func (s *workflowService) validateStatusTransition(status models.WorkflowStatus) error {
-	validStatuses := []models.WorkflowStatus{
-		models.WorkflowStatusDraft,
-		models.WorkflowStatusActive,
-		models.WorkflowStatusPaused,
-		models.WorkflowStatusArchived,
+// validateActions checks the routing and conditionals of a workflow's actions, and the
+// configuration of the action types that parse theirs.
+func validateActions(actions []models.Action) error {
+	if err := validateActionGraph(actions); err != nil {
+		return err
 	}
 
-	for _, validStatus := range validStatuses {
-		if status == validStatus {
-			return nil
+	for _, action := range actions {
+		var err error
+		if action.Conditional != nil {
+			if err := validateConditional(action.Conditional, nil); err != nil {
+				return fmt.Errorf("action %s: %w", action.ID, err)
+			}
+		}
+		switch action.Type {
+		case models.ActionTypeCondition:
+			var cond *models.Conditional
+			if cond, err = actionConditional(action); err == nil {
+				err = validateConditional(cond, nil)
+			}
+		case models.ActionTypeSendEmail:
+			_, err = parseEmailConfig(action.Config)
+		case models.ActionTypeCallWebhook:
+			_, err = parseWebhookActionConfig(action.Config)
+		}
+		if err != nil {
+			return fmt.Errorf("action %s: %w", action.ID, err)
+		}
+	}
+	return nil
+}
+
+// buildTrigger validates a trigger and encodes it for the workflows.trigger column.
+func buildTrigger(triggerType models.TriggerType, config map[string]interface{}) ([]byte, error) {
+	trigger := models.Trigger{Type: triggerType}
+	if config != nil {
+		trigger.Config, _ = json.Marshal(config)
+	}
+
+	switch triggerType {
+	case models.TriggerTypeManual, models.TriggerTypeFormSubmission:
+	case models.TriggerTypeWebhook:
+		if _, err := parseWebhookTriggerConfig(trigger.Config); err != nil {
+			return nil, err
+		}
+	case models.TriggerTypeSchedule:
+		if _, err := parseScheduleTriggerConfig(trigger.Config); err != nil {
+			return nil, err
 		}
+	default:
+		return nil, fmt.Errorf("unknown trigger type %q", triggerType)
 	}
 
-	return fmt.Errorf("invalid status: %s", status)
+	return json.Marshal(trigger)
 }
 
-func (s *workflowService) dbToModel(workflow db.Workflow) *models.Workflow {
-	var triggerData map[string]interface{}
-	var actions []map[string]interface{}
+// checkFormOwner checks that a form exists and belongs to ownerID. Another owner's
+// form is reported as not found, as it is everywhere else.
+func checkFormOwner(ctx context.Context, q db.Querier, formID, ownerID pgtype.UUID) error {
+	form, err := q.GetForm(ctx, formID)
+	if err != nil {
+		return dbNotFound(err, "form", "failed to get form")
+	}
+	if form.OwnerID != ownerID {
+		return NotFound("form_not_found", "form not found", nil)
+	}
+	return nil
+}
 
-	json.Unmarshal(workflow.Trigger, &triggerData)
-	json.Unmarshal(workflow.Actions, &actions)
+// syncTrigger keeps the webhook and schedule of a workflow in line with its trigger and
+// returns them; each is nil unless the trigger has that type.
+func (s *workflowService) syncTrigger(ctx context.Context, q db.Querier, workflow *db.Workflow) (*db.WorkflowWebhook, *db.WorkflowSchedule, error) {
+	var trigger models.Trigger
+	json.Unmarshal(workflow.Trigger, &trigger)
 
-	// Convert actions to the proper structure
-	modelActions := make([]models.Action, len(actions))
-	for i, action := range actions {
-		config, _ := json.Marshal(action["config"])
-		modelActions[i] = models.Action{
-			ID:          action["id"].(string),
-			Type:        models.ActionType(action["type"].(string)),
-			Description: action["description"].(string),
-			Config:      config,
+	webhook, err := syncWorkflowWebhook(ctx, q, workflow.ID, trigger.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schedule, err := syncWorkflowSchedule(ctx, q, workflow.ID, trigger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return webhook, schedule, nil
+}
+
+// validateStatusTransition checks a status change against workflowTransitions.
+func (s *workflowService) validateStatusTransition(from, to models.WorkflowStatus) error {
+	if _, ok := workflowTransitions[to]; !ok {
+		return Validation("invalid_status", fmt.Sprintf("unknown status %q", to), nil)
+	}
+
+	for _, allowed := range workflowTransitions[from] {
+		if to == allowed {
+			return nil
 		}
 	}
 
-	// Convert trigger
-	triggerConfig, _ := json.Marshal(triggerData["config"])
-	trigger := models.Trigger{
-		Type:   models.TriggerType(triggerData["type"].(string)),
-		Config: triggerConfig,
+	return Conflict(ErrInvalidStatusTransition.Code, fmt.Sprintf("cannot change status from %s to %s", from, to), nil)
+}
+
+// summaryToModel builds the analytics summary from the aggregated counters.
+// CompletionRate is the percentage of visiting sessions that went on to submit.
+func summaryToModel(totalVisits, completedVisits, totalSubmissions int64, averageSeconds float64, lastSubmissionAt pgtype.Timestamptz) models.SubmissionSummary {
+	summary := models.SubmissionSummary{
+		TotalVisits:           int(totalVisits),
+		TotalSubmissions:      int(totalSubmissions),
+		AverageTimeToComplete: int(math.Round(averageSeconds)),
+	}
+
+	if totalVisits > 0 {
+		summary.CompletionRate = math.Round(float64(completedVisits)/float64(totalVisits)*10000) / 100
+	}
+
+	if lastSubmissionAt.Valid {
+		t := lastSubmissionAt.Time
+		summary.LastSubmissionAt = &t
 	}
 
+	return summary
+}
+
+func (s *workflowService) dbToModel(workflow db.Workflow) *models.Workflow {
+	var trigger models.Trigger
+	actions := []models.Action{}
+
+	json.Unmarshal(workflow.Trigger, &trigger)
+	json.Unmarshal(workflow.Actions, &actions)
+
 	schemaID := ""
 	if workflow.SchemaID.Valid {
 		schemaID = uuid.UUID(workflow.SchemaID.Bytes[:]).String()
@@ -269,7 +597,7 @@ func (s *workflowService) dbToModel(workflow db.Workflow) *models.Workflow {
 		OwnerID:   uuid.UUID(workflow.OwnerID.Bytes[:]).String(),
 		SchemaID:  schemaID,
 		Trigger:   trigger,
-		Actions:   modelActions,
+		Actions:   actions,
 		CreatedAt: workflow.CreatedAt,
 		UpdatedAt: workflow.UpdatedAt,
 	}
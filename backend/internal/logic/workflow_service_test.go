@@ -0,0 +1,510 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/db/memdb"
+	"github.com/hungaikev/rootd/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestCreateWorkflow(t *testing.T) {
+	env := newTestEnv(t)
+	form := env.createForm(t)
+
+	tests := []struct {
+		name    string
+		req     CreateWorkflowRequest
+		wantErr bool
+	}{
+		{"valid", CreateWorkflowRequest{Name: "Intake", OwnerID: env.owner}, false},
+		{"with schema", CreateWorkflowRequest{Name: "Intake", OwnerID: env.owner, SchemaID: &form.ID}, false},
+		{"missing name", CreateWorkflowRequest{OwnerID: env.owner}, true},
+		{"missing owner", CreateWorkflowRequest{Name: "Intake"}, true},
+		{"owner not a UUID", CreateWorkflowRequest{Name: "Intake", OwnerID: "alice"}, true},
+		{"schema not a UUID", CreateWorkflowRequest{Name: "Intake", OwnerID: env.owner, SchemaID: ptr("contact")}, true},
+		{"unknown trigger type", CreateWorkflowRequest{Name: "Intake", OwnerID: env.owner, TriggerType: "cron"}, true},
+		{"invalid field mapping", CreateWorkflowRequest{Name: "Intake", OwnerID: env.owner, TriggerType: models.TriggerTypeWebhook,
+			TriggerConfig: map[string]interface{}{"fieldMapping": map[string]interface{}{"email": "customer..email"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow, err := env.workflows.CreateWorkflow(env.ctx, tt.req)
+			if tt.wantErr {
+				assertError(t, err, KindValidation, "validation_failed")
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateWorkflow: %v", err)
+			}
+
+			if workflow.Status != models.WorkflowStatusDraft {
+				t.Errorf("status = %s, want draft", workflow.Status)
+			}
+			if workflow.Trigger.Type != models.TriggerTypeManual {
+				t.Errorf("trigger type = %s, want manual", workflow.Trigger.Type)
+			}
+			if workflow.Actions == nil || len(workflow.Actions) != 0 {
+				t.Errorf("actions = %v, want empty", workflow.Actions)
+			}
+			if tt.req.SchemaID != nil && workflow.SchemaID != *tt.req.SchemaID {
+				t.Errorf("schema ID = %s, want %s", workflow.SchemaID, *tt.req.SchemaID)
+			}
+		})
+	}
+}
+
+func TestCreateWorkflowFormOwnership(t *testing.T) {
+	env := newTestEnv(t)
+	other := env.otherOwnersForm(t)
+
+	tests := []struct {
+		name     string
+		schemaID string
+	}{
+		{"another owner's form", other.ID},
+		{"unknown form", uuid.NewString()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.workflows.CreateWorkflow(env.ctx, CreateWorkflowRequest{Name: "Intake", OwnerID: env.owner, SchemaID: &tt.schemaID})
+			assertError(t, err, KindNotFound, "form_not_found")
+		})
+	}
+}
+
+// otherOwnersForm creates a form that belongs to an owner other than env.owner.
+func (e *testEnv) otherOwnersForm(t *testing.T) *models.Form {
+	t.Helper()
+
+	form, err := e.forms.CreateForm(e.ctx, CreateFormRequest{
+		Name:    "Private",
+		Schema:  schemaMap(t, models.FormSchema{}),
+		OwnerID: uuid.NewString(),
+	})
+	if err != nil {
+		t.Fatalf("CreateForm: %v", err)
+	}
+	return form
+}
+
+func TestGetWorkflow(t *testing.T) {
+	env := newTestEnv(t)
+	created := env.createWorkflow(t, nil)
+
+	workflow, err := env.workflows.GetWorkflow(env.ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetWorkflow: %v", err)
+	}
+	if workflow.ID != created.ID || workflow.Name != created.Name {
+		t.Errorf("got %+v, want %+v", workflow, created)
+	}
+
+	_, err = env.workflows.GetWorkflow(env.ctx, uuid.NewString())
+	assertError(t, err, KindNotFound, "workflow_not_found")
+
+	_, err = env.workflows.GetWorkflow(env.ctx, "not-a-uuid")
+	assertError(t, err, KindNotFound, "workflow_not_found")
+}
+
+func TestGetWorkflowOwner(t *testing.T) {
+	env := newTestEnv(t)
+	created := env.createWorkflow(t, nil)
+
+	owner, err := env.workflows.GetWorkflowOwner(env.ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetWorkflowOwner: %v", err)
+	}
+	if owner != env.owner {
+		t.Errorf("owner = %s, want %s", owner, env.owner)
+	}
+
+	_, err = env.workflows.GetWorkflowOwner(env.ctx, uuid.NewString())
+	assertError(t, err, KindNotFound, "workflow_not_found")
+
+	_, err = env.workflows.GetWorkflowOwner(env.ctx, "not-a-uuid")
+	assertError(t, err, KindNotFound, "workflow_not_found")
+}
+
+func TestListWorkflows(t *testing.T) {
+	env := newTestEnv(t)
+	first := env.createWorkflow(t, nil)
+	second := env.createWorkflow(t, nil)
+
+	_, err := env.workflows.CreateWorkflow(env.ctx, CreateWorkflowRequest{Name: "Other", OwnerID: uuid.NewString()})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+
+	workflows, err := env.workflows.ListWorkflows(env.ctx, env.owner)
+	if err != nil {
+		t.Fatalf("ListWorkflows: %v", err)
+	}
+	if len(workflows) != 2 {
+		t.Fatalf("got %d workflows, want 2", len(workflows))
+	}
+	if workflows[0].ID != second.ID || workflows[1].ID != first.ID {
+		t.Errorf("workflows are not ordered newest first")
+	}
+
+	if _, err := env.workflows.ListWorkflows(env.ctx, "alice"); err == nil {
+		t.Error("expected an error for an invalid owner ID")
+	}
+}
+
+func TestUpdateWorkflow(t *testing.T) {
+	env := newTestEnv(t)
+	form := env.createForm(t)
+
+	t.Run("draft", func(t *testing.T) {
+		created := env.createWorkflow(t, nil)
+		actions := []models.Action{{ID: "a1", Type: models.ActionTypeNotification}}
+
+		updated, err := env.workflows.UpdateWorkflow(env.ctx, created.ID, UpdateWorkflowRequest{
+			SchemaID: &form.ID,
+			Actions:  actions,
+		})
+		if err != nil {
+			t.Fatalf("UpdateWorkflow: %v", err)
+		}
+		if updated.Name != created.Name {
+			t.Errorf("name = %q, want unchanged %q", updated.Name, created.Name)
+		}
+		if updated.SchemaID != form.ID {
+			t.Errorf("schema ID = %s, want %s", updated.SchemaID, form.ID)
+		}
+		if len(updated.Actions) != 1 || updated.Actions[0].ID != "a1" {
+			t.Errorf("actions = %+v, want %+v", updated.Actions, actions)
+		}
+		if !updated.UpdatedAt.After(created.UpdatedAt) {
+			t.Errorf("updated_at was not bumped")
+		}
+	})
+
+	t.Run("not draft", func(t *testing.T) {
+		active := env.activeWorkflow(t, nil)
+		_, err := env.workflows.UpdateWorkflow(env.ctx, active.ID, UpdateWorkflowRequest{Name: ptr("Renamed")})
+		assertIs(t, err, ErrWorkflowNotDraft)
+	})
+
+	t.Run("schema not a UUID", func(t *testing.T) {
+		created := env.createWorkflow(t, nil)
+		_, err := env.workflows.UpdateWorkflow(env.ctx, created.ID, UpdateWorkflowRequest{SchemaID: ptr("contact")})
+		assertError(t, err, KindValidation, "validation_failed")
+	})
+
+	t.Run("another owner's form", func(t *testing.T) {
+		created := env.createWorkflow(t, nil)
+		other := env.otherOwnersForm(t)
+		_, err := env.workflows.UpdateWorkflow(env.ctx, created.ID, UpdateWorkflowRequest{SchemaID: &other.ID})
+		assertError(t, err, KindNotFound, "form_not_found")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := env.workflows.UpdateWorkflow(env.ctx, uuid.NewString(), UpdateWorkflowRequest{})
+		assertError(t, err, KindNotFound, "workflow_not_found")
+	})
+}
+
+func TestUpdateWorkflowStatusTransitions(t *testing.T) {
+	statuses := []models.WorkflowStatus{
+		models.WorkflowStatusDraft,
+		models.WorkflowStatusActive,
+		models.WorkflowStatusPaused,
+		models.WorkflowStatusStopped,
+		models.WorkflowStatusArchived,
+	}
+	allowed := map[[2]models.WorkflowStatus]bool{
+		{models.WorkflowStatusDraft, models.WorkflowStatusActive}:     true,
+		{models.WorkflowStatusDraft, models.WorkflowStatusArchived}:   true,
+		{models.WorkflowStatusActive, models.WorkflowStatusPaused}:    true,
+		{models.WorkflowStatusActive, models.WorkflowStatusStopped}:   true,
+		{models.WorkflowStatusActive, models.WorkflowStatusArchived}:  true,
+		{models.WorkflowStatusPaused, models.WorkflowStatusActive}:    true,
+		{models.WorkflowStatusPaused, models.WorkflowStatusStopped}:   true,
+		{models.WorkflowStatusPaused, models.WorkflowStatusArchived}:  true,
+		{models.WorkflowStatusStopped, models.WorkflowStatusArchived}: true,
+	}
+
+	env := newTestEnv(t)
+	for _, from := range statuses {
+		for _, to := range statuses {
+			t.Run(string(from)+" to "+string(to), func(t *testing.T) {
+				workflow := env.createWorkflow(t, nil)
+				setWorkflowStatus(t, env, workflow.ID, from)
+
+				updated, err := env.workflows.UpdateWorkflowStatus(env.ctx, workflow.ID, to, env.owner)
+				if !allowed[[2]models.WorkflowStatus{from, to}] {
+					assertIs(t, err, ErrInvalidStatusTransition)
+					got, _ := env.workflows.GetWorkflow(env.ctx, workflow.ID)
+					if got.Status != from {
+						t.Errorf("status = %s after a rejected transition, want %s", got.Status, from)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("UpdateWorkflowStatus: %v", err)
+				}
+				if updated.Status != to {
+					t.Errorf("status = %s, want %s", updated.Status, to)
+				}
+
+				history, err := env.workflows.ListStatusTransitions(env.ctx, workflow.ID)
+				if err != nil {
+					t.Fatalf("ListStatusTransitions: %v", err)
+				}
+				if len(history) != 1 || history[0].From != from || history[0].To != to || history[0].ChangedBy != env.owner {
+					t.Errorf("history = %+v, want one %s to %s by %s", history, from, to, env.owner)
+				}
+			})
+		}
+	}
+}
+
+func TestUpdateWorkflowStatusErrors(t *testing.T) {
+	env := newTestEnv(t)
+	workflow := env.createWorkflow(t, nil)
+
+	_, err := env.workflows.UpdateWorkflowStatus(env.ctx, workflow.ID, "deleted", env.owner)
+	assertError(t, err, KindValidation, "invalid_status")
+
+	_, err = env.workflows.UpdateWorkflowStatus(env.ctx, uuid.NewString(), models.WorkflowStatusActive, env.owner)
+	assertError(t, err, KindNotFound, "workflow_not_found")
+
+	if _, err := env.workflows.UpdateWorkflowStatus(env.ctx, workflow.ID, models.WorkflowStatusActive, "alice"); err == nil {
+		t.Error("expected an error for an invalid user ID")
+	}
+}
+
+// racingQuerier changes a workflow's status between the service reading it and
+// writing the transition, like a concurrent request would.
+type racingQuerier struct {
+	*memdb.Queries
+	status string
+}
+
+func (q *racingQuerier) TransitionWorkflowStatus(ctx context.Context, arg *db.TransitionWorkflowStatusParams) (*db.Workflow, error) {
+	if _, err := q.Queries.UpdateWorkflowStatus(ctx, &db.UpdateWorkflowStatusParams{ID: arg.ID, Status: q.status}); err != nil {
+		return nil, err
+	}
+	return q.Queries.TransitionWorkflowStatus(ctx, arg)
+}
+
+func (q *racingQuerier) ExecTx(ctx context.Context, opts db.TxOptions, fn func(db.Querier) error) error {
+	return q.Queries.ExecTx(ctx, opts, func(db.Querier) error {
+		return fn(q)
+	})
+}
+
+func TestUpdateWorkflowStatusConcurrentChange(t *testing.T) {
+	env := newTestEnv(t)
+	workflow := env.activeWorkflow(t, nil)
+
+	workflows := NewWorkflowService(&racingQuerier{Queries: env.queries, status: string(models.WorkflowStatusStopped)})
+	_, err := workflows.UpdateWorkflowStatus(env.ctx, workflow.ID, models.WorkflowStatusPaused, env.owner)
+	assertIs(t, err, ErrInvalidStatusTransition)
+
+	history, err := env.workflows.ListStatusTransitions(env.ctx, workflow.ID)
+	if err != nil {
+		t.Fatalf("ListStatusTransitions: %v", err)
+	}
+	if len(history) != 1 {
+		t.Errorf("got %d transitions, want only the activation", len(history))
+	}
+}
+
+func TestListStatusTransitions(t *testing.T) {
+	env := newTestEnv(t)
+	workflow := env.activeWorkflow(t, nil)
+	for _, status := range []models.WorkflowStatus{models.WorkflowStatusPaused, models.WorkflowStatusArchived} {
+		if _, err := env.workflows.UpdateWorkflowStatus(env.ctx, workflow.ID, status, env.owner); err != nil {
+			t.Fatalf("UpdateWorkflowStatus: %v", err)
+		}
+	}
+
+	history, err := env.workflows.ListStatusTransitions(env.ctx, workflow.ID)
+	if err != nil {
+		t.Fatalf("ListStatusTransitions: %v", err)
+	}
+
+	want := []models.WorkflowStatus{models.WorkflowStatusArchived, models.WorkflowStatusPaused, models.WorkflowStatusActive}
+	if len(history) != len(want) {
+		t.Fatalf("got %d transitions, want %d", len(history), len(want))
+	}
+	for i, transition := range history {
+		if transition.To != want[i] || transition.WorkflowID != workflow.ID {
+			t.Errorf("transition %d = %+v, want to %s", i, transition, want[i])
+		}
+	}
+
+	_, err = env.workflows.ListStatusTransitions(env.ctx, "not-a-uuid")
+	assertError(t, err, KindNotFound, "workflow_not_found")
+}
+
+func TestDeleteWorkflow(t *testing.T) {
+	env := newTestEnv(t)
+
+	t.Run("without submissions", func(t *testing.T) {
+		workflow := env.activeWorkflow(t, nil)
+		if err := env.workflows.DeleteWorkflow(env.ctx, workflow.ID); err != nil {
+			t.Fatalf("DeleteWorkflow: %v", err)
+		}
+
+		_, err := env.workflows.GetWorkflow(env.ctx, workflow.ID)
+		assertError(t, err, KindNotFound, "workflow_not_found")
+
+		history, err := env.workflows.ListStatusTransitions(env.ctx, workflow.ID)
+		if err != nil || len(history) != 0 {
+			t.Errorf("status history survived the delete: %v, %v", history, err)
+		}
+	})
+
+	t.Run("with submissions", func(t *testing.T) {
+		workflow := env.activeWorkflow(t, nil)
+		env.submit(t, workflow.ID, map[string]interface{}{"name": "Ada"})
+
+		err := env.workflows.DeleteWorkflow(env.ctx, workflow.ID)
+		assertIs(t, err, ErrWorkflowHasSubmissions)
+	})
+
+	t.Run("invalid ID", func(t *testing.T) {
+		err := env.workflows.DeleteWorkflow(env.ctx, "not-a-uuid")
+		assertError(t, err, KindNotFound, "workflow_not_found")
+	})
+}
+
+func TestWorkflowSubmissionSummary(t *testing.T) {
+	env := newTestEnv(t)
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := env.setClock(start)
+	workflow := env.activeWorkflow(t, nil)
+
+	// Three sessions view the form, two start it and one submits 90 seconds after starting
+	sessions := []string{uuid.NewString(), uuid.NewString(), uuid.NewString()}
+	for _, session := range sessions {
+		recordEvent(t, env, workflow.ID, models.FormEventTypeView, session)
+	}
+	recordEvent(t, env, workflow.ID, models.FormEventTypeStart, sessions[0])
+	recordEvent(t, env, workflow.ID, models.FormEventTypeStart, sessions[1])
+	// Repeated events for a session are counted once
+	recordEvent(t, env, workflow.ID, models.FormEventTypeView, sessions[0])
+
+	clock.Advance(90 * time.Second)
+	submission, err := env.submissions.CreateSubmission(env.ctx, CreateSubmissionRequest{
+		WorkflowID: workflow.ID,
+		Data:       map[string]interface{}{"name": "Ada"},
+		Metadata:   &models.SubmissionMetadata{SessionID: sessions[0]},
+	})
+	if err != nil {
+		t.Fatalf("CreateSubmission: %v", err)
+	}
+
+	got, err := env.workflows.GetWorkflow(env.ctx, workflow.ID)
+	if err != nil {
+		t.Fatalf("GetWorkflow: %v", err)
+	}
+
+	summary := got.SubmissionSummary
+	if summary.TotalVisits != 3 || summary.TotalSubmissions != 1 {
+		t.Errorf("visits = %d, submissions = %d, want 3 and 1", summary.TotalVisits, summary.TotalSubmissions)
+	}
+	if summary.CompletionRate != 33.33 {
+		t.Errorf("completion rate = %v, want 33.33", summary.CompletionRate)
+	}
+	if summary.AverageTimeToComplete != 90 {
+		t.Errorf("average time to complete = %d, want 90", summary.AverageTimeToComplete)
+	}
+	if summary.LastSubmissionAt == nil || !summary.LastSubmissionAt.Equal(submission.CreatedAt) {
+		t.Errorf("last submission at = %v, want %v", summary.LastSubmissionAt, submission.CreatedAt)
+	}
+
+	listed, err := env.workflows.ListWorkflows(env.ctx, env.owner)
+	if err != nil {
+		t.Fatalf("ListWorkflows: %v", err)
+	}
+	if len(listed) != 1 || listed[0].SubmissionSummary.TotalVisits != 3 || listed[0].SubmissionSummary.TotalSubmissions != 1 {
+		t.Errorf("listed summary = %+v, want the same as GetWorkflow", listed[0].SubmissionSummary)
+	}
+}
+
+func TestRecordFormEvent(t *testing.T) {
+	env := newTestEnv(t)
+	active := env.activeWorkflow(t, nil)
+	draft := env.createWorkflow(t, nil)
+
+	tests := []struct {
+		name       string
+		workflowID string
+		req        FormEventRequest
+		kind       ErrorKind
+		code       string
+	}{
+		{"view", active.ID, FormEventRequest{Type: models.FormEventTypeView, SessionID: uuid.NewString()}, "", ""},
+		{"start", active.ID, FormEventRequest{Type: models.FormEventTypeStart, SessionID: uuid.NewString()}, "", ""},
+		{"unknown type", active.ID, FormEventRequest{Type: "submit", SessionID: uuid.NewString()}, KindValidation, "validation_failed"},
+		{"session not a UUID", active.ID, FormEventRequest{Type: models.FormEventTypeView, SessionID: "abc"}, KindValidation, "validation_failed"},
+		{"inactive workflow", draft.ID, FormEventRequest{Type: models.FormEventTypeView, SessionID: uuid.NewString()}, KindConflict, "workflow_not_active"},
+		{"unknown workflow", uuid.NewString(), FormEventRequest{Type: models.FormEventTypeView, SessionID: uuid.NewString()}, KindNotFound, "workflow_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := env.analytics.RecordFormEvent(env.ctx, tt.workflowID, tt.req)
+			if tt.kind == "" {
+				if err != nil {
+					t.Fatalf("RecordFormEvent: %v", err)
+				}
+				return
+			}
+			assertError(t, err, tt.kind, tt.code)
+		})
+	}
+}
+
+func TestSummaryToModel(t *testing.T) {
+	last := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	summary := summaryToModel(3, 2, 5, 61.6, pgtype.Timestamptz{Time: last, Valid: true})
+	if summary.CompletionRate != 66.67 {
+		t.Errorf("completion rate = %v, want 66.67", summary.CompletionRate)
+	}
+	if summary.AverageTimeToComplete != 62 {
+		t.Errorf("average time = %d, want 62", summary.AverageTimeToComplete)
+	}
+	if summary.LastSubmissionAt == nil || !summary.LastSubmissionAt.Equal(last) {
+		t.Errorf("last submission at = %v, want %v", summary.LastSubmissionAt, last)
+	}
+
+	empty := summaryToModel(0, 0, 0, 0, pgtype.Timestamptz{})
+	if empty.CompletionRate != 0 || empty.LastSubmissionAt != nil {
+		t.Errorf("empty summary = %+v, want zero values", empty)
+	}
+}
+
+// setWorkflowStatus forces a workflow into a status without going through the transition rules.
+func setWorkflowStatus(t *testing.T, env *testEnv, id string, status models.WorkflowStatus) {
+	t.Helper()
+
+	_, err := env.queries.UpdateWorkflowStatus(env.ctx, &db.UpdateWorkflowStatusParams{
+		ID:     pgtype.UUID{Bytes: uuid.MustParse(id), Valid: true},
+		Status: string(status),
+	})
+	if err != nil {
+		t.Fatalf("UpdateWorkflowStatus: %v", err)
+	}
+}
+
+func recordEvent(t *testing.T, env *testEnv, workflowID string, eventType models.FormEventType, sessionID string) {
+	t.Helper()
+
+	err := env.analytics.RecordFormEvent(env.ctx, workflowID, FormEventRequest{Type: eventType, SessionID: sessionID})
+	if err != nil {
+		t.Fatalf("RecordFormEvent: %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+// Package mail delivers email. Mailer is the transport of the send_email action:
+// SMTPMailer sends through an SMTP relay and Capture keeps messages in memory for
+// tests.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is an email to deliver. Addresses are in RFC 5322 form, such as
+// "ada@example.com" or "Ada Lovelace <ada@example.com>".
+type Message struct {
+	From    string // Defaults to the sender configured on the mailer.
+	To      []string
+	Cc      []string
+	Subject string
+	Text    string // Plain text body.
+	HTML    string // HTML body, sent as an alternative to Text when both are set.
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Capture is a Mailer that keeps the messages it is given instead of sending them.
+// The zero value is ready to use.
+type Capture struct {
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+var _ Mailer = (*Capture)(nil)
+
+// Send records a copy of msg, or returns the error set with Fail.
+func (c *Capture) Send(ctx context.Context, msg *Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+	m := *msg
+	m.To = append([]string(nil), msg.To...)
+	m.Cc = append([]string(nil), msg.Cc...)
+	c.messages = append(c.messages, m)
+	return nil
+}
+
+// Fail makes every later Send return err, or succeed again when err is nil.
+func (c *Capture) Fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (c *Capture) Messages() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message(nil), c.messages...)
+}
+
+// Reset forgets the messages sent so far.
+func (c *Capture) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = nil
+}
+
+// ParseAddresses parses a comma-separated list of RFC 5322 addresses. Blank input is
+// an empty list.
+func ParseAddresses(list string) ([]*mail.Address, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	return mail.ParseAddressList(list)
+}
+
+// build encodes msg as an RFC 5322 message sent from the given address.
+func build(msg *Message, from *mail.Address, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+
+	to, err := formatAddresses(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to address: %w", err)
+	}
+	cc, err := formatAddresses(msg.Cc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cc address: %w", err)
+	}
+
+	header("From", from.String())
+	header("To", to)
+	if cc != "" {
+		header("Cc", cc)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID(from))
+	header("MIME-Version", "1.0")
+
+	if msg.HTML == "" || msg.Text == "" {
+		contentType, body := "text/plain", msg.Text
+		if msg.HTML != "" {
+			contentType, body = "text/html", msg.HTML
+		}
+		header("Content-Type", contentType+"; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w interface{ Write([]byte) (int, error) }, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// formatAddresses parses the addresses and joins them for a header.
+func formatAddresses(addresses []string) (string, error) {
+	formatted := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return "", fmt.Errorf("%q: %w", address, err)
+		}
+		formatted = append(formatted, parsed.String())
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+// messageID returns a unique Message-ID in the domain of the sender.
+func messageID(from *mail.Address) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from.Address, "@"); at >= 0 {
+		domain = from.Address[at+1:]
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
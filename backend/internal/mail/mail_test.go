@@ -0,0 +1,300 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession is what a fakeSMTP server received in one session.
+type smtpSession struct {
+	from       string
+	recipients []string
+	data       string
+}
+
+// fakeSMTP serves the plain SMTP commands a client needs to deliver one message
+// per connection, and reports every session on the returned channel.
+func fakeSMTP(t *testing.T) (host string, port int, sessions <-chan smtpSession) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan smtpSession, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, ch)
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, ch
+}
+
+func serveSMTP(conn net.Conn, sessions chan<- smtpSession) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	var session smtpSession
+
+	text.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			text.PrintfLine("250 localhost")
+		case "MAIL":
+			session.from = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			session.recipients = append(session.recipients, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 Go ahead")
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			session.data = string(data)
+			text.PrintfLine("250 OK")
+			sessions <- session
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	host, port, sessions := fakeSMTP(t)
+	mailer := NewSMTPMailer(SMTPConfig{Host: host, Port: port, From: "Rootd <noreply@rootd.example>"})
+
+	err := mailer.Send(context.Background(), &Message{
+		To:      []string{"Ada Lovelace <ada@example.com>"},
+		Cc:      []string{"ops@example.com"},
+		Subject: "Thanks, Ada — we got it",
+		Text:    "Hello Ada",
+		HTML:    "<p>Hello <b>Ada</b></p>",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var session smtpSession
+	select {
+	case session = <-sessions:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server received no message")
+	}
+
+	if session.from != "noreply@rootd.example" {
+		t.Errorf("envelope from = %q, want the configured sender", session.from)
+	}
+	if strings.Join(session.recipients, ",") != "ada@example.com,ops@example.com" {
+		t.Errorf("envelope recipients = %v, want the to and cc addresses", session.recipients)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(session.data))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "Thanks, Ada — we got it" {
+		t.Errorf("subject = %q", subject)
+	}
+	if to := msg.Header.Get("To"); to != `"Ada Lovelace" <ada@example.com>` {
+		t.Errorf("to header = %q", to)
+	}
+	if msg.Header.Get("Message-ID") == "" || msg.Header.Get("Date") == "" {
+		t.Errorf("header = %v, want a message ID and date", msg.Header)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type = %q, %v, want multipart/alternative", mediaType, err)
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for _, want := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", "Hello Ada"},
+		{"text/html; charset=utf-8", "<p>Hello <b>Ada</b></p>"},
+	} {
+		part, err := parts.NextPart()
+		if err != nil {
+			t.Fatalf("NextPart: %v", err)
+		}
+		body, _ := io.ReadAll(part) // NextPart decodes quoted-printable
+		if part.Header.Get("Content-Type") != want.contentType || string(body) != want.body {
+			t.Errorf("part = %s %q, want %s %q", part.Header.Get("Content-Type"), body, want.contentType, want.body)
+		}
+	}
+}
+
+func TestSMTPMailerRejectsInvalidMessages(t *testing.T) {
+	mailer := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: 1, From: "noreply@rootd.example"})
+
+	tests := []struct {
+		name string
+		msg  *Message
+		want string
+	}{
+		{"no recipients", &Message{Text: "hi"}, "message has no recipients"},
+		{"invalid recipient", &Message{To: []string{"not an address"}, Text: "hi"}, "invalid recipient"},
+		{"invalid sender", &Message{From: "nobody", To: []string{"ada@example.com"}}, "invalid from address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := mailer.Send(context.Background(), tt.msg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Send = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSMTPMailerTimeout(t *testing.T) {
+	// A server that accepts connections and never greets
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			bufio.NewReader(conn).ReadString('\n')
+		}
+	}()
+
+	port := ln.Addr().(*net.TCPAddr).Port
+	mailer := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: port, From: "noreply@rootd.example", Timeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	err = mailer.Send(context.Background(), &Message{To: []string{"ada@example.com"}, Text: "hi"})
+	if err == nil {
+		t.Fatal("Send to a silent server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send returned after %s, want it bounded by the timeout", elapsed)
+	}
+}
+
+func TestBuildSinglePart(t *testing.T) {
+	from := &mail.Address{Address: "noreply@rootd.example"}
+
+	tests := []struct {
+		name        string
+		msg         *Message
+		contentType string
+		body        string
+	}{
+		{"text only", &Message{To: []string{"ada@example.com"}, Text: "line one\nline two"}, "text/plain; charset=utf-8", "line one\r\nline two"}, // Lines end in CRLF on the wire
+		{"html only", &Message{To: []string{"ada@example.com"}, HTML: "<p>hi</p>"}, "text/html; charset=utf-8", "<p>hi</p>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := build(tt.msg, from, time.Now())
+			if err != nil {
+				t.Fatalf("build: %v", err)
+			}
+			msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+			if err != nil {
+				t.Fatalf("ReadMessage: %v", err)
+			}
+			if got := msg.Header.Get("Content-Type"); got != tt.contentType {
+				t.Errorf("content type = %q, want %q", got, tt.contentType)
+			}
+			if msg.Header.Get("Cc") != "" {
+				t.Errorf("cc header = %q, want none", msg.Header.Get("Cc"))
+			}
+			body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+			if string(body) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestCapture(t *testing.T) {
+	var capture Capture
+	ctx := context.Background()
+
+	msg := &Message{To: []string{"ada@example.com"}, Subject: "hi"}
+	if err := capture.Send(ctx, msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	msg.To[0] = "changed@example.com"
+
+	failure := errors.New("relay down")
+	capture.Fail(failure)
+	if err := capture.Send(ctx, msg); !errors.Is(err, failure) {
+		t.Errorf("Send while failing = %v, want %v", err, failure)
+	}
+
+	messages := capture.Messages()
+	if len(messages) != 1 || messages[0].To[0] != "ada@example.com" {
+		t.Errorf("messages = %+v, want the first message as it was sent", messages)
+	}
+
+	capture.Reset()
+	if n := len(capture.Messages()); n != 0 {
+		t.Errorf("%d messages after Reset", n)
+	}
+}
+
+func TestParseAddresses(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"  ", nil, false},
+		{"ada@example.com", []string{"ada@example.com"}, false},
+		{"Ada <ada@example.com>, grace@example.com", []string{"ada@example.com", "grace@example.com"}, false},
+		{"ada", nil, true},
+	}
+
+	for _, tt := range tests {
+		addresses, err := ParseAddresses(tt.list)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAddresses(%q) error = %v, want error %v", tt.list, err, tt.wantErr)
+			continue
+		}
+		var got []string
+		for _, a := range addresses {
+			got = append(got, a.Address)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("ParseAddresses(%q) = %v, want %v", tt.list, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPConfig configures an SMTPMailer.
+type SMTPConfig struct {
+	Host     string        // The relay's host name. Sending is disabled when empty.
+	Port     int           // Defaults to 587.
+	Username string        // Authenticates with PLAIN when set, which requires TLS unless the host is local.
+	Password string        // Used with Username.
+	From     string        // Sender of messages that do not set one.
+	Timeout  time.Duration // Bounds each delivery. Defaults to 30s.
+}
+
+// SMTPMailer sends messages through an SMTP relay, upgrading the connection with
+// STARTTLS when the relay offers it. Each message uses its own connection.
+type SMTPMailer struct {
+	config SMTPConfig
+}
+
+var _ Mailer = (*SMTPMailer)(nil)
+
+// NewSMTPMailer creates a mailer that delivers through the relay in config.
+func NewSMTPMailer(config SMTPConfig) *SMTPMailer {
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &SMTPMailer{config: config}
+}
+
+// Send delivers msg to every To and Cc recipient.
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	from := msg.From
+	if from == "" {
+		from = m.config.From
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return fmt.Errorf("invalid from address %q: %w", from, err)
+	}
+
+	var recipients []string
+	for _, address := range append(append([]string(nil), msg.To...), msg.Cc...) {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", address, err)
+		}
+		recipients = append(recipients, parsed.Address)
+	}
+	if len(recipients) == 0 {
+		return fmt.Errorf("message has no recipients")
+	}
+
+	raw, err := build(msg, sender, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.config.Timeout)
+	defer cancel()
+	return m.deliver(ctx, sender.Address, recipients, raw)
+}
+
+func (m *SMTPMailer) deliver(ctx context.Context, from string, recipients []string, raw []byte) error {
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+
+	// net/smtp does not take a context, so cancellation closes the connection under it
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.config.Host}); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if m.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("smtp rcpt to %s: %w", recipient, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+
+	return client.Quit()
+}
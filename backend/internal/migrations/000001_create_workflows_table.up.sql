@@ -1,5 +1,3 @@
--- +goose Up
--- +goose StatementBegin
 CREATE TABLE IF NOT EXISTS workflows (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     name VARCHAR(255) NOT NULL,
@@ -31,4 +29,3 @@ CREATE TRIGGER update_workflows_updated_at
     BEFORE UPDATE ON workflows 
     FOR EACH ROW 
     EXECUTE FUNCTION update_updated_at_column();
--- +goose StatementEnd
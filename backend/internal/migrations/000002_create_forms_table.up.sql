@@ -1,5 +1,3 @@
--- +goose Up
--- +goose StatementBegin
 CREATE TABLE IF NOT EXISTS forms (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     name VARCHAR(255) NOT NULL,
@@ -19,4 +17,3 @@ CREATE TRIGGER update_forms_updated_at
     BEFORE UPDATE ON forms 
     FOR EACH ROW 
     EXECUTE FUNCTION update_updated_at_column();
--- +goose StatementEnd
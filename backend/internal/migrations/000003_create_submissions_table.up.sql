@@ -1,5 +1,3 @@
--- +goose Up
--- +goose StatementBegin
 CREATE TABLE IF NOT EXISTS submissions (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     workflow_id UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
@@ -22,4 +20,3 @@ CREATE TRIGGER update_submissions_updated_at
     BEFORE UPDATE ON submissions 
     FOR EACH ROW 
     EXECUTE FUNCTION update_updated_at_column();
--- +goose StatementEnd
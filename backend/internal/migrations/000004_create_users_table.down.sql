@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
+DROP INDEX IF EXISTS idx_users_email;
+DROP TABLE IF EXISTS users;
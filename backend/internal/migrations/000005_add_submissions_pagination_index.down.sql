@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_submissions_workflow_created_at_id;
@@ -0,0 +1,2 @@
+-- Supports keyset pagination on (created_at, id) within a workflow
+CREATE INDEX IF NOT EXISTS idx_submissions_workflow_created_at_id ON submissions(workflow_id, created_at, id);
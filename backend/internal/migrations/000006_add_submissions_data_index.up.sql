@@ -0,0 +1,2 @@
+-- Supports jsonpath filters on submission data (data @@ '...')
+CREATE INDEX IF NOT EXISTS idx_submissions_data ON submissions USING GIN (data jsonb_path_ops);
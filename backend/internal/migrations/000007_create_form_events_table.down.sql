@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_submissions_session_id;
+DROP INDEX IF EXISTS idx_form_events_workflow_type;
+DROP INDEX IF EXISTS idx_form_events_workflow_session_type;
+DROP TABLE IF EXISTS form_events;
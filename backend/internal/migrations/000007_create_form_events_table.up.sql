@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS form_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    session_id UUID NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- A session counts once per event type, so reloading the form is not a new visit
+CREATE UNIQUE INDEX IF NOT EXISTS idx_form_events_workflow_session_type ON form_events(workflow_id, session_id, event_type);
+CREATE INDEX IF NOT EXISTS idx_form_events_workflow_type ON form_events(workflow_id, event_type);
+
+-- Submissions are linked to their form session through metadata.sessionId
+CREATE INDEX IF NOT EXISTS idx_submissions_session_id ON submissions((metadata->>'sessionId'));
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_workflow_status_transitions_workflow_id;
+DROP TABLE IF EXISTS workflow_status_transitions;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS workflow_webhooks (
+    workflow_id UUID PRIMARY KEY REFERENCES workflows(id) ON DELETE CASCADE,
+    token TEXT NOT NULL UNIQUE,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_workflow_runs_pending;
+DROP INDEX IF EXISTS idx_workflow_runs_workflow_id;
+DROP TABLE IF EXISTS workflow_runs;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS workflow_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    submission_id UUID REFERENCES submissions(id) ON DELETE SET NULL,
+    input JSONB,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    error TEXT,
+    triggered_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_workflow_id ON workflow_runs(workflow_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_pending ON workflow_runs(created_at) WHERE status = 'pending';
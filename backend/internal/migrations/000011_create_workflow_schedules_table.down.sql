@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_workflow_schedules_next_fire_at;
+DROP TABLE IF EXISTS workflow_schedules;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS workflow_schedules (
+    workflow_id UUID PRIMARY KEY REFERENCES workflows(id) ON DELETE CASCADE,
+    cron_expression TEXT NOT NULL,
+    time_zone TEXT NOT NULL,
+    next_fire_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_fire_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_schedules_next_fire_at ON workflow_schedules(next_fire_at);
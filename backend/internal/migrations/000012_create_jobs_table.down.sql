@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS update_jobs_updated_at ON jobs;
+DROP INDEX IF EXISTS idx_jobs_running;
+DROP INDEX IF EXISTS idx_jobs_queued;
+DROP TABLE IF EXISTS jobs;
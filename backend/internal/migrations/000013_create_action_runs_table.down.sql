@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_action_runs_finished_at;
+DROP INDEX IF EXISTS idx_action_runs_submission_id;
+DROP TABLE IF EXISTS action_runs;
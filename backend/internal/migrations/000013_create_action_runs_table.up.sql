@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS action_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    submission_id UUID NOT NULL REFERENCES submissions(id) ON DELETE CASCADE,
+    workflow_run_id UUID REFERENCES workflow_runs(id) ON DELETE CASCADE,
+    action_id VARCHAR(255) NOT NULL,
+    action_type VARCHAR(50) NOT NULL,
+    attempt INTEGER NOT NULL DEFAULT 1,
+    step INTEGER NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    config JSONB,
+    output JSONB,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_action_runs_submission_id ON action_runs(submission_id, started_at);
+CREATE INDEX IF NOT EXISTS idx_action_runs_finished_at ON action_runs(finished_at);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS workflow_signing_secrets (
+    workflow_id UUID PRIMARY KEY REFERENCES workflows(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+DELETE FROM jobs WHERE kind = 'run_workflow';
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_pending ON workflow_runs(created_at) WHERE status = 'pending';
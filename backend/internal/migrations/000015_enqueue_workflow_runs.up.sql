@@ -0,0 +1,13 @@
+-- Workflow runs are carried out by the job queue instead of being polled
+DROP INDEX IF EXISTS idx_workflow_runs_pending;
+
+-- Runs left running by an engine that stopped before finishing them start over
+UPDATE workflow_runs
+SET status = 'pending', started_at = NULL
+WHERE status = 'running';
+
+-- Runs still waiting for the engine are handed to the queue
+INSERT INTO jobs (kind, payload)
+SELECT 'run_workflow', jsonb_build_object('runId', id)
+FROM workflow_runs
+WHERE status = 'pending';
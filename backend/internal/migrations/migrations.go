@@ -0,0 +1,13 @@
+// Package migrations holds the SQL schema migrations, embedded so that binaries
+// can apply them regardless of their working directory.
+//
+// Files follow the golang-migrate naming scheme, NNNNNN_name.up.sql and
+// NNNNNN_name.down.sql. Each file is sent to Postgres as a single script.
+package migrations
+
+import "embed"
+
+// FS contains every migration file.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -51,10 +51,13 @@ type Option struct {
 }
 
 // Conditional defines a rule for when a field should be displayed.
+// It either compares one field with a value or, when Expression is set, evaluates
+// an expression such as `{age} >= 18 && {country} == "KE"`.
 type Conditional struct {
-	FieldID  string `json:"fieldId"`  // The UUID of the field to check.
-	Operator string `json:"operator"` // e.g., "==", "!=", "includes", ">=", "<="
-	Value    any    `json:"value"`    // The value to compare against.
+	FieldID    string `json:"fieldId"`              // The UUID of the field to check.
+	Operator   string `json:"operator"`             // e.g., "==", "!=", "includes", ">=", "<="
+	Value      any    `json:"value"`                // The value to compare against.
+	Expression string `json:"expression,omitempty"` // A boolean expression; replaces the fields above.
 }
 
 // Validation defines custom rules to apply to a field's input.
@@ -73,6 +76,12 @@ type DataSource struct {
 	LabelField string `json:"labelField"` // The field in the response to use as the label
 }
 
+// FormSchema is the structure stored in Form.Schema.
+// Fields are listed in the order they are rendered.
+type FormSchema struct {
+	Fields []Field `json:"fields"`
+}
+
 // Form represents a form schema definition.
 type Form struct {
 	ID          string                 `json:"id"`          // UUID for the form.
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// User represents an account that owns forms and workflows.
+type User struct {
+	ID        string    `json:"id"`        // UUID for the user.
+	Email     string    `json:"email"`     // Login email, stored lowercased.
+	Name      string    `json:"name"`      // Display name.
+	CreatedAt time.Time `json:"createdAt"` // Timestamp of registration.
+	UpdatedAt time.Time `json:"updatedAt"` // Timestamp of last update.
+}
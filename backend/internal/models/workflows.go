@@ -23,6 +23,7 @@ const (
 	TriggerTypeFormSubmission TriggerType = "form_submission"
 	TriggerTypeWebhook        TriggerType = "webhook"
 	TriggerTypeManual         TriggerType = "manual"
+	TriggerTypeSchedule       TriggerType = "schedule"
 )
 
 // ActionType defines the type of operation a workflow node performs.
@@ -45,6 +46,33 @@ const (
 	SubmissionStatusFailed     SubmissionStatus = "failed"
 )
 
+// WorkflowRunStatus represents the progress of an on-demand workflow run.
+type WorkflowRunStatus string
+
+const (
+	WorkflowRunStatusPending   WorkflowRunStatus = "pending"
+	WorkflowRunStatusRunning   WorkflowRunStatus = "running"
+	WorkflowRunStatusCompleted WorkflowRunStatus = "completed"
+	WorkflowRunStatusFailed    WorkflowRunStatus = "failed"
+)
+
+// ActionRunStatus represents the outcome of one action in a submission's execution log.
+type ActionRunStatus string
+
+const (
+	ActionRunStatusCompleted ActionRunStatus = "completed"
+	ActionRunStatusFailed    ActionRunStatus = "failed"
+	ActionRunStatusSkipped   ActionRunStatus = "skipped" // The action's conditional did not match.
+)
+
+// FormEventType identifies a step of a respondent's form session used for analytics.
+type FormEventType string
+
+const (
+	FormEventTypeView  FormEventType = "view"  // The form was displayed.
+	FormEventTypeStart FormEventType = "start" // The respondent began filling in the form.
+)
+
 // Workflow represents the operational controller for a form schema.
 // It defines the trigger, manages the state, and contains the sequence of actions to be executed.
 type Workflow struct {
@@ -60,6 +88,19 @@ type Workflow struct {
 
 	// SubmissionSummary holds aggregated data about the submissions for this workflow.
 	SubmissionSummary SubmissionSummary `json:"submissionSummary"`
+
+	// Webhook holds the inbound endpoint of a workflow with a webhook trigger.
+	// It is only included when a single workflow is fetched, created or updated.
+	Webhook *WorkflowWebhook `json:"webhook,omitempty"`
+
+	// Schedule holds the fire times of a workflow with a schedule trigger.
+	// It is only included when a single workflow is fetched, created or updated.
+	Schedule *WorkflowSchedule `json:"schedule,omitempty"`
+
+	// SigningSecret is the key the workflow's call_webhook actions sign their requests
+	// with. A workflow gets one with its first call_webhook action and keeps it. It is
+	// only included when a single workflow is fetched, created or updated.
+	SigningSecret string `json:"signingSecret,omitempty"`
 }
 
 // Trigger defines the event that initiates a workflow.
@@ -68,13 +109,50 @@ type Trigger struct {
 	Config json.RawMessage `json:"config,omitempty"` // Configuration specific to the trigger type (e.g., webhook URL).
 }
 
+// WebhookTriggerConfig is the Config of a webhook trigger.
+type WebhookTriggerConfig struct {
+	// FieldMapping maps form field IDs to dot-separated paths into the JSON payload,
+	// e.g. {"email": "customer.email", "item": "items.0.name"}. When it is empty the
+	// payload's top-level keys are used as field IDs.
+	FieldMapping map[string]string `json:"fieldMapping,omitempty"`
+}
+
+// WorkflowWebhook is the inbound endpoint of a workflow with a webhook trigger.
+// Requests must carry an HMAC-SHA256 signature made with Secret.
+type WorkflowWebhook struct {
+	URL    string `json:"url"`    // Path that accepts the payloads, e.g. "/hooks/{token}".
+	Secret string `json:"secret"` // Key used to sign the payloads.
+}
+
+// ScheduleTriggerConfig is the Config of a schedule trigger.
+type ScheduleTriggerConfig struct {
+	Cron     string `json:"cron"`               // Five-field cron expression, e.g. "0 9 * * MON".
+	TimeZone string `json:"timeZone,omitempty"` // IANA time zone the expression is evaluated in. Defaults to UTC.
+}
+
+// WorkflowSchedule reports when a workflow with a schedule trigger fires.
+type WorkflowSchedule struct {
+	Cron       string     `json:"cron"`                 // The cron expression.
+	TimeZone   string     `json:"timeZone"`             // The time zone of the expression.
+	NextFireAt time.Time  `json:"nextFireAt"`           // When the workflow fires next.
+	LastFireAt *time.Time `json:"lastFireAt,omitempty"` // When the workflow last fired, if it has.
+}
+
 // Action represents a single step or node within a workflow.
+//
+// Without routing, a workflow's actions run in order. Once any action sets Next or
+// Else the actions form a graph: the first action runs first, each action continues
+// with the one its Next names, and a condition action continues with Next when its
+// conditional matches and with Else when it does not. A path ends at an action with
+// nothing to continue with.
 type Action struct {
 	ID          string          `json:"id"`                    // UUID for this action step.
 	Type        ActionType      `json:"type"`                  // The type of action to perform.
 	Description string          `json:"description,omitempty"` // A user-defined description of the step.
 	Config      json.RawMessage `json:"config"`                // Configuration for the action (e.g., email template, webhook URL, conditions).
-	Conditional *Conditional    `json:"conditional,omitempty"` // Optional logic to determine if this action should run.
+	Conditional *Conditional    `json:"conditional,omitempty"` // Optional logic to determine if this action should run. Its field ID may name an earlier action's output as "{actionId}.{key}".
+	Next        string          `json:"next,omitempty"`        // ID of the action to run after this one; for a condition, when it matches.
+	Else        string          `json:"else,omitempty"`        // ID of the action to run when a condition does not match.
 }
 
 // SubmissionSummary contains aggregated analytics for a workflow.
@@ -86,6 +164,31 @@ type SubmissionSummary struct {
 	LastSubmissionAt      *time.Time `json:"lastSubmissionAt,omitempty"`      // Timestamp of the most recent submission.
 }
 
+// WorkflowStatusTransition records a single change of a workflow's status.
+type WorkflowStatusTransition struct {
+	ID         string         `json:"id"`         // UUID for the transition.
+	WorkflowID string         `json:"workflowId"` // The workflow whose status changed.
+	From       WorkflowStatus `json:"from"`       // The status before the change.
+	To         WorkflowStatus `json:"to"`         // The status after the change.
+	ChangedBy  string         `json:"changedBy"`  // The user who made the change.
+	ChangedAt  time.Time      `json:"changedAt"`  // When the change was made.
+}
+
+// WorkflowRun is an on-demand execution of a workflow's actions, started by an operator
+// either with an input payload or against an existing submission.
+type WorkflowRun struct {
+	ID           string                 `json:"id"`                     // UUID for the run.
+	WorkflowID   string                 `json:"workflowId"`             // The workflow whose actions run.
+	SubmissionID string                 `json:"submissionId,omitempty"` // The submission the actions run against, if any.
+	Input        map[string]interface{} `json:"input,omitempty"`        // The payload the actions run against when there is no submission.
+	Status       WorkflowRunStatus      `json:"status"`                 // The progress of the run.
+	Error        string                 `json:"error,omitempty"`        // Why the run failed.
+	TriggeredBy  string                 `json:"triggeredBy"`            // The user who started the run.
+	CreatedAt    time.Time              `json:"createdAt"`              // When the run was requested.
+	StartedAt    *time.Time             `json:"startedAt,omitempty"`    // When the engine picked the run up.
+	FinishedAt   *time.Time             `json:"finishedAt,omitempty"`   // When the run completed or failed.
+}
+
 // Submission represents a single data entry for a form through an active workflow.
 type Submission struct {
 	ID         string                 `json:"id"`         // UUID for the submission.
@@ -96,6 +199,37 @@ type Submission struct {
 	Data       map[string]interface{} `json:"data"`       // The submitted form data.
 	Metadata   SubmissionMetadata     `json:"metadata"`   // Additional metadata about the submission context.
 	Status     SubmissionStatus       `json:"status"`     // Processing status of the submission.
+
+	Runs *ActionRunSummary `json:"runs,omitempty"` // Summary of the execution log, on the submission detail only.
+}
+
+// ActionRun is an entry of a submission's execution log: one action executed once,
+// while processing the submission or in an on-demand run against it.
+type ActionRun struct {
+	ID            string                 `json:"id"`                      // UUID for the entry.
+	SubmissionID  string                 `json:"submissionId"`            // The submission the action ran against.
+	WorkflowRunID string                 `json:"workflowRunId,omitempty"` // The on-demand run the action was part of, if any.
+	ActionID      string                 `json:"actionId"`                // The action that ran.
+	ActionType    ActionType             `json:"actionType"`              // The type of the action.
+	Attempt       int                    `json:"attempt"`                 // The processing attempt, starting at 1.
+	Step          int                    `json:"step"`                    // The position of the action in the attempt or run, starting at 1.
+	Status        ActionRunStatus        `json:"status"`                  // The outcome of the action.
+	Config        json.RawMessage        `json:"config,omitempty"`        // The action's configuration as it ran.
+	Output        map[string]interface{} `json:"output,omitempty"`        // The values the action produced.
+	Error         string                 `json:"error,omitempty"`         // Why the action failed.
+	StartedAt     time.Time              `json:"startedAt"`               // When the action started.
+	FinishedAt    time.Time              `json:"finishedAt"`              // When the action completed, failed or was skipped.
+}
+
+// ActionRunSummary condenses a submission's execution log. The counts are of the
+// latest execution: the last processing attempt or on-demand run.
+type ActionRunSummary struct {
+	Executions int        `json:"executions"`          // Processing attempts and on-demand runs logged.
+	Completed  int        `json:"completed"`           // Actions of the latest execution that completed.
+	Failed     int        `json:"failed"`              // Actions of the latest execution that failed.
+	Skipped    int        `json:"skipped"`             // Actions of the latest execution that were skipped.
+	LastError  string     `json:"lastError,omitempty"` // The error of the latest execution, if it failed.
+	LastRunAt  *time.Time `json:"lastRunAt,omitempty"` // When the latest execution finished its last action.
 }
 
 // SubmissionMetadata contains contextual information about a submission.
@@ -103,4 +237,5 @@ type SubmissionMetadata struct {
 	IPAddress string `json:"ipAddress,omitempty"`
 	UserAgent string `json:"userAgent,omitempty"`
 	Referrer  string `json:"referrer,omitempty"`
+	SessionID string `json:"sessionId,omitempty"` // Links the submission to the form session's view and start events.
 }
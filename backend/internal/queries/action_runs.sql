@@ -0,0 +1,16 @@
+-- name: CreateActionRun :one
+INSERT INTO action_runs (
+    submission_id, workflow_run_id, action_id, action_type, attempt, step, status, config, output, error, started_at, finished_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING *;
+
+-- name: ListActionRunsBySubmission :many
+SELECT * FROM action_runs 
+WHERE submission_id = $1 
+ORDER BY started_at, step;
+
+-- name: DeleteActionRunsFinishedBefore :execrows
+-- Removes the log entries that finished before the retention cutoff.
+DELETE FROM action_runs 
+WHERE finished_at < $1;
@@ -0,0 +1,6 @@
+-- name: CreateFormEvent :exec
+INSERT INTO form_events (
+    workflow_id, session_id, event_type
+) VALUES (
+    $1, $2, $3
+) ON CONFLICT (workflow_id, session_id, event_type) DO NOTHING;
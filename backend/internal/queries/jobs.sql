@@ -0,0 +1,63 @@
+-- name: EnqueueJob :one
+INSERT INTO jobs (
+    kind, payload
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: GetJob :one
+SELECT * FROM jobs 
+WHERE id = $1;
+
+-- name: ListJobsByStatus :many
+SELECT * FROM jobs 
+WHERE status = $1 
+ORDER BY created_at, id;
+
+-- name: ClaimJob :one
+-- Leases the next runnable job: a queued job that is due, or a running job whose
+-- lease expired because its worker died. Jobs locked by another worker are skipped.
+UPDATE jobs 
+SET 
+    status = 'running',
+    attempts = attempts + 1,
+    locked_until = sqlc.arg(locked_until),
+    updated_at = NOW()
+WHERE id = (
+    SELECT id FROM jobs 
+    WHERE (status = 'queued' AND run_at <= sqlc.arg(now)) 
+       OR (status = 'running' AND locked_until <= sqlc.arg(now)) 
+    ORDER BY run_at 
+    LIMIT 1 
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteJob :execrows
+-- The attempts check makes this a no-op for a worker whose lease was taken over.
+DELETE FROM jobs 
+WHERE id = $1 AND attempts = $2;
+
+-- name: RetryJob :execrows
+UPDATE jobs 
+SET 
+    status = 'queued',
+    run_at = $3,
+    last_error = $4,
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND attempts = $2;
+
+-- name: DeadLetterJob :execrows
+UPDATE jobs 
+SET 
+    status = 'dead',
+    last_error = $3,
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND attempts = $2;
+
+-- name: CountJobsByStatus :many
+SELECT status, COUNT(*) AS count FROM jobs 
+GROUP BY status 
+ORDER BY status;
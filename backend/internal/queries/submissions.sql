@@ -31,3 +31,41 @@ RETURNING *;
 -- name: DeleteSubmission :exec
 DELETE FROM submissions 
 WHERE id = $1;
+
+-- name: ListSubmissionsPageDesc :many
+SELECT s.* FROM submissions s
+JOIN workflows w ON s.workflow_id = w.id
+WHERE (sqlc.narg('workflow_id')::uuid IS NULL OR s.workflow_id = sqlc.narg('workflow_id'))
+  AND (sqlc.narg('owner_id')::uuid IS NULL OR w.owner_id = sqlc.narg('owner_id'))
+  AND (sqlc.narg('status')::text IS NULL OR s.status = sqlc.narg('status'))
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR s.created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR s.created_at < sqlc.narg('created_to'))
+  AND (sqlc.narg('data_filter')::text IS NULL OR s.data @@ sqlc.narg('data_filter')::text::jsonpath)
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (s.created_at, s.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+ORDER BY s.created_at DESC, s.id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: ListSubmissionsPageAsc :many
+SELECT s.* FROM submissions s
+JOIN workflows w ON s.workflow_id = w.id
+WHERE (sqlc.narg('workflow_id')::uuid IS NULL OR s.workflow_id = sqlc.narg('workflow_id'))
+  AND (sqlc.narg('owner_id')::uuid IS NULL OR w.owner_id = sqlc.narg('owner_id'))
+  AND (sqlc.narg('status')::text IS NULL OR s.status = sqlc.narg('status'))
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR s.created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR s.created_at < sqlc.narg('created_to'))
+  AND (sqlc.narg('data_filter')::text IS NULL OR s.data @@ sqlc.narg('data_filter')::text::jsonpath)
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (s.created_at, s.id) > (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+ORDER BY s.created_at ASC, s.id ASC
+LIMIT sqlc.arg('page_limit');
+
+-- name: CountSubmissions :one
+SELECT COUNT(*) FROM submissions s
+JOIN workflows w ON s.workflow_id = w.id
+WHERE (sqlc.narg('workflow_id')::uuid IS NULL OR s.workflow_id = sqlc.narg('workflow_id'))
+  AND (sqlc.narg('owner_id')::uuid IS NULL OR w.owner_id = sqlc.narg('owner_id'))
+  AND (sqlc.narg('status')::text IS NULL OR s.status = sqlc.narg('status'))
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR s.created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR s.created_at < sqlc.narg('created_to'))
+  AND (sqlc.narg('data_filter')::text IS NULL OR s.data @@ sqlc.narg('data_filter')::text::jsonpath);
@@ -0,0 +1,35 @@
+-- name: CreateWorkflowRun :one
+INSERT INTO workflow_runs (
+    workflow_id, submission_id, input, triggered_by
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetWorkflowRun :one
+SELECT * FROM workflow_runs 
+WHERE id = $1;
+
+-- name: ListWorkflowRuns :many
+SELECT * FROM workflow_runs 
+WHERE workflow_id = $1 
+ORDER BY created_at DESC, id DESC;
+
+-- name: StartWorkflowRun :one
+-- Marks a run as running, clearing the outcome of an earlier attempt at it.
+UPDATE workflow_runs 
+SET 
+    status = 'running',
+    error = NULL,
+    started_at = NOW(),
+    finished_at = NULL
+WHERE id = $1 
+RETURNING *;
+
+-- name: FinishWorkflowRun :one
+UPDATE workflow_runs 
+SET 
+    status = $2,
+    error = $3,
+    finished_at = NOW()
+WHERE id = $1 
+RETURNING *;
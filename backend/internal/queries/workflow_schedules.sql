@@ -0,0 +1,37 @@
+-- name: UpsertWorkflowSchedule :one
+INSERT INTO workflow_schedules (
+    workflow_id, cron_expression, time_zone, next_fire_at
+) VALUES (
+    $1, $2, $3, $4
+) ON CONFLICT (workflow_id) DO UPDATE SET 
+    cron_expression = EXCLUDED.cron_expression,
+    time_zone = EXCLUDED.time_zone,
+    next_fire_at = EXCLUDED.next_fire_at,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetWorkflowSchedule :one
+SELECT * FROM workflow_schedules 
+WHERE workflow_id = $1;
+
+-- name: DeleteWorkflowSchedule :exec
+DELETE FROM workflow_schedules 
+WHERE workflow_id = $1;
+
+-- name: ClaimDueWorkflowSchedules :many
+-- Locks the due schedules until the transaction ends. Rows locked
+-- by another scheduler are skipped, so each fire time is claimed by one replica.
+SELECT * FROM workflow_schedules 
+WHERE next_fire_at <= sqlc.arg(now) 
+ORDER BY next_fire_at 
+LIMIT sqlc.arg(claim_limit) 
+FOR UPDATE SKIP LOCKED;
+
+-- name: RecordWorkflowScheduleFire :one
+UPDATE workflow_schedules 
+SET 
+    last_fire_at = $2,
+    next_fire_at = $3,
+    updated_at = NOW()
+WHERE workflow_id = $1 
+RETURNING *;
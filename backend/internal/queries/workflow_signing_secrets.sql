@@ -0,0 +1,10 @@
+-- name: CreateWorkflowSigningSecret :one
+INSERT INTO workflow_signing_secrets (
+    workflow_id, secret
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: GetWorkflowSigningSecret :one
+SELECT * FROM workflow_signing_secrets 
+WHERE workflow_id = $1;
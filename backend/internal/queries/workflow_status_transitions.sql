@@ -0,0 +1,4 @@
+-- name: ListWorkflowStatusTransitions :many
+SELECT * FROM workflow_status_transitions 
+WHERE workflow_id = $1 
+ORDER BY changed_at DESC, id DESC;
@@ -0,0 +1,18 @@
+-- name: CreateWorkflowWebhook :one
+INSERT INTO workflow_webhooks (
+    workflow_id, token, secret
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetWorkflowWebhook :one
+SELECT * FROM workflow_webhooks 
+WHERE workflow_id = $1;
+
+-- name: GetWorkflowWebhookByToken :one
+SELECT * FROM workflow_webhooks 
+WHERE token = $1;
+
+-- name: DeleteWorkflowWebhook :exec
+DELETE FROM workflow_webhooks 
+WHERE workflow_id = $1;
@@ -34,15 +34,88 @@ SET
 WHERE id = $1 
 RETURNING *;
 
+-- name: TransitionWorkflowStatus :one
+-- Changes the status only if it still equals expected_status and records the
+-- transition in the same statement, so both commit or neither does.
+WITH updated AS (
+    UPDATE workflows 
+    SET 
+        status = sqlc.arg(status),
+        updated_at = NOW()
+    WHERE id = sqlc.arg(id) AND status = sqlc.arg(expected_status)
+    RETURNING *
+), transition AS (
+    INSERT INTO workflow_status_transitions (
+        workflow_id, from_status, to_status, changed_by
+    )
+    SELECT updated.id, sqlc.arg(expected_status), updated.status, sqlc.arg(changed_by)
+    FROM updated
+)
+SELECT * FROM updated;
+
 -- name: DeleteWorkflow :exec
 DELETE FROM workflows 
 WHERE id = $1;
 
 -- name: GetWorkflowSubmissionSummary :one
 SELECT 
-    COUNT(DISTINCT s.id) as total_submissions,
-    COUNT(DISTINCT s.id) as total_visits, -- For now, same as submissions
-    COALESCE(AVG(EXTRACT(EPOCH FROM (s.created_at - s.created_at))), 0) as average_time_to_complete,
-    MAX(s.created_at) as last_submission_at
-FROM submissions s
-WHERE s.workflow_id = $1;
+    v.total_visits::bigint as total_visits,
+    v.completed_visits::bigint as completed_visits,
+    s.total_submissions::bigint as total_submissions,
+    COALESCE(d.average_time_to_complete, 0)::float8 as average_time_to_complete,
+    s.last_submission_at::timestamptz as last_submission_at
+FROM workflows w
+CROSS JOIN LATERAL (
+    SELECT COUNT(*) as total_submissions, MAX(created_at) as last_submission_at
+    FROM submissions WHERE workflow_id = w.id
+) s
+CROSS JOIN LATERAL (
+    SELECT 
+        COUNT(*) as total_visits,
+        COUNT(*) FILTER (WHERE EXISTS (
+            SELECT 1 FROM submissions sub 
+            WHERE sub.workflow_id = e.workflow_id AND sub.metadata->>'sessionId' = e.session_id::text
+        )) as completed_visits
+    FROM form_events e WHERE e.workflow_id = w.id AND e.event_type = 'view'
+) v
+CROSS JOIN LATERAL (
+    SELECT AVG(EXTRACT(EPOCH FROM (sub.created_at - e.created_at))) as average_time_to_complete
+    FROM form_events e
+    JOIN submissions sub ON sub.workflow_id = e.workflow_id AND sub.metadata->>'sessionId' = e.session_id::text
+    WHERE e.workflow_id = w.id AND e.event_type = 'start' AND sub.created_at >= e.created_at
+) d
+WHERE w.id = $1;
+
+-- name: ListWorkflowSubmissionSummaries :many
+SELECT 
+    w.id as workflow_id,
+    v.total_visits::bigint as total_visits,
+    v.completed_visits::bigint as completed_visits,
+    s.total_submissions::bigint as total_submissions,
+    COALESCE(d.average_time_to_complete, 0)::float8 as average_time_to_complete,
+    s.last_submission_at::timestamptz as last_submission_at
+FROM workflows w
+CROSS JOIN LATERAL (
+    SELECT COUNT(*) as total_submissions, MAX(created_at) as last_submission_at
+    FROM submissions WHERE workflow_id = w.id
+) s
+CROSS JOIN LATERAL (
+    SELECT 
+        COUNT(*) as total_visits,
+        COUNT(*) FILTER (WHERE EXISTS (
+            SELECT 1 FROM submissions sub 
+            WHERE sub.workflow_id = e.workflow_id AND sub.metadata->>'sessionId' = e.session_id::text
+        )) as completed_visits
+    FROM form_events e WHERE e.workflow_id = w.id AND e.event_type = 'view'
+) v
+CROSS JOIN LATERAL (
+    SELECT AVG(EXTRACT(EPOCH FROM (sub.created_at - e.created_at))) as average_time_to_complete
+    FROM form_events e
+    JOIN submissions sub ON sub.workflow_id = e.workflow_id AND sub.metadata->>'sessionId' = e.session_id::text
+    WHERE e.workflow_id = w.id AND e.event_type = 'start' AND sub.created_at >= e.created_at
+) d
+WHERE w.owner_id = $1;
+
+-- name: CountWorkflowsBySchema :one
+SELECT COUNT(*) FROM workflows 
+WHERE schema_id = $1;
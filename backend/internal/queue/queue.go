@@ -0,0 +1,333 @@
+// Package queue is a durable job queue stored in the jobs table.
+//
+// Jobs are added with Enqueue, normally in the same transaction as the rows they
+// are about, so a job exists exactly when the change that needs it committed. A
+// Pool of workers claims due jobs with FOR UPDATE SKIP LOCKED, which lets any
+// number of processes work the same queue. A claim is a lease: when a worker dies
+// its job is picked up again once the lease expires.
+//
+// A job whose handler fails is retried with exponential backoff and jitter. After
+// MaxAttempts attempts, or as soon as the handler returns a Permanent error, the
+// job is moved to the dead-letter state and kept for inspection. Jobs that succeed
+// are deleted.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Job statuses as stored in the jobs table
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDead    = "dead"
+)
+
+// Outcomes of an attempt, as counted in Stats
+const (
+	OutcomeCompleted = "completed"
+	OutcomeRetried   = "retried"
+	OutcomeDead      = "dead"
+)
+
+// Job is a claimed job as handed to its Handler.
+type Job struct {
+	ID      string
+	Kind    string
+	Payload json.RawMessage
+	Attempt int // 1 on the first attempt.
+}
+
+// Handler runs jobs of one kind. A job is retried when its handler returns an error,
+// so handlers must be safe to run more than once for the same job.
+type Handler func(ctx context.Context, job *Job) error
+
+// Config configures a Pool. Zero fields take their defaults.
+type Config struct {
+	Workers      int           // Jobs run at the same time. Defaults to 4.
+	PollInterval time.Duration // How long an idle worker waits before looking again. Defaults to 1s.
+	MaxAttempts  int           // Attempts before a failing job is dead-lettered. Defaults to 5.
+	BaseBackoff  time.Duration // Delay before the first retry, doubled for each one after. Defaults to 10s.
+	MaxBackoff   time.Duration // Upper bound of the retry delay. Defaults to 1h.
+	Lease        time.Duration // How long a job may run before another worker takes it over. Defaults to 5m.
+}
+
+func (c Config) withDefaults() Config {
+	if c.Workers <= 0 {
+		c.Workers = 4
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = time.Second
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = 10 * time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = time.Hour
+	}
+	if c.Lease <= 0 {
+		c.Lease = 5 * time.Minute
+	}
+	return c
+}
+
+// Enqueue adds a job of the given kind with the JSON encoding of payload and returns
+// its ID. Pass the Querier of a transaction to add the job only if it commits.
+func Enqueue(ctx context.Context, q db.Querier, kind string, payload interface{}) (string, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s job payload: %w", kind, err)
+	}
+
+	job, err := q.EnqueueJob(ctx, &db.EnqueueJobParams{
+		Kind:    kind,
+		Payload: raw,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to enqueue %s job: %w", kind, err)
+	}
+	return job.ID.String(), nil
+}
+
+// Permanent marks err as not worth retrying; the job is dead-lettered right away.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Pool runs queued jobs on a fixed number of workers.
+type Pool struct {
+	queries db.Querier
+	config  Config
+	now     func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	inFlight  atomic.Int64
+	statsMu   sync.Mutex
+	processed map[ProcessedKey]int64
+}
+
+// Stats counts the work of a pool since it was created.
+type Stats struct {
+	InFlight  int64                  // Jobs being run right now.
+	Processed map[ProcessedKey]int64 // Finished attempts.
+}
+
+// ProcessedKey groups finished attempts by job kind and outcome.
+type ProcessedKey struct {
+	Kind    string
+	Outcome string
+}
+
+// NewPool creates a pool that works the queue in queries. Register a handler for
+// every kind of job before calling Run.
+func NewPool(queries db.Querier, config Config) *Pool {
+	return &Pool{
+		queries:   queries,
+		config:    config.withDefaults(),
+		now:       time.Now,
+		handlers:  make(map[string]Handler),
+		processed: make(map[ProcessedKey]int64),
+	}
+}
+
+// Handle registers the handler for jobs of the given kind, replacing any earlier one.
+func (p *Pool) Handle(kind string, handler Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[kind] = handler
+}
+
+// Stats returns a copy of the pool's counters.
+func (p *Pool) Stats() Stats {
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+
+	stats := Stats{
+		InFlight:  p.inFlight.Load(),
+		Processed: make(map[ProcessedKey]int64, len(p.processed)),
+	}
+	for key, n := range p.processed {
+		stats.Processed[key] = n
+	}
+	return stats
+}
+
+// Run starts the workers and blocks until ctx is cancelled and the jobs in progress
+// have finished. Those jobs are not cancelled with ctx; they are bounded by the lease.
+func (p *Pool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.worker(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (p *Pool) worker(ctx context.Context) {
+	for ctx.Err() == nil {
+		claimed, err := p.Work(ctx)
+		if err != nil {
+			log.Printf("queue: %v", err)
+		}
+		// Look for the next job straight away while there is work
+		if claimed && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(p.config.PollInterval):
+		}
+	}
+}
+
+// Work claims one due job and runs it. It reports whether a job was claimed. The
+// error is about the queue itself; failures of the job are recorded on the job.
+func (p *Pool) Work(ctx context.Context) (bool, error) {
+	now := p.now()
+	job, err := p.queries.ClaimJob(ctx, &db.ClaimJobParams{
+		LockedUntil: pgtype.Timestamptz{Time: now.Add(p.config.Lease), Valid: true},
+		Now:         now,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	// A claimed job runs to completion, or to the end of its lease, even during shutdown
+	ctx = context.WithoutCancel(ctx)
+	p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+
+	var jobErr error
+	if int(job.Attempts) > p.config.MaxAttempts {
+		// Every earlier attempt lost its lease, most likely because the job kills its worker
+		jobErr = Permanent(fmt.Errorf("lease expired on the last of %d attempts", p.config.MaxAttempts))
+	} else {
+		runCtx, cancel := context.WithTimeout(ctx, p.config.Lease)
+		jobErr = p.run(runCtx, job)
+		cancel()
+	}
+
+	return true, p.finish(ctx, job, jobErr)
+}
+
+// run calls the handler registered for the job's kind.
+func (p *Pool) run(ctx context.Context, job *db.Job) (err error) {
+	p.mu.RLock()
+	handler, ok := p.handlers[job.Kind]
+	p.mu.RUnlock()
+	if !ok {
+		// Another version of the service may know the kind, so this is retried
+		return fmt.Errorf("no handler registered for job kind %q", job.Kind)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return handler(ctx, &Job{
+		ID:      job.ID.String(),
+		Kind:    job.Kind,
+		Payload: json.RawMessage(job.Payload),
+		Attempt: int(job.Attempts),
+	})
+}
+
+// finish records the outcome of an attempt: the job is deleted when it succeeded,
+// retried later when it failed and has attempts left, and dead-lettered otherwise.
+func (p *Pool) finish(ctx context.Context, job *db.Job, jobErr error) error {
+	var permanent *permanentError
+	var outcome string
+	var n int64
+	var err error
+
+	switch {
+	case jobErr == nil:
+		outcome = OutcomeCompleted
+		n, err = p.queries.CompleteJob(ctx, &db.CompleteJobParams{
+			ID:       job.ID,
+			Attempts: job.Attempts,
+		})
+	case errors.As(jobErr, &permanent) || int(job.Attempts) >= p.config.MaxAttempts:
+		outcome = OutcomeDead
+		log.Printf("queue: %s job %s failed on attempt %d, moving it to the dead-letter state: %v", job.Kind, job.ID.String(), job.Attempts, jobErr)
+		n, err = p.queries.DeadLetterJob(ctx, &db.DeadLetterJobParams{
+			ID:        job.ID,
+			Attempts:  job.Attempts,
+			LastError: pgtype.Text{String: jobErr.Error(), Valid: true},
+		})
+	default:
+		outcome = OutcomeRetried
+		delay := p.backoff(int(job.Attempts))
+		log.Printf("queue: %s job %s failed on attempt %d, retrying in %s: %v", job.Kind, job.ID.String(), job.Attempts, delay.Round(time.Second), jobErr)
+		n, err = p.queries.RetryJob(ctx, &db.RetryJobParams{
+			ID:        job.ID,
+			Attempts:  job.Attempts,
+			RunAt:     p.now().Add(delay),
+			LastError: pgtype.Text{String: jobErr.Error(), Valid: true},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record the outcome of job %s: %w", job.ID.String(), err)
+	}
+
+	p.statsMu.Lock()
+	p.processed[ProcessedKey{Kind: job.Kind, Outcome: outcome}]++
+	p.statsMu.Unlock()
+	if n == 0 {
+		log.Printf("queue: %s job %s: the lease expired and another worker took the job over", job.Kind, job.ID.String())
+	}
+
+	return nil
+}
+
+// backoff returns the delay before the retry that follows the given attempt. The
+// base delay doubles with each attempt up to MaxBackoff, and a random part of up to
+// half of it is taken off so that jobs that failed together do not retry together.
+func (p *Pool) backoff(attempt int) time.Duration {
+	delay := p.config.BaseBackoff
+	for i := 1; i < attempt && delay < p.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.config.MaxBackoff {
+		delay = p.config.MaxBackoff
+	}
+
+	half := delay / 2
+	return delay - rand.N(half+1)
+}
@@ -0,0 +1,322 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/db/memdb"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func enqueue(t *testing.T, q db.Querier, kind string) pgtype.UUID {
+	t.Helper()
+
+	id, err := Enqueue(context.Background(), q, kind, map[string]string{"hello": "world"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	var uuid pgtype.UUID
+	if err := uuid.Scan(id); err != nil {
+		t.Fatalf("job ID %q: %v", id, err)
+	}
+	return uuid
+}
+
+func getJob(t *testing.T, q db.Querier, id pgtype.UUID) *db.Job {
+	t.Helper()
+
+	job, err := q.GetJob(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	return job
+}
+
+// work runs one job and fails the test unless a job was claimed.
+func work(t *testing.T, pool *Pool) {
+	t.Helper()
+
+	claimed, err := pool.Work(context.Background())
+	if err != nil {
+		t.Fatalf("Work: %v", err)
+	}
+	if !claimed {
+		t.Fatal("Work did not claim a job")
+	}
+}
+
+func TestEnqueueInTransaction(t *testing.T) {
+	ctx := context.Background()
+	store := memdb.New()
+
+	failure := errors.New("insert failed")
+	err := store.ExecTx(ctx, db.TxOptions{}, func(q db.Querier) error {
+		enqueue(t, q, "greet")
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("ExecTx = %v, want %v", err, failure)
+	}
+
+	jobs, err := store.ListJobsByStatus(ctx, StatusQueued)
+	if err != nil {
+		t.Fatalf("ListJobsByStatus: %v", err)
+	}
+	if len(jobs) != 0 {
+		t.Errorf("rolled back transaction left %d jobs", len(jobs))
+	}
+}
+
+func TestWorkCompletesJob(t *testing.T) {
+	store := memdb.New()
+	pool := NewPool(store, Config{})
+
+	var got *Job
+	pool.Handle("greet", func(ctx context.Context, job *Job) error {
+		got = job
+		return nil
+	})
+
+	id := enqueue(t, store, "greet")
+	work(t, pool)
+
+	if got == nil || got.ID != id.String() || got.Attempt != 1 || string(got.Payload) != `{"hello":"world"}` {
+		t.Errorf("handler got %+v, want the first attempt of job %s", got, id.String())
+	}
+	if _, err := store.GetJob(context.Background(), id); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("completed job was not deleted: %v", err)
+	}
+
+	if claimed, err := pool.Work(context.Background()); claimed || err != nil {
+		t.Errorf("Work on an empty queue = %v, %v, want nothing claimed", claimed, err)
+	}
+}
+
+func TestWorkRetriesWithBackoff(t *testing.T) {
+	store := memdb.New()
+	pool := NewPool(store, Config{MaxAttempts: 3, BaseBackoff: time.Minute, MaxBackoff: time.Hour})
+	id := enqueue(t, store, "flaky")
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+
+	var attempts []int
+	pool.Handle("flaky", func(ctx context.Context, job *Job) error {
+		attempts = append(attempts, job.Attempt)
+		return errors.New("smtp unavailable")
+	})
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		work(t, pool)
+
+		job := getJob(t, store, id)
+		if job.Status != StatusQueued || job.LastError.String != "smtp unavailable" || job.LockedUntil.Valid {
+			t.Fatalf("after attempt %d job = %+v, want it queued again with the error", attempt, job)
+		}
+
+		// The delay doubles per attempt, less up to half of it as jitter
+		max := time.Minute << (attempt - 1)
+		if delay := job.RunAt.Sub(now); delay < max/2 || delay > max {
+			t.Errorf("after attempt %d the retry is in %s, want between %s and %s", attempt, delay, max/2, max)
+		}
+
+		// Not due yet
+		if claimed, _ := pool.Work(context.Background()); claimed {
+			t.Fatalf("a job was claimed before its retry was due")
+		}
+		now = job.RunAt
+	}
+
+	work(t, pool)
+	job := getJob(t, store, id)
+	if job.Status != StatusDead || job.Attempts != 3 || job.LastError.String != "smtp unavailable" {
+		t.Errorf("job = %+v, want it dead after 3 attempts", job)
+	}
+	if len(attempts) != 3 || attempts[2] != 3 {
+		t.Errorf("handler saw attempts %v, want [1 2 3]", attempts)
+	}
+	stats := pool.Stats()
+	if retried, dead := stats.Processed[ProcessedKey{"flaky", OutcomeRetried}], stats.Processed[ProcessedKey{"flaky", OutcomeDead}]; retried != 2 || dead != 1 || stats.InFlight != 0 {
+		t.Errorf("stats = %+v, want 2 retried and 1 dead attempt", stats)
+	}
+
+	// Dead jobs are never claimed again
+	now = now.Add(24 * time.Hour)
+	if claimed, _ := pool.Work(context.Background()); claimed {
+		t.Error("a dead job was claimed")
+	}
+}
+
+func TestWorkFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler Handler
+		status  string
+		error   string
+	}{
+		{
+			name:    "permanent error",
+			handler: func(ctx context.Context, job *Job) error { return Permanent(errors.New("malformed payload")) },
+			status:  StatusDead,
+			error:   "malformed payload",
+		},
+		{
+			name:    "panic",
+			handler: func(ctx context.Context, job *Job) error { panic("nil map") },
+			status:  StatusQueued,
+			error:   "panic: nil map",
+		},
+		{
+			name:   "no handler",
+			status: StatusQueued,
+			error:  `no handler registered for job kind "task"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memdb.New()
+			pool := NewPool(store, Config{})
+			if tt.handler != nil {
+				pool.Handle("task", tt.handler)
+			}
+
+			id := enqueue(t, store, "task")
+			work(t, pool)
+
+			job := getJob(t, store, id)
+			if job.Status != tt.status || job.LastError.String != tt.error {
+				t.Errorf("job is %s with error %q, want %s with %q", job.Status, job.LastError.String, tt.status, tt.error)
+			}
+		})
+	}
+}
+
+func TestWorkTakesOverExpiredLease(t *testing.T) {
+	ctx := context.Background()
+	store := memdb.New()
+	pool := NewPool(store, Config{MaxAttempts: 2, Lease: time.Minute})
+	id := enqueue(t, store, "report")
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+
+	var attempts []int
+	pool.Handle("report", func(ctx context.Context, job *Job) error {
+		attempts = append(attempts, job.Attempt)
+		return nil
+	})
+
+	// A worker claims the job and dies
+	claim := func() *db.Job {
+		job, err := store.ClaimJob(ctx, &db.ClaimJobParams{
+			LockedUntil: pgtype.Timestamptz{Time: now.Add(time.Minute), Valid: true},
+			Now:         now,
+		})
+		if err != nil {
+			t.Fatalf("ClaimJob: %v", err)
+		}
+		return job
+	}
+	stale := claim()
+
+	if claimed, _ := pool.Work(ctx); claimed {
+		t.Fatal("a job was claimed while its lease was held")
+	}
+
+	now = now.Add(time.Minute)
+	work(t, pool)
+	if len(attempts) != 1 || attempts[0] != 2 {
+		t.Fatalf("handler saw attempts %v, want [2]", attempts)
+	}
+
+	// The outcome reported by the dead worker is discarded
+	if n, err := store.CompleteJob(ctx, &db.CompleteJobParams{ID: stale.ID, Attempts: stale.Attempts}); err != nil || n != 0 {
+		t.Errorf("CompleteJob by the stale worker = %d, %v, want no rows", n, err)
+	}
+
+	// A job whose every attempt lost its lease is dead-lettered without running again
+	id = enqueue(t, store, "report")
+	attempts = nil
+	for i := 0; i < 2; i++ {
+		claim()
+		now = now.Add(time.Minute)
+	}
+	work(t, pool)
+	if job := getJob(t, store, id); job.Status != StatusDead || len(attempts) != 0 {
+		t.Errorf("job = %+v after handler calls %v, want it dead without running", job, attempts)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	pool := NewPool(memdb.New(), Config{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{1000, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if d := pool.backoff(tt.attempt); d < tt.max/2 || d > tt.max {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", tt.attempt, d, tt.max/2, tt.max)
+			}
+		}
+	}
+}
+
+func TestRunWorkersShareQueue(t *testing.T) {
+	store := memdb.New()
+	pool := NewPool(store, Config{Workers: 4, PollInterval: time.Millisecond})
+
+	const jobs = 40
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	var done atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool.Handle("count", func(ctx context.Context, job *Job) error {
+		mu.Lock()
+		seen[job.ID]++
+		mu.Unlock()
+		if done.Add(1) == jobs {
+			cancel()
+		}
+		return nil
+	})
+	for i := 0; i < jobs; i++ {
+		enqueue(t, store, "count")
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		pool.Run(ctx)
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("workers ran %d of %d jobs before timing out", done.Load(), jobs)
+	}
+
+	if len(seen) != jobs {
+		t.Errorf("%d distinct jobs ran, want %d", len(seen), jobs)
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("job %s ran %d times", id, n)
+		}
+	}
+}
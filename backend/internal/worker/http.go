@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hungaikev/rootd/backend/internal/queue"
+)
+
+// healthChecker is implemented by stores that can check their connection, such as
+// db.Service.
+type healthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// Handler serves the worker's health and metrics endpoints:
+//
+//	GET /health   200 while the worker runs and reaches the database, 503 otherwise
+//	GET /metrics  the worker's counters and the queue depth in the Prometheus text format
+func (w *Worker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", w.health)
+	mux.HandleFunc("GET /metrics", w.metrics)
+	return mux
+}
+
+func (w *Worker) health(rw http.ResponseWriter, r *http.Request) {
+	status, body := http.StatusOK, map[string]string{"status": "ok"}
+
+	if w.draining.Load() {
+		// Take the worker out of rotation while it finishes its jobs
+		status, body = http.StatusServiceUnavailable, map[string]string{"status": "draining"}
+	} else if checker, ok := w.store.(healthChecker); ok {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+		if err := checker.HealthCheck(ctx); err != nil {
+			log.Printf("worker: health check: %v", err)
+			status, body = http.StatusServiceUnavailable, map[string]string{"status": "database_unavailable"}
+		}
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(body)
+}
+
+func (w *Worker) metrics(rw http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	stats := w.jobs.Stats()
+
+	metric(&buf, "rootd_worker_start_time_seconds", "gauge", "Start time of the worker in seconds since the Unix epoch.")
+	fmt.Fprintf(&buf, "rootd_worker_start_time_seconds %d\n", w.started.Unix())
+
+	metric(&buf, "rootd_worker_draining", "gauge", "1 while the worker is shutting down.")
+	draining := 0
+	if w.draining.Load() {
+		draining = 1
+	}
+	fmt.Fprintf(&buf, "rootd_worker_draining %d\n", draining)
+
+	metric(&buf, "rootd_jobs_in_flight", "gauge", "Jobs this worker is running.")
+	fmt.Fprintf(&buf, "rootd_jobs_in_flight %d\n", stats.InFlight)
+
+	metric(&buf, "rootd_job_attempts_total", "counter", "Job attempts this worker finished, by job kind and outcome.")
+	keys := make([]queue.ProcessedKey, 0, len(stats.Processed))
+	for key := range stats.Processed {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Kind != keys[j].Kind {
+			return keys[i].Kind < keys[j].Kind
+		}
+		return keys[i].Outcome < keys[j].Outcome
+	})
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "rootd_job_attempts_total{kind=\"%s\",outcome=\"%s\"} %d\n", labelValue(key.Kind), labelValue(key.Outcome), stats.Processed[key])
+	}
+
+	// The queue depth is shared by every worker, so it comes from the database
+	counts, err := w.store.CountJobsByStatus(r.Context())
+	if err != nil {
+		log.Printf("worker: metrics: failed to count jobs: %v", err)
+	} else {
+		depth := map[string]int64{queue.StatusQueued: 0, queue.StatusRunning: 0, queue.StatusDead: 0}
+		for _, row := range counts {
+			depth[row.Status] = row.Count
+		}
+		statuses := make([]string, 0, len(depth))
+		for status := range depth {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+
+		metric(&buf, "rootd_jobs", "gauge", "Jobs in the queue, by status.")
+		for _, status := range statuses {
+			fmt.Fprintf(&buf, "rootd_jobs{status=\"%s\"} %d\n", labelValue(status), depth[status])
+		}
+	}
+
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	rw.Write(buf.Bytes())
+}
+
+// metric writes the HELP and TYPE lines that precede the samples of a metric.
+func metric(buf *bytes.Buffer, name, kind, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labelValue(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,104 @@
+// Package worker runs the background processing: queued jobs, which include
+// submissions and workflow runs, and schedule triggers. It runs in cmd/worker,
+// or inside cmd/server for deployments with a single process.
+package worker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hungaikev/rootd/backend/internal/config"
+	"github.com/hungaikev/rootd/backend/internal/db"
+	"github.com/hungaikev/rootd/backend/internal/logic"
+	"github.com/hungaikev/rootd/backend/internal/mail"
+	"github.com/hungaikev/rootd/backend/internal/queue"
+)
+
+// Worker runs the scheduler and the job queue workers, which hand submissions and
+// workflow runs to the execution engine.
+type Worker struct {
+	store     db.Store
+	config    config.WorkerConfig
+	engine    logic.ExecutionEngine
+	scheduler logic.Scheduler
+	jobs      *queue.Pool
+	started   time.Time
+	draining  atomic.Bool
+}
+
+// New creates a worker that processes the queue and workflows stored in store.
+func New(store db.Store, cfg config.WorkerConfig) *Worker {
+	executors := append(logic.DefaultExecutors(), logic.NewWebhookExecutor(store, nil))
+	if cfg.Mail.Host != "" {
+		executors = append(executors, logic.NewEmailExecutor(mail.NewSMTPMailer(cfg.Mail)))
+	}
+	engine := logic.NewExecutionEngine(store, executors...)
+
+	jobs := queue.NewPool(store, cfg.Queue)
+	jobs.Handle(logic.JobProcessSubmission, engine.ProcessSubmissionJob)
+	jobs.Handle(logic.JobRunWorkflow, engine.ProcessRunJob)
+
+	return &Worker{
+		store:     store,
+		config:    cfg,
+		engine:    engine,
+		scheduler: logic.NewScheduler(store),
+		jobs:      jobs,
+		started:   time.Now(),
+	}
+}
+
+// Run processes work until ctx is cancelled, then waits for the jobs and workflow runs
+// in progress to finish before it returns.
+func (w *Worker) Run(ctx context.Context) {
+	stop := context.AfterFunc(ctx, func() {
+		w.draining.Store(true)
+	})
+	defer stop()
+
+	var wg sync.WaitGroup
+	for _, run := range []func(){
+		func() { w.jobs.Run(ctx) },
+		func() { w.scheduler.Run(ctx, w.config.SchedulerPollInterval) },
+		func() { w.pruneActionRuns(ctx) },
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run()
+		}()
+	}
+	wg.Wait()
+}
+
+// pruneInterval is how often execution log entries past their retention are removed.
+const pruneInterval = time.Hour
+
+// pruneActionRuns removes the execution log entries older than the configured
+// retention every pruneInterval until ctx is cancelled.
+func (w *Worker) pruneActionRuns(ctx context.Context) {
+	if w.config.ActionRunRetention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := w.engine.PruneActionRuns(ctx, time.Now().Add(-w.config.ActionRunRetention))
+		if err != nil {
+			log.Printf("worker: %v", err)
+		} else if n > 0 {
+			log.Printf("worker: removed %d action runs older than %s", n, w.config.ActionRunRetention)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hungaikev/rootd/backend/internal/config"
+	"github.com/hungaikev/rootd/backend/internal/db/memdb"
+	"github.com/hungaikev/rootd/backend/internal/queue"
+)
+
+// pingingStore is a memdb store with a database health check.
+type pingingStore struct {
+	*memdb.Queries
+	err error
+}
+
+func (s *pingingStore) HealthCheck(ctx context.Context) error {
+	return s.err
+}
+
+func newTestWorker(store *pingingStore) *Worker {
+	return New(store, config.WorkerConfig{
+		SchedulerPollInterval: time.Millisecond,
+		Queue:                 queue.Config{Workers: 2, PollInterval: time.Millisecond},
+	})
+}
+
+func get(t *testing.T, handler http.Handler, path string) (int, string) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	body, _ := io.ReadAll(rec.Body)
+	return rec.Code, string(body)
+}
+
+func TestHealth(t *testing.T) {
+	store := &pingingStore{Queries: memdb.New()}
+	w := newTestWorker(store)
+
+	status := func() (int, string) {
+		code, body := get(t, w.Handler(), "/health")
+		var got map[string]string
+		if err := json.Unmarshal([]byte(body), &got); err != nil {
+			t.Fatalf("health body %q: %v", body, err)
+		}
+		return code, got["status"]
+	}
+
+	if code, s := status(); code != http.StatusOK || s != "ok" {
+		t.Errorf("health = %d %s, want 200 ok", code, s)
+	}
+
+	store.err = errors.New("connection refused")
+	if code, s := status(); code != http.StatusServiceUnavailable || s != "database_unavailable" {
+		t.Errorf("health with the database down = %d %s, want 503 database_unavailable", code, s)
+	}
+
+	store.err = nil
+	w.draining.Store(true)
+	if code, s := status(); code != http.StatusServiceUnavailable || s != "draining" {
+		t.Errorf("health while draining = %d %s, want 503 draining", code, s)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	store := &pingingStore{Queries: memdb.New()}
+	w := newTestWorker(store)
+	w.jobs.Handle("greet", func(ctx context.Context, job *queue.Job) error { return nil })
+
+	for _, kind := range []string{"greet", "greet", "unknown"} {
+		if _, err := queue.Enqueue(ctx, store, kind, struct{}{}); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := w.jobs.Work(ctx); err != nil {
+			t.Fatalf("Work: %v", err)
+		}
+	}
+
+	code, body := get(t, w.Handler(), "/metrics")
+	if code != http.StatusOK {
+		t.Fatalf("metrics = %d, want 200", code)
+	}
+	for _, want := range []string{
+		"# TYPE rootd_job_attempts_total counter\n",
+		"rootd_job_attempts_total{kind=\"greet\",outcome=\"completed\"} 2\n",
+		"rootd_job_attempts_total{kind=\"unknown\",outcome=\"retried\"} 1\n",
+		"rootd_jobs_in_flight 0\n",
+		"rootd_jobs{status=\"dead\"} 0\n",
+		"rootd_jobs{status=\"queued\"} 1\n",
+		"rootd_jobs{status=\"running\"} 0\n",
+		"rootd_worker_draining 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics are missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestRunFinishesInFlightJobs(t *testing.T) {
+	store := &pingingStore{Queries: memdb.New()}
+	w := newTestWorker(store)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var finished bool
+	w.jobs.Handle("slow", func(ctx context.Context, job *queue.Job) error {
+		close(started)
+		<-release
+		finished = ctx.Err() == nil
+		return nil
+	})
+	if _, err := queue.Enqueue(context.Background(), store, "slow", struct{}{}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case <-done:
+		t.Fatal("Run returned while a job was in flight")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if code, _ := get(t, w.Handler(), "/health"); code != http.StatusServiceUnavailable {
+		t.Errorf("health while draining = %d, want 503", code)
+	}
+
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the job finished")
+	}
+	if !finished {
+		t.Error("the in-flight job's context was cancelled on shutdown")
+	}
+
+	if counts, _ := store.CountJobsByStatus(context.Background()); len(counts) != 0 {
+		t.Errorf("jobs left after shutdown: %+v", counts)
+	}
+}